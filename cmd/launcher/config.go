@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"uv_server/internal/uv_server/common"
+)
+
+const (
+	configFileName = "launcher.yaml"
+
+	channelStable     = "stable"
+	channelPreRelease = "pre-release"
+)
+
+// Config of the launcher, it is read from launcher.yaml in the working
+// directory, defaults are used when the file does not exist
+type Config struct {
+	// Releases updated to, stable or pre-release
+	Channel string `yaml:"channel"`
+	// Version to run instead of the latest one of the channel,
+	// it is installed even when it is older than the current one,
+	// the database is migrated down to its version first
+	PinnedVersion string `yaml:"pinnedVersion"`
+	// Base url of releases, GitHub is used when it is empty
+	ReleaseUrl string `yaml:"releaseUrl"`
+	// The installed version is started without checking for updates
+	NoUpdate bool `yaml:"noUpdate"`
+}
+
+func LoadConfig(path string, explicit bool) (*Config, error) {
+	config := &Config{}
+
+	file, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		fmt.Printf("config file %v is not found, using defaults\n", path)
+	} else if err != nil {
+		return nil, err
+	} else {
+		err = common.UnmarshalYamlStrict(file, config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config %v: %v", path, err)
+		}
+	}
+
+	if config.Channel == "" {
+		config.Channel = channelStable
+	}
+
+	if config.Channel != channelStable && config.Channel != channelPreRelease {
+		return nil, fmt.Errorf(
+			"invalid channel %q, expected %q or %q",
+			config.Channel, channelStable, channelPreRelease)
+	}
+
+	if config.PinnedVersion != "" {
+		_, err := ParseSemVer(config.PinnedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pinned version: %v", err)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), configFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, "channel: pre-release\npinnedVersion: v1.2.0\nnoUpdate: true\n")
+
+	config, err := LoadConfig(path, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Channel: channelPreRelease, PinnedVersion: "v1.2.0", NoUpdate: true}, config)
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+
+	config, err := LoadConfig(path, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Channel: channelStable}, config)
+
+	_, err = LoadConfig(path, true)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"channel":        "channel: nightly\n",
+		"pinned version": "pinnedVersion: latest\n",
+		"unknown field":  "chanel: stable\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, content), true)
+			assert.Error(t, err)
+		})
+	}
+}
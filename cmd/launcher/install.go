@@ -0,0 +1,271 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	versionFileName = "version"
+	versionsDirName = "versions"
+	// The legacy installation kept the data of the server next to its
+	// binary, the directory stays the home of the server, so the
+	// library survives switching to versioned installations
+	homeDirName = "server"
+)
+
+// Installation keeps every version in its own directory under
+// versions, the version file names the one which is run. Versions
+// installed before are run from the working directory itself
+type Installation struct {
+	wd string
+}
+
+func NewInstallation(wd string) *Installation {
+	object := &Installation{}
+	object.wd = wd
+	return object
+}
+
+func (i *Installation) Current() (string, error) {
+	data, err := os.ReadFile(filepath.Join(i.wd, versionFileName))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Dir returns the directory containing server and client
+// directories of the version
+func (i *Installation) Dir(version string) string {
+	dir := i.versionDir(version)
+
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return i.wd
+	}
+
+	return dir
+}
+
+// Installed reports whether the version has its own directory
+func (i *Installation) Installed(version string) bool {
+	info, err := os.Stat(i.versionDir(version))
+	return err == nil && info.IsDir()
+}
+
+func (i *Installation) HomeDir() string {
+	return filepath.Join(i.wd, homeDirName)
+}
+
+func (i *Installation) versionDir(version string) string {
+	return filepath.Join(i.wd, versionsDirName, version)
+}
+
+// Install downloads and unpacks the release into a staging directory
+// which is renamed to the version once it is complete, nothing is left
+// behind on failure. Tags of server and client may differ in format
+func (i *Installation) Install(server SemVer, client SemVer, releases *Releases) error {
+	version := server.String()
+
+	versionsDir := filepath.Join(i.wd, versionsDirName)
+
+	err := os.MkdirAll(versionsDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp(versionsDir, fmt.Sprintf(".%v-staging-", version))
+	if err != nil {
+		return err
+	}
+
+	err = installAssets(staging, server, client, releases)
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	// An earlier attempt may have been interrupted after the rename
+	// but before the switch
+	dir := i.versionDir(version)
+	err = os.RemoveAll(dir)
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	err = os.Rename(staging, dir)
+	if err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+
+	return nil
+}
+
+func installAssets(staging string, server SemVer, client SemVer, releases *Releases) error {
+	assets := []struct {
+		repo    string
+		version SemVer
+		name    string
+		dir     string
+	}{
+		{serverRepo, server, "server.zip", "server"},
+		{clientRepo, client, "client.zip", "client"},
+	}
+
+	for _, asset := range assets {
+		archive := filepath.Join(staging, asset.name)
+
+		err := releases.Download(asset.repo, asset.version.String(), asset.name, archive)
+		if err != nil {
+			return err
+		}
+
+		err = unzipSource(archive, staging)
+		if err != nil {
+			return fmt.Errorf("failed to unpack %v: %v", asset.name, err)
+		}
+
+		err = os.Remove(archive)
+		if err != nil {
+			return err
+		}
+
+		info, err := os.Stat(filepath.Join(staging, asset.dir))
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("%v does not contain %v directory", asset.name, asset.dir)
+		}
+	}
+
+	return nil
+}
+
+// Switch makes the version current, the version file is replaced
+// atomically, so it names either the old or the new version
+func (i *Installation) Switch(version string) error {
+	path := filepath.Join(i.wd, versionFileName)
+	tmp := path + ".tmp"
+
+	err := writeFileSync(tmp, []byte(version))
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// Cleanup removes installed versions except the kept ones
+func (i *Installation) Cleanup(keep ...string) {
+	entries, err := os.ReadDir(filepath.Join(i.wd, versionsDirName))
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || contains(keep, entry.Name()) {
+			continue
+		}
+
+		fmt.Printf("removing version %v\n", entry.Name())
+
+		err := os.RemoveAll(filepath.Join(i.wd, versionsDirName, entry.Name()))
+		if err != nil {
+			fmt.Printf("failed to remove version %v: %v\n", entry.Name(), err)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(data)
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func unzipSource(source, destination string) error {
+	reader, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	destination, err = filepath.Abs(destination)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range reader.File {
+		err := unzipFile(f, destination)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unzipFile(f *zip.File, destination string) error {
+	filePath := filepath.Join(destination, f.Name)
+	if !strings.HasPrefix(filePath, filepath.Clean(destination)+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid file path: %s", filePath)
+	}
+
+	if f.FileInfo().IsDir() {
+		if err := os.MkdirAll(filePath, os.ModePerm); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	destinationFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+	defer destinationFile.Close()
+
+	zippedFile, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer zippedFile.Close()
+
+	if _, err := io.Copy(destinationFile, zippedFile); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,364 +1,298 @@
 package main
 
 import (
-	"archive/zip"
-	"bytes"
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
-	"path"
+	"os/signal"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"syscall"
+)
+
+var (
+	configPath = flag.String("config", "", "config file (default is "+configFileName+" in the working directory)")
+	releaseUrl = flag.String(
+		"release-url",
+		"",
+		"base url of releases, tags are fetched from <url>/repos/denbykov/<repo>/tags\n"+
+			"and assets from <url>/denbykov/<repo>/releases/download/<tag>/<asset> (default is GitHub)",
+	)
+	noUpdate = flag.Bool("no-update", false, "start the installed version without checking for updates")
 )
 
 func main() {
-	err := updateIfNeeded()
+	flag.Parse()
+
+	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("error: %v", err)
 		return
 	}
 
-	err = start()
+	config, err := loadConfig(wd)
 	if err != nil {
 		fmt.Printf("error: %v", err)
 		return
 	}
-}
 
-func start() error {
-	fmt.Println("starting...")
-
-	wd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
+	installation := NewInstallation(wd)
 
-	err = startServer(wd)
-	if err != nil {
-		return err
-	}
+	previous := ""
+	if config.NoUpdate {
+		fmt.Println("updates are disabled")
+	} else {
+		previous, err = updateIfNeeded(installation, NewReleases(config.ReleaseUrl), config)
+		if err != nil {
+			// Being offline or a broken release must not keep
+			// the installed version from starting
+			if _, currentErr := installation.Current(); currentErr != nil {
+				fmt.Printf("error: %v", err)
+				return
+			}
 
-	err = startClient(wd)
-	if err != nil {
-		return err
+			fmt.Printf("update failed: %v\n", err)
+			fmt.Println("starting the installed version")
+		}
 	}
 
-	fmt.Println("starting completed")
+	err = start(installation)
+	if err != nil && previous != "" {
+		fmt.Printf("updated version failed: %v\n", err)
+		fmt.Printf("rolling back to %v\n", previous)
 
-	return nil
-}
+		err = rollBack(installation, previous)
+		if err != nil {
+			fmt.Printf("error: failed to roll back: %v", err)
+			return
+		}
 
-func startServer(wd string) error {
-	path := path.Join(wd, "server", "uv_server")
-	cmd := exec.Command(path)
-	cmd.Dir = "server"
-	err := cmd.Start()
-	if err != nil {
-		return fmt.Errorf("failed to run server: %v", err)
+		err = start(installation)
 	}
 
-	return nil
-}
-
-func startClient(wd string) error {
-	path := path.Join(wd, "client", "uv-client")
-	cmd := exec.Command(path)
-	cmd.Dir = "client"
-	err := cmd.Start()
 	if err != nil {
-		return fmt.Errorf("failed to run client: %v", err)
+		fmt.Printf("error: %v", err)
+		return
 	}
-
-	return nil
 }
 
-func updateIfNeeded() error {
-	fmt.Println("checking for updates")
-	serverTagsUrl := "https://api.github.com/repos/denbykov/uv-server/tags"
-	serverVersion, err := getLatestVersion(serverTagsUrl)
+// rollBack makes the previous version current again, the database
+// may be migrated by the failed version, so it is brought back to
+// the version the previous one requires first. The failed version
+// stays current when this is not possible
+func rollBack(installation *Installation, previous string) error {
+	current, err := installation.Current()
 	if err != nil {
-		return fmt.Errorf("failed to get latest server version: %v", err)
+		return err
 	}
-	fmt.Printf("latest server version: %v\n", serverVersion)
 
-	clientTagsUrl := "https://api.github.com/repos/denbykov/uv-client/tags"
-	clientVersion, err := getLatestVersion(clientTagsUrl)
+	err = prepareDowngrade(installation, current, previous)
 	if err != nil {
-		return fmt.Errorf("failed to get latest client version: %v", err)
+		return fmt.Errorf(
+			"database cannot be used by %v, restore a backup taken before the update: %v",
+			previous, err)
 	}
-	fmt.Printf("latest client version: %v\n", clientVersion)
 
-	versionFileName := "version"
-
-	currentVersion, err := readVersion(versionFileName)
-
-	if err == nil {
-		fmt.Printf("current version: %v\n", currentVersion)
-
-		if compareSemVer(serverVersion, clientVersion) == 0 &&
-			compareSemVer(currentVersion, serverVersion) == -1 {
-			fmt.Printf("updating to %v\n", serverVersion)
-
-			err := installLatestVersion(serverVersion)
-			if err != nil {
-				return err
-			}
-
-			err = writeVersion(versionFileName, serverVersion)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("update completed")
-		}
-	} else {
-		fmt.Println("current version is not set")
-
-		if compareSemVer(serverVersion, clientVersion) == 0 {
-			fmt.Printf("updating to %v\n", serverVersion)
-
-			err := installLatestVersion(serverVersion)
-			if err != nil {
-				return err
-			}
-
-			err = writeVersion(versionFileName, serverVersion)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("update completed")
-		} else {
-			return fmt.Errorf("unable to install latest version, client and server version mismatch")
-		}
-	}
-
-	return nil
+	return installation.Switch(previous)
 }
 
-func installLatestVersion(version string) error {
-	err := installServer(version)
-	if err != nil {
-		return err
+// loadConfig reads the config file, flags take precedence over it
+func loadConfig(wd string) (*Config, error) {
+	path := *configPath
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(wd, configFileName)
 	}
 
-	return installClient(version)
-}
-
-func writeVersion(filename, version string) error {
-	return os.WriteFile(filename, []byte(version), 0644)
-}
-
-func readVersion(filename string) (string, error) {
-	data, err := os.ReadFile(filename)
+	config, err := LoadConfig(path, explicit)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return strings.TrimSpace(string(data)), nil
-}
 
-func compareSemVer(v1, v2 string) int {
-	split1 := strings.Split(v1, ".")
-	split2 := strings.Split(v2, ".")
-
-	for i := 0; i < 3; i++ {
-		n1, _ := strconv.Atoi(split1[i])
-		n2, _ := strconv.Atoi(split2[i])
-
-		if n1 > n2 {
-			return 1
-		} else if n1 < n2 {
-			return -1
-		}
+	if *releaseUrl != "" {
+		config.ReleaseUrl = *releaseUrl
 	}
-	return 0
-}
 
-type Commit struct {
-	SHA string `json:"sha"`
-	URL string `json:"url"`
-}
-type Version struct {
-	Name       string `json:"name"`
-	ZipballURL string `json:"zipball_url"`
-	TarballURL string `json:"tarball_url"`
-	Commit     Commit `json:"commit"`
-	NodeID     string `json:"node_id"`
-}
-
-func getLatestVersion(url string) (tag string, err error) {
-	tags, err := getTags(url)
-	if err != nil {
-		return tag, err
+	if *noUpdate {
+		config.NoUpdate = true
 	}
 
-	tag = tags[0].Name
-	return tag, err
+	return config, nil
 }
 
-func getTags(url string) (tags []Version, err error) {
-	resp, err := http.Get(url)
+func start(installation *Installation) error {
+	fmt.Println("starting...")
+
+	version, err := installation.Current()
 	if err != nil {
-		return tags, err
+		return fmt.Errorf("failed to get current version: %v", err)
 	}
-	defer resp.Body.Close()
 
-	var buf bytes.Buffer
+	dir := installation.Dir(version)
+	fmt.Printf("running version %v from %v\n", version, dir)
 
-	_, err = io.Copy(&buf, resp.Body)
+	homeDir := installation.HomeDir()
+	err = os.MkdirAll(homeDir, 0755)
 	if err != nil {
-		return tags, err
+		return err
 	}
 
-	err = json.Unmarshal(buf.Bytes(), &tags)
-
-	return tags, err
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func installServer(tag string) error {
-	url := fmt.Sprintf("https://github.com/denbykov/uv-server/releases/download/%v/server.zip", tag)
-	err := downloadFile(url, "server.zip")
+	port, err := freePort()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to find a port for server: %v", err)
 	}
 
-	err = unzipSource("server.zip", ".")
+	token, err := newAuthToken()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to generate auth token: %v", err)
 	}
 
-	err = os.Remove("server.zip")
-	return err
-}
-
-func installClient(tag string) error {
-	url := fmt.Sprintf("https://github.com/denbykov/uv-client/releases/download/%v/client.zip", tag)
-	err := downloadFile(url, "client.zip")
+	server := NewServerSupervisor(dir, homeDir, port, token)
+	err = server.Start(ctx)
+	if ctx.Err() != nil {
+		// Interrupted by the user
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	err = unzipSource("client.zip", ".")
+	client, err := startClient(dir, port, token)
 	if err != nil {
+		server.Stop()
 		return err
 	}
 
-	err = os.Remove("client.zip")
-	return err
-}
-
-func downloadFile(url, destination string) error {
-	fmt.Printf("downloading file from url: %v\n", url)
-	fmt.Printf("destination is: %v\n", destination)
-
-	if info, err := os.Stat(destination); err == nil {
-		if info.IsDir() {
-			return fmt.Errorf("requested path is a directory")
-		}
-
-		if err = os.Remove(destination); err != nil {
-			return err
-		}
-	}
+	fmt.Println("starting completed")
 
-	out, err := os.Create(destination)
+	// The server is supervised as long as the client runs
+	done, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-client.exited
+		fmt.Printf("client exited: %v\n", client.err)
+		cancel()
+	}()
 
+	err = server.Supervise(done)
 	if err != nil {
+		client.Stop()
 		return err
 	}
-	defer out.Close()
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	client.Stop()
+	fmt.Println("stopped")
 
-	size, _ := strconv.Atoi(resp.Header.Get("Content-Length"))
+	return nil
+}
 
-	reader := &ProgressReader{
-		Reader: resp.Body,
-		Total:  int64(size),
+func startClient(dir string, port int, token string) (*Process, error) {
+	clientDir := filepath.Join(dir, "client")
+	env := []string{
+		fmt.Sprintf("%v=%v", portEnv, port),
+		fmt.Sprintf("%v=%v", authTokenEnv, token),
 	}
 
-	_, err = io.Copy(out, reader)
-
-	// extra spaces to remove potential garbage from progess display
-	fmt.Printf("donwloading done             ")
-	return err
-}
+	client, err := StartProcess(filepath.Join(clientDir, "uv-client"), clientDir, nil, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run client: %v", err)
+	}
 
-type ProgressReader struct {
-	Reader io.Reader
-	Total  int64
-	Count  int64
+	return client, nil
 }
 
-func (p *ProgressReader) Read(b []byte) (int, error) {
-	n, err := p.Reader.Read(b)
-	p.Count += int64(n)
-	fmt.Printf("Downloading... %d%%", (p.Count*100)/p.Total)
-	fmt.Printf("\r")
-	return n, err
-}
+// updateIfNeeded installs the target version once both server and
+// client are released with it, the version which was current before
+// the update is returned, it is empty when nothing is updated
+func updateIfNeeded(installation *Installation, releases *Releases, config *Config) (string, error) {
+	fmt.Println("checking for updates")
 
-func unzipSource(source, destination string) error {
-	reader, err := zip.OpenReader(source)
+	serverVersion, err := targetVersion(releases, serverRepo, config)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get server version: %v", err)
 	}
-	defer reader.Close()
+	fmt.Printf("target server version: %v\n", serverVersion)
 
-	destination, err = filepath.Abs(destination)
+	clientVersion, err := targetVersion(releases, clientRepo, config)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to get client version: %v", err)
 	}
+	fmt.Printf("target client version: %v\n", clientVersion)
 
-	for _, f := range reader.File {
-		err := unzipFile(f, destination)
-		if err != nil {
-			return err
+	currentVersion, err := installation.Current()
+
+	if err == nil {
+		fmt.Printf("current version: %v\n", currentVersion)
+
+		if serverVersion.Compare(clientVersion) != 0 {
+			return "", nil
 		}
-	}
 
-	return nil
-}
+		current, err := ParseSemVer(currentVersion)
+		if err != nil {
+			fmt.Printf("current version is invalid: %v\n", err)
+		} else if config.PinnedVersion != "" && current.Compare(serverVersion) == 0 {
+			return "", nil
+		} else if config.PinnedVersion == "" && current.Compare(serverVersion) >= 0 {
+			return "", nil
+		}
+	} else {
+		fmt.Println("current version is not set")
 
-func unzipFile(f *zip.File, destination string) error {
-	filePath := filepath.Join(destination, f.Name)
-	if !strings.HasPrefix(filePath, filepath.Clean(destination)+string(os.PathSeparator)) {
-		return fmt.Errorf("invalid file path: %s", filePath)
+		if serverVersion.Compare(clientVersion) != 0 {
+			return "", fmt.Errorf("unable to install latest version, client and server version mismatch")
+		}
 	}
 
-	if f.FileInfo().IsDir() {
-		if err := os.MkdirAll(filePath, os.ModePerm); err != nil {
-			return err
+	if installation.Installed(serverVersion.String()) {
+		// The version is kept after an update, so switching back
+		// to it needs no download
+		fmt.Printf("switching to installed %v\n", serverVersion)
+	} else {
+		fmt.Printf("updating to %v\n", serverVersion)
+
+		err = installation.Install(serverVersion, clientVersion, releases)
+		if err != nil {
+			return "", err
 		}
-		return nil
 	}
 
-	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-		return err
+	current, err := ParseSemVer(currentVersion)
+	if err == nil && serverVersion.Compare(current) < 0 {
+		// A pinned version may be older than the database
+		err = prepareDowngrade(installation, currentVersion, serverVersion.String())
+		if err != nil {
+			return "", fmt.Errorf("unable to switch to older %v: %v", serverVersion, err)
+		}
 	}
 
-	destinationFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	err = installation.Switch(serverVersion.String())
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer destinationFile.Close()
 
-	zippedFile, err := f.Open()
-	if err != nil {
-		return err
-	}
-	defer zippedFile.Close()
+	// The previous version is kept to roll back to
+	installation.Cleanup(serverVersion.String(), currentVersion)
 
-	if _, err := io.Copy(destinationFile, zippedFile); err != nil {
-		return err
+	fmt.Println("update completed")
+
+	return currentVersion, nil
+}
+
+// targetVersion is the pinned version when there is one,
+// otherwise the latest version of the channel
+func targetVersion(releases *Releases, repo string, config *Config) (SemVer, error) {
+	if config.PinnedVersion != "" {
+		pinned, err := ParseSemVer(config.PinnedVersion)
+		if err != nil {
+			return SemVer{}, err
+		}
+
+		return releases.FindVersion(repo, pinned)
 	}
-	return nil
+
+	return releases.LatestVersion(repo, config.Channel == channelPreRelease)
 }
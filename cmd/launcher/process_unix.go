@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startInProcessGroup keeps the child in the group of the launcher,
+// signals are delivered to a single process on unix anyway
+func startInProcessGroup(cmd *exec.Cmd) {}
+
+func interrupt(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+// killProcessTree kills the child only, the server stops its
+// children itself whenever it is able to
+func killProcessTree(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
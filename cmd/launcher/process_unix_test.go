@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess_StopInterrupts(t *testing.T) {
+	// The script exits with a distinct code only when interrupted
+	script := `trap 'exit 3' INT; while true; do sleep 0.1; done`
+
+	process, err := StartProcess("/bin/sh", t.TempDir(), []string{"-c", script}, nil)
+	require.NoError(t, err)
+
+	// Lets the shell install the trap
+	time.Sleep(200 * time.Millisecond)
+
+	startedAt := time.Now()
+	process.Stop()
+
+	assert.Less(t, time.Since(startedAt), stopTimeout)
+	assert.Equal(t, 3, process.cmd.ProcessState.ExitCode())
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// startInProcessGroup makes the child a root of a new process group,
+// so console events can be sent to it alone
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// interrupt sends CTRL_BREAK to the process group of the child, Go
+// programs receive it as os.Interrupt. It fails when the launcher has
+// no console shared with the child
+func interrupt(cmd *exec.Cmd) error {
+	return windows.GenerateConsoleCtrlEvent(
+		windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid))
+}
+
+// killProcessTree kills the child and every process it has spawned,
+// downloaders of the server are started in process groups of their own
+// and would be orphaned otherwise
+func killProcessTree(cmd *exec.Cmd) error {
+	err := exec.Command(
+		"taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid),
+	).Run()
+	if err != nil {
+		return cmd.Process.Kill()
+	}
+
+	return nil
+}
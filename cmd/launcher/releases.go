@@ -0,0 +1,288 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	githubApiUrl      = "https://api.github.com"
+	githubDownloadUrl = "https://github.com"
+
+	// Update checks give up on unreachable servers after this time,
+	// so the installed version is started without a long wait
+	checkTimeout = 15 * time.Second
+
+	owner      = "denbykov"
+	serverRepo = "uv-server"
+	clientRepo = "uv-client"
+)
+
+type Commit struct {
+	SHA string `json:"sha"`
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name       string `json:"name"`
+	ZipballURL string `json:"zipball_url"`
+	TarballURL string `json:"tarball_url"`
+	Commit     Commit `json:"commit"`
+	NodeID     string `json:"node_id"`
+}
+
+// Releases fetches tags and release assets, each asset is published
+// together with a <asset>.sha256 file holding its checksum
+type Releases struct {
+	apiUrl      string
+	downloadUrl string
+
+	// Used for everything except downloads of assets
+	client *http.Client
+}
+
+// NewReleases uses GitHub when baseUrl is empty, otherwise tags are
+// fetched from <baseUrl>/repos/<owner>/<repo>/tags and assets from
+// <baseUrl>/<owner>/<repo>/releases/download/<tag>/<asset>
+func NewReleases(baseUrl string) *Releases {
+	object := &Releases{}
+	object.client = &http.Client{Timeout: checkTimeout}
+
+	if baseUrl == "" {
+		object.apiUrl = githubApiUrl
+		object.downloadUrl = githubDownloadUrl
+	} else {
+		object.apiUrl = strings.TrimSuffix(baseUrl, "/")
+		object.downloadUrl = object.apiUrl
+	}
+
+	return object
+}
+
+// LatestVersion returns the greatest released version of the repo,
+// tags which are not versions are skipped, so are pre-releases unless
+// they are asked for
+func (r *Releases) LatestVersion(repo string, preReleases bool) (SemVer, error) {
+	versions, err := r.getVersions(repo)
+	if err != nil {
+		return SemVer{}, err
+	}
+
+	var latest SemVer
+	found := false
+
+	for _, version := range versions {
+		if version.IsPreRelease() && !preReleases {
+			continue
+		}
+
+		if !found || version.Compare(latest) > 0 {
+			latest = version
+			found = true
+		}
+	}
+
+	if !found {
+		return latest, fmt.Errorf("no released versions of %v", repo)
+	}
+
+	return latest, nil
+}
+
+// FindVersion returns the tag of the repo matching the version,
+// so the format of the tag does not matter
+func (r *Releases) FindVersion(repo string, version SemVer) (SemVer, error) {
+	versions, err := r.getVersions(repo)
+	if err != nil {
+		return SemVer{}, err
+	}
+
+	for _, v := range versions {
+		if v.Compare(version) == 0 {
+			return v, nil
+		}
+	}
+
+	return SemVer{}, fmt.Errorf("version %v of %v is not released", version, repo)
+}
+
+func (r *Releases) getVersions(repo string) ([]SemVer, error) {
+	tags, err := r.getTags(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]SemVer, 0, len(tags))
+
+	for _, tag := range tags {
+		version, err := ParseSemVer(tag.Name)
+		if err != nil {
+			fmt.Printf("skipping tag %v: %v\n", tag.Name, err)
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+func (r *Releases) getTags(repo string) (tags []Tag, err error) {
+	url := fmt.Sprintf("%v/repos/%v/%v/tags?per_page=100", r.apiUrl, owner, repo)
+
+	resp, err := get(r.client, url)
+	if err != nil {
+		return tags, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&tags)
+
+	return tags, err
+}
+
+// Download saves the asset of the release to destination, the file
+// is removed when its checksum does not match the published one
+func (r *Releases) Download(repo string, tag string, asset string, destination string) error {
+	url := fmt.Sprintf("%v/%v/%v/releases/download/%v/%v", r.downloadUrl, owner, repo, tag, asset)
+
+	expected, err := getChecksum(r.client, url+".sha256")
+	if err != nil {
+		return fmt.Errorf("failed to get checksum of %v: %v", asset, err)
+	}
+
+	actual, err := downloadFile(url, destination)
+	if err != nil {
+		os.Remove(destination)
+		return err
+	}
+
+	if actual != expected {
+		os.Remove(destination)
+		return fmt.Errorf("checksum mismatch of %v: expected %v, got %v", asset, expected, actual)
+	}
+
+	return nil
+}
+
+// getChecksum reads a checksum file in the format of sha256sum,
+// only the first field is used
+func getChecksum(client *http.Client, url string) (string, error) {
+	resp, err := get(client, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file is empty")
+	}
+
+	checksum := strings.ToLower(fields[0])
+
+	decoded, err := hex.DecodeString(checksum)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum: %q", fields[0])
+	}
+
+	return checksum, nil
+}
+
+func get(client *http.Client, url string) (*http.Response, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get %v: %v", url, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// downloadFile returns the checksum of the downloaded file
+func downloadFile(url, destination string) (string, error) {
+	fmt.Printf("downloading file from url: %v\n", url)
+	fmt.Printf("destination is: %v\n", destination)
+
+	if info, err := os.Stat(destination); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("requested path is a directory")
+		}
+
+		if err = os.Remove(destination); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := os.Create(destination)
+
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	resp, err := get(http.DefaultClient, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	size, _ := strconv.Atoi(resp.Header.Get("Content-Length"))
+
+	reader := &ProgressReader{
+		Reader: resp.Body,
+		Total:  int64(size),
+	}
+
+	hash := sha256.New()
+
+	_, err = io.Copy(io.MultiWriter(out, hash), reader)
+	if err != nil {
+		return "", err
+	}
+
+	err = out.Sync()
+	if err != nil {
+		return "", err
+	}
+
+	// extra spaces to remove potential garbage from progess display
+	fmt.Println("downloading done             ")
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type ProgressReader struct {
+	Reader io.Reader
+	Total  int64
+	Count  int64
+}
+
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.Reader.Read(b)
+	p.Count += int64(n)
+	if p.Total > 0 {
+		fmt.Printf("Downloading... %d%%", (p.Count*100)/p.Total)
+	} else {
+		// The size is not known without Content-Length
+		fmt.Printf("Downloading... %d bytes", p.Count)
+	}
+	fmt.Printf("\r")
+	return n, err
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The server refuses to start without a port, maintenance commands
+// never listen on it
+const maintenancePort = 1
+
+// prepareDowngrade migrates the database down to the version required
+// by the older version of the server. Only the newer server knows how
+// to revert its migrations, so it is run before switching, it backs
+// the database up before migrating
+func prepareDowngrade(installation *Installation, newer string, older string) error {
+	current, _, err := schemaVersions(installation, newer)
+	if err != nil {
+		return fmt.Errorf("failed to get database version of %v: %v", newer, err)
+	}
+
+	_, required, err := schemaVersions(installation, older)
+	if err != nil {
+		return fmt.Errorf("failed to get database version required by %v: %v", older, err)
+	}
+
+	if current <= required {
+		return nil
+	}
+
+	fmt.Printf("migrating database from version %v down to %v\n", current, required)
+
+	_, err = runServerCommand(installation, newer, "migrate", "--to", strconv.Itoa(required))
+	if err != nil {
+		return fmt.Errorf("failed to migrate database down to version %v: %v", required, err)
+	}
+
+	return nil
+}
+
+// schemaVersions returns the version of the database and the version
+// required by the server of the installed version
+func schemaVersions(installation *Installation, version string) (int, int, error) {
+	output, err := runServerCommand(installation, version, "db", "version")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	current, currentErr := parseVersionLine(output, "database version:")
+	required, requiredErr := parseVersionLine(output, "required version:")
+	if err := errors.Join(currentErr, requiredErr); err != nil {
+		return 0, 0, err
+	}
+
+	return current, required, nil
+}
+
+func parseVersionLine(output string, prefix string) (int, error) {
+	for _, line := range strings.Split(output, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), prefix)
+		if ok {
+			return strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+
+	return 0, fmt.Errorf("%q is not reported", prefix)
+}
+
+// runServerCommand runs a command of the server of the version against
+// the home directory and returns its output
+func runServerCommand(installation *Installation, version string, args ...string) (string, error) {
+	path := filepath.Join(installation.Dir(version), "server", "uv_server")
+	args = append(
+		[]string{"--home", installation.HomeDir(), "--port", strconv.Itoa(maintenancePort)},
+		args...)
+
+	output, err := exec.Command(path, args...).Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) != 0 {
+		return "", fmt.Errorf("%v: %v", err, strings.TrimSpace(string(exitErr.Stderr)))
+	} else if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
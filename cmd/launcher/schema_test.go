@@ -0,0 +1,173 @@
+//go:build !windows
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fake server keeps the database version in the db file of the home
+// directory and reverts it to any version it is asked to
+const fakeServerScript = `#!/bin/sh
+home="$2"
+shift 4
+case "$1" in
+	db)
+		echo "database version: $(cat "$home/db")"
+		echo "required version: %v"
+		;;
+	migrate)
+		echo "$3" > "$home/db"
+		;;
+esac
+`
+
+func installFakeServer(t *testing.T, installation *Installation, version string, required int) {
+	dir := filepath.Join(installation.versionDir(version), "server")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, "uv_server"),
+		[]byte(fmt.Sprintf(fakeServerScript, required)),
+		0755))
+}
+
+func setDbVersion(t *testing.T, installation *Installation, version int) {
+	require.NoError(t, os.MkdirAll(installation.HomeDir(), 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(installation.HomeDir(), "db"), []byte(fmt.Sprint(version)), 0644))
+}
+
+func dbVersion(t *testing.T, installation *Installation) string {
+	content, err := os.ReadFile(filepath.Join(installation.HomeDir(), "db"))
+	require.NoError(t, err)
+	return strings.TrimSpace(string(content))
+}
+
+func TestRollBack_MigratesDatabaseDown(t *testing.T) {
+	installation := NewInstallation(t.TempDir())
+	installFakeServer(t, installation, "1.0.0", 3)
+	installFakeServer(t, installation, "1.1.0", 5)
+	require.NoError(t, installation.Switch("1.1.0"))
+	setDbVersion(t, installation, 5)
+
+	err := rollBack(installation, "1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "3", dbVersion(t, installation))
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", current)
+}
+
+func TestRollBack_NotMigratedDatabaseIsKept(t *testing.T) {
+	installation := NewInstallation(t.TempDir())
+	installFakeServer(t, installation, "1.0.0", 3)
+	installFakeServer(t, installation, "1.1.0", 3)
+	require.NoError(t, installation.Switch("1.1.0"))
+	setDbVersion(t, installation, 3)
+
+	err := rollBack(installation, "1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "3", dbVersion(t, installation))
+}
+
+func TestRollBack_UnknownSchemaIsRefused(t *testing.T) {
+	installation := NewInstallation(t.TempDir())
+	// The previous version has no server to ask
+	require.NoError(t, os.MkdirAll(installation.versionDir("1.0.0"), 0755))
+	installFakeServer(t, installation, "1.1.0", 5)
+	require.NoError(t, installation.Switch("1.1.0"))
+	setDbVersion(t, installation, 5)
+
+	err := rollBack(installation, "1.0.0")
+	assert.ErrorContains(t, err, "database cannot be used by 1.0.0")
+
+	assert.Equal(t, "5", dbVersion(t, installation))
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", current)
+}
+
+func makeServerZip(t *testing.T, required int) []byte {
+	var buf bytes.Buffer
+
+	writer := zip.NewWriter(&buf)
+	header := &zip.FileHeader{Name: "server/uv_server", Method: zip.Deflate}
+	header.SetMode(0755)
+	file, err := writer.CreateHeader(header)
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(file, fakeServerScript, required)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func TestUpdateIfNeeded_Pinned(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "v1.0.0", "server.zip", makeServerZip(t, 3))
+	standIn.release(clientRepo, "1.0.0", "client.zip", makeZip(t, "client"))
+	standIn.release(serverRepo, "v1.1.0", "server.zip", makeServerZip(t, 5))
+	standIn.release(clientRepo, "1.1.0", "client.zip", makeZip(t, "client"))
+
+	installation, releases := setUpUpdate(t, standIn)
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+	require.NoError(t, err)
+	setDbVersion(t, installation, 5)
+
+	// The older version is installed once it is pinned and the
+	// database is migrated down to its version
+	previous, err := updateIfNeeded(installation, releases, &Config{PinnedVersion: "1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", previous)
+	assert.Equal(t, "3", dbVersion(t, installation))
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", current)
+
+	// Switching back to the kept version does not download it
+	standIn.assets = map[string][]byte{}
+	_, err = updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+	require.NoError(t, err)
+
+	current, err = installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", current)
+}
+
+func TestUpdateIfNeeded_PinnedOlderThanDatabase(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "v1.0.0", "server.zip", makeZip(t, "server"))
+	standIn.release(clientRepo, "1.0.0", "client.zip", makeZip(t, "client"))
+	standIn.release(serverRepo, "v1.1.0", "server.zip", makeServerZip(t, 5))
+	standIn.release(clientRepo, "1.1.0", "client.zip", makeZip(t, "client"))
+
+	installation, releases := setUpUpdate(t, standIn)
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+	require.NoError(t, err)
+	setDbVersion(t, installation, 5)
+
+	// The pinned version is unable to tell its database version
+	_, err = updateIfNeeded(installation, releases, &Config{PinnedVersion: "1.0"})
+	assert.ErrorContains(t, err, "unable to switch to older v1.0.0")
+	assert.Equal(t, "5", dbVersion(t, installation))
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", current)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer is a semantic version parsed from a release tag, a "v" prefix
+// and missing minor or patch components are allowed
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+	// Dot separated identifiers after "-"
+	PreRelease []string
+
+	// Tag the version is parsed from
+	tag string
+}
+
+func ParseSemVer(tag string) (SemVer, error) {
+	v := SemVer{tag: tag}
+
+	s := strings.TrimPrefix(strings.TrimPrefix(tag, "v"), "V")
+
+	// Build metadata does not take part in the precedence
+	s, build, found := strings.Cut(s, "+")
+	if found && !validIdentifiers(build) {
+		return v, fmt.Errorf("invalid version %q: bad build metadata", tag)
+	}
+
+	s, preRelease, found := strings.Cut(s, "-")
+	if found {
+		if !validIdentifiers(preRelease) {
+			return v, fmt.Errorf("invalid version %q: bad pre-release", tag)
+		}
+		v.PreRelease = strings.Split(preRelease, ".")
+	}
+
+	components := strings.Split(s, ".")
+	if len(components) > 3 {
+		return v, fmt.Errorf("invalid version %q: too many components", tag)
+	}
+
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, component := range components {
+		n, err := strconv.Atoi(component)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q: bad component %q", tag, component)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// validIdentifiers checks dot separated identifiers consist of
+// alphanumerics and hyphens, so versions are safe to use in paths
+func validIdentifiers(s string) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (v SemVer) IsPreRelease() bool {
+	return len(v.PreRelease) > 0
+}
+
+func (v SemVer) String() string {
+	return v.tag
+}
+
+// Compare returns -1, 0 or 1 when v is lower, equal or greater than
+// other, following the precedence rules of semantic versioning
+func (v SemVer) Compare(other SemVer) int {
+	if c := compareInts(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// A release is greater than its pre-releases
+	switch {
+	case !v.IsPreRelease() && !other.IsPreRelease():
+		return 0
+	case !v.IsPreRelease():
+		return 1
+	case !other.IsPreRelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.PreRelease) && i < len(other.PreRelease); i++ {
+		if c := comparePreReleaseIds(v.PreRelease[i], other.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInts(len(v.PreRelease), len(other.PreRelease))
+}
+
+// comparePreReleaseIds compares numeric identifiers numerically, they
+// are lower than alphanumeric ones which are compared lexically
+func comparePreReleaseIds(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	if a > b {
+		return 1
+	} else if a < b {
+		return -1
+	}
+	return 0
+}
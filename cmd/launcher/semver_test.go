@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		tag        string
+		major      int
+		minor      int
+		patch      int
+		preRelease []string
+	}{
+		{"1.2.3", 1, 2, 3, nil},
+		{"v1.2.3", 1, 2, 3, nil},
+		{"v1.2", 1, 2, 0, nil},
+		{"2", 2, 0, 0, nil},
+		{"1.0.0-rc.1", 1, 0, 0, []string{"rc", "1"}},
+		{"1.0.0-beta+exp.sha.5114f85", 1, 0, 0, []string{"beta"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			v, err := ParseSemVer(test.tag)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.major, v.Major)
+			assert.Equal(t, test.minor, v.Minor)
+			assert.Equal(t, test.patch, v.Patch)
+			assert.Equal(t, test.preRelease, v.PreRelease)
+			assert.Equal(t, test.tag, v.String())
+		})
+	}
+}
+
+func TestParseSemVer_Invalid(t *testing.T) {
+	tags := []string{"", "latest", "1.2.3.4", "1..2", "1.x", "1.0.0-", "1.0.0-rc..1", "1.0.0-a/b", "1.0.0+"}
+
+	for _, tag := range tags {
+		t.Run(tag, func(t *testing.T) {
+			_, err := ParseSemVer(tag)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	// Ordered by precedence
+	tags := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"v1.0.1",
+		"1.1",
+		"1.10.0",
+		"2.0.0",
+	}
+
+	for i := range tags {
+		for j := range tags {
+			a, _ := ParseSemVer(tags[i])
+			b, _ := ParseSemVer(tags[j])
+
+			expected := compareInts(i, j)
+			assert.Equal(t, expected, a.Compare(b), "%v vs %v", tags[i], tags[j])
+		}
+	}
+
+	a, _ := ParseSemVer("v1.2")
+	b, _ := ParseSemVer("1.2.0+build")
+	assert.Equal(t, 0, a.Compare(b))
+}
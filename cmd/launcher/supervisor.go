@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// Environment variables the port and the auth token of the server
+	// are passed in, the server reads only the token
+	portEnv      = "UV_SERVER_PORT"
+	authTokenEnv = "UV_AUTH_TOKEN"
+
+	// Migrations run before the server gets ready, so it may take a while
+	readyTimeout      = 2 * time.Minute
+	readyPollInterval = 200 * time.Millisecond
+
+	// Longer than the shutdown grace period of the server,
+	// so running jobs can finish
+	stopTimeout = 30 * time.Second
+
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+	// The backoff is reset once the server runs this long
+	stableRunTime = time.Minute
+	// Restarts in a row after which the server is given up on
+	maxRestarts = 5
+)
+
+// Process is a child process of the launcher, exited is closed once
+// the process exits and err is set
+type Process struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+	err    error
+}
+
+func StartProcess(path string, dir string, args []string, env []string) (*Process, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	startInProcessGroup(cmd)
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Process{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+
+	return p, nil
+}
+
+// Stop asks the process to shut down gracefully and kills it
+// when it does not exit in time
+func (p *Process) Stop() {
+	select {
+	case <-p.exited:
+		return
+	default:
+	}
+
+	err := interrupt(p.cmd)
+	if err != nil {
+		fmt.Printf("failed to interrupt %v, killing it: %v\n", p.cmd.Path, err)
+		killProcessTree(p.cmd)
+	}
+
+	select {
+	case <-p.exited:
+	case <-time.After(stopTimeout):
+		fmt.Printf("%v did not stop in time, killing it\n", p.cmd.Path)
+		killProcessTree(p.cmd)
+		<-p.exited
+	}
+}
+
+// ServerSupervisor runs the server and restarts it with a backoff
+// when it crashes
+type ServerSupervisor struct {
+	path    string
+	homeDir string
+	port    int
+	token   string
+
+	server *Process
+	// Zero until the running server gets ready
+	readyAt time.Time
+}
+
+// NewServerSupervisor runs the server of the installation in dir,
+// data of the server is kept in homeDir
+func NewServerSupervisor(dir string, homeDir string, port int, token string) *ServerSupervisor {
+	object := &ServerSupervisor{}
+
+	object.path = filepath.Join(dir, "server", "uv_server")
+	object.homeDir = homeDir
+	object.port = port
+	object.token = token
+
+	return object
+}
+
+// Start runs the server and waits until it is ready to serve clients
+func (s *ServerSupervisor) Start(ctx context.Context) error {
+	err := s.launch()
+	if err != nil {
+		return err
+	}
+
+	err = s.waitForReady(ctx)
+	if err != nil {
+		s.server.Stop()
+		return err
+	}
+
+	s.readyAt = time.Now()
+
+	return nil
+}
+
+func (s *ServerSupervisor) launch() error {
+	args := []string{"--home", s.homeDir, "--port", fmt.Sprint(s.port)}
+	env := []string{fmt.Sprintf("%v=%v", authTokenEnv, s.token)}
+
+	server, err := StartProcess(s.path, s.homeDir, args, env)
+	if err != nil {
+		return fmt.Errorf("failed to run server: %v", err)
+	}
+
+	s.server = server
+	s.readyAt = time.Time{}
+
+	return nil
+}
+
+func (s *ServerSupervisor) waitForReady(ctx context.Context) error {
+	url := fmt.Sprintf("http://127.0.0.1:%v/readyz", s.port)
+
+	return waitForReady(ctx, url, s.server.exited, readyTimeout)
+}
+
+// Supervise restarts the server whenever it exits until done is
+// canceled, then the server is stopped
+func (s *ServerSupervisor) Supervise(done context.Context) error {
+	backoff := minBackoff
+	restarts := 0
+
+	for {
+		select {
+		case <-done.Done():
+			s.Stop()
+			return nil
+		case <-s.server.exited:
+		}
+
+		fmt.Printf("server exited unexpectedly: %v\n", s.server.err)
+
+		if !s.readyAt.IsZero() && time.Since(s.readyAt) > stableRunTime {
+			backoff = minBackoff
+			restarts = 0
+		}
+
+		if restarts == maxRestarts {
+			return fmt.Errorf("server keeps crashing, gave up after %v restarts", restarts)
+		}
+		restarts++
+
+		fmt.Printf("restarting server in %v\n", backoff)
+
+		select {
+		case <-done.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+
+		err := s.launch()
+		if err != nil {
+			return err
+		}
+
+		// A server which does not get ready is treated as crashed
+		err = s.waitForReady(done)
+		if err != nil {
+			fmt.Printf("restarted server is not ready: %v\n", err)
+			s.server.Stop()
+			continue
+		}
+
+		s.readyAt = time.Now()
+		fmt.Println("server is restarted")
+	}
+}
+
+func (s *ServerSupervisor) Stop() {
+	fmt.Println("stopping server")
+	s.server.Stop()
+}
+
+// waitForReady polls the readiness endpoint until it answers with 200,
+// the process exiting or the timeout passing is an error
+func waitForReady(
+	ctx context.Context,
+	url string,
+	exited <-chan struct{},
+	timeout time.Duration,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: time.Second}
+
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-exited:
+			return fmt.Errorf("server exited before getting ready")
+		case <-ctx.Done():
+			return fmt.Errorf("server is not ready: %w", ctx.Err())
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// freePort asks the system for a port which is not in use, it may be
+// taken by someone else before the server listens on it, but this is
+// unlikely
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func newAuthToken() (string, error) {
+	token := make([]byte, 32)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
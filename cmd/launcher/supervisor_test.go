@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForReady(t *testing.T) {
+	var polls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 3 {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	err := waitForReady(context.Background(), server.URL, make(chan struct{}), 5*time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), polls.Load())
+}
+
+func TestWaitForReady_Exited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exited := make(chan struct{})
+	close(exited)
+
+	err := waitForReady(context.Background(), server.URL, exited, 5*time.Second)
+
+	assert.ErrorContains(t, err, "exited")
+}
+
+func TestWaitForReady_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := waitForReady(context.Background(), server.URL, make(chan struct{}), 500*time.Millisecond)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFreePort(t *testing.T) {
+	port, err := freePort()
+
+	assert.NoError(t, err)
+	assert.Greater(t, port, 0)
+	assert.LessOrEqual(t, port, math.MaxUint16)
+}
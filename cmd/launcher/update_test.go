@@ -0,0 +1,208 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseStandIn serves tags and assets in the layout of GitHub
+type releaseStandIn struct {
+	tags map[string][]string
+	// Keyed by <repo>/<tag>/<asset>
+	assets map[string][]byte
+}
+
+func newReleaseStandIn() *releaseStandIn {
+	return &releaseStandIn{
+		tags:   make(map[string][]string),
+		assets: make(map[string][]byte),
+	}
+}
+
+func (s *releaseStandIn) release(repo string, tag string, asset string, content []byte) {
+	s.tags[repo] = append(s.tags[repo], tag)
+
+	checksum := sha256.Sum256(content)
+	s.assets[fmt.Sprintf("%v/%v/%v", repo, tag, asset)] = content
+	s.assets[fmt.Sprintf("%v/%v/%v.sha256", repo, tag, asset)] =
+		[]byte(hex.EncodeToString(checksum[:]) + "  " + asset + "\n")
+}
+
+func (s *releaseStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for repo, names := range s.tags {
+		if r.URL.Path == fmt.Sprintf("/repos/%v/%v/tags", owner, repo) {
+			tags := []Tag{}
+			for _, name := range names {
+				tags = append(tags, Tag{Name: name})
+			}
+			json.NewEncoder(w).Encode(tags)
+			return
+		}
+	}
+
+	// /<owner>/<repo>/releases/download/<tag>/<asset>
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 7 {
+		http.NotFound(w, r)
+		return
+	}
+
+	content, ok := s.assets[strings.Join([]string{parts[2], parts[5], parts[6]}, "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Write(content)
+}
+
+func makeZip(t *testing.T, dir string) []byte {
+	var buf bytes.Buffer
+
+	writer := zip.NewWriter(&buf)
+	file, err := writer.Create(dir + "/binary")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(dir))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func setUpUpdate(t *testing.T, standIn *releaseStandIn) (*Installation, *Releases) {
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	return NewInstallation(t.TempDir()), NewReleases(server.URL)
+}
+
+func TestUpdateIfNeeded(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "v1.0.0", "server.zip", makeZip(t, "server"))
+	standIn.release(serverRepo, "v1.10.0", "server.zip", makeZip(t, "server"))
+	standIn.release(serverRepo, "v2.0.0-rc.1", "server.zip", makeZip(t, "server"))
+	standIn.release(clientRepo, "1.10.0", "client.zip", makeZip(t, "client"))
+
+	installation, releases := setUpUpdate(t, standIn)
+	require.NoError(t, installation.Switch("1.2.0"))
+
+	previous, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", previous)
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.10.0", current)
+
+	dir := installation.Dir(current)
+	assert.Equal(t, filepath.Join(installation.wd, versionsDirName, "v1.10.0"), dir)
+	assert.FileExists(t, filepath.Join(dir, "server", "binary"))
+	assert.FileExists(t, filepath.Join(dir, "client", "binary"))
+	assert.NoFileExists(t, filepath.Join(dir, "server.zip"))
+
+	// The up to date installation is left as is
+	previous, err = updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+	require.NoError(t, err)
+	assert.Equal(t, "", previous)
+}
+
+func TestUpdateIfNeeded_ChecksumMismatch(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "1.1.0", "server.zip", makeZip(t, "server"))
+	standIn.release(clientRepo, "1.1.0", "client.zip", makeZip(t, "client"))
+	standIn.assets[clientRepo+"/1.1.0/client.zip"] = makeZip(t, "tampered")
+
+	installation, releases := setUpUpdate(t, standIn)
+	require.NoError(t, installation.Switch("1.0.0"))
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", current)
+
+	// Staging directory is removed
+	entries, err := os.ReadDir(filepath.Join(installation.wd, versionsDirName))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestUpdateIfNeeded_MissingChecksum(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "1.1.0", "server.zip", makeZip(t, "server"))
+	standIn.release(clientRepo, "1.1.0", "client.zip", makeZip(t, "client"))
+	delete(standIn.assets, serverRepo+"/1.1.0/server.zip.sha256")
+
+	installation, releases := setUpUpdate(t, standIn)
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+
+	assert.ErrorContains(t, err, "failed to get checksum")
+
+	_, err = installation.Current()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInstallationCleanup(t *testing.T) {
+	installation := NewInstallation(t.TempDir())
+
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		require.NoError(t, os.MkdirAll(installation.versionDir(version), 0755))
+	}
+
+	installation.Cleanup("1.2.0", "1.1.0")
+
+	assert.NoDirExists(t, installation.versionDir("1.0.0"))
+	assert.DirExists(t, installation.versionDir("1.1.0"))
+	assert.DirExists(t, installation.versionDir("1.2.0"))
+
+	// Legacy installations are run from the working directory
+	assert.Equal(t, installation.wd, installation.Dir("1.0.0"))
+}
+
+func TestUpdateIfNeeded_PreReleaseChannel(t *testing.T) {
+	standIn := newReleaseStandIn()
+	standIn.release(serverRepo, "1.0.0", "server.zip", makeZip(t, "server"))
+	standIn.release(serverRepo, "1.1.0-rc.1", "server.zip", makeZip(t, "server"))
+	standIn.release(clientRepo, "1.1.0-rc.1", "client.zip", makeZip(t, "client"))
+
+	installation, releases := setUpUpdate(t, standIn)
+	require.NoError(t, installation.Switch("1.0.0"))
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelPreRelease})
+	require.NoError(t, err)
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0-rc.1", current)
+}
+
+func TestUpdateIfNeeded_Unreachable(t *testing.T) {
+	installation := NewInstallation(t.TempDir())
+	require.NoError(t, installation.Switch("1.0.0"))
+
+	releases := NewReleases("http://127.0.0.1:1")
+
+	_, err := updateIfNeeded(installation, releases, &Config{Channel: channelStable})
+	assert.Error(t, err)
+
+	current, err := installation.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", current)
+}
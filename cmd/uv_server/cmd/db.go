@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"uv_server/internal/uv_server/data"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance",
+}
+
+var dbVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the database version and the version required by the server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator := data.NewDbMigrator(env.config, env.db)
+
+		version, err := migrator.GetVersion()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("database version: %v\n", version)
+		fmt.Printf("required version: %v\n", migrator.RequiredVersion())
+
+		return nil
+	},
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Backup the database into the path or the backups directory",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups := data.NewDbBackups(env.config, env.db)
+
+		if len(args) == 1 {
+			path := env.config.ResolvePath(args[0])
+
+			err := backups.BackupTo(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(path)
+			return nil
+		}
+
+		path, err := backups.Backup("manual")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Replace the database with the backup, the server must be stopped",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backups := data.NewDbBackups(env.config, env.db)
+
+		// The current state is kept in case the wrong backup is restored
+		current, err := backups.Backup("pre-restore")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current database is backed up to %v\n", current)
+
+		err = backups.Restore(env.config.ResolvePath(args[0]))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("database is restored")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbVersionCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"uv_server/internal/uv_server/business/workflows/downloading"
+	jobmessages "uv_server/internal/uv_server/business/workflows/downloading/job_messages"
+	"uv_server/internal/uv_server/data"
+	"uv_server/internal/uv_server/data/downloaders"
+)
+
+var downloadCmd = &cobra.Command{
+	Use:   "download <url>",
+	Short: "Download a file into the library",
+	Long: `Download a file into the library, the progress is printed to stderr.
+    Interrupting the command cancels the download.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		// The tmp directory is not cleaned up front since a running
+		// server may be using it
+		to_clean := make(chan string, 5)
+		defer close(to_clean)
+
+		cleaner := data.NewFileCleaner(to_clean)
+		go cleaner.CleanUpLoop()
+
+		request := &jobmessages.Request{Url: &args[0]}
+
+		_, err = runWorkflow(longTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			downloaderOut := make(chan interface{}, 1)
+
+			ctx, cancel := context.WithCancel(ctx)
+
+			database := data.NewDatabase(env.db)
+
+			downloader := downloaders.NewYtDownloader(
+				uuid,
+				env.config,
+				ctx,
+				downloaderOut,
+				to_clean,
+				database,
+			)
+
+			wf := downloading.NewDownloadingWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				downloader,
+				downloaderOut,
+				database,
+				data.NewFilesystem(),
+				data.NewDownloadRegistry(),
+				data.NewDownloadQueue(env.db),
+				cancel,
+				longTimeout,
+			)
+			go wf.Run(wg, request)
+		})
+
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(downloadCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	deletefiles "uv_server/internal/uv_server/business/workflows/delete_files"
+	deletejobmessages "uv_server/internal/uv_server/business/workflows/delete_files/job_messages"
+	getfiles "uv_server/internal/uv_server/business/workflows/get_files"
+	getjobmessages "uv_server/internal/uv_server/business/workflows/get_files/job_messages"
+	"uv_server/internal/uv_server/data"
+)
+
+var filesCmd = &cobra.Command{
+	Use:   "files",
+	Short: "Library files",
+}
+
+var filesListRequest = &getjobmessages.Request{
+	Limit:      new(int),
+	Offset:     new(int),
+	Trashed:    new(bool),
+	Tag:        new(string),
+	PlaylistId: new(int64),
+}
+
+var filesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List files of the library",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		request := *filesListRequest
+		if !cmd.Flags().Changed("tag") {
+			request.Tag = nil
+		}
+		if !cmd.Flags().Changed("playlist") {
+			request.PlaylistId = nil
+		}
+
+		_, err = runWorkflow(queryTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			wf := getfiles.NewGetFilesWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				data.NewDatabase(env.db),
+			)
+			go wf.Run(wg, &request)
+		})
+
+		return err
+	},
+}
+
+var filesDeleteRequest = &deletejobmessages.Request{}
+
+var filesDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Move files to the trash or delete them permanently",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := *filesDeleteRequest
+		request.Ids = []int64{}
+
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid file id: %v", arg)
+			}
+			request.Ids = append(request.Ids, id)
+		}
+
+		err := prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		result, err := runWorkflow(queryTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			wf := deletefiles.NewDeleteFilesWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				data.NewDatabase(env.db),
+				data.NewFilesystem(),
+				data.NewDownloadRegistry(),
+			)
+			go wf.Run(wg, &request)
+		})
+		if err != nil {
+			return err
+		}
+
+		if tResult, ok := result.(*deletejobmessages.Error); ok && len(tResult.FailedIds) > 0 {
+			return fmt.Errorf("failed to delete %v of %v files", len(tResult.FailedIds), len(request.Ids))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(filesCmd)
+
+	filesCmd.AddCommand(filesListCmd)
+	filesListCmd.Flags().IntVar(filesListRequest.Limit, "limit", 100, "maximum number of files")
+	filesListCmd.Flags().IntVar(filesListRequest.Offset, "offset", 0, "number of files to skip")
+	filesListCmd.Flags().BoolVar(filesListRequest.Trashed, "trashed", false, "list only trashed files")
+	filesListCmd.Flags().StringVar(filesListRequest.Tag, "tag", "", "list only files with the tag")
+	filesListCmd.Flags().Int64Var(filesListRequest.PlaylistId, "playlist", 0, "list files of the playlist in the playlist order")
+
+	filesCmd.AddCommand(filesDeleteCmd)
+	filesDeleteCmd.Flags().BoolVar(&filesDeleteRequest.Permanent, "permanent", false, "delete files immediately instead of moving them to the trash")
+	filesDeleteCmd.Flags().BoolVar(&filesDeleteRequest.AllowMissing, "allow-missing", false, "remove rows of files which are missing on disk")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"uv_server/internal/uv_server/data"
+)
+
+var (
+	migrateTo     int
+	migrateDryRun bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate the database",
+	Long: `Migrate the database to the required version or to the version given with --to,
+    down migrations are applied for older versions.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator := data.NewDbMigrator(env.config, env.db)
+
+		target := migrateTo
+		if target < 0 {
+			target = migrator.RequiredVersion()
+		}
+
+		if !migrateDryRun {
+			err := migrator.Migrate(target)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("database is at version %v\n", target)
+			return nil
+		}
+
+		return printPlan(migrator, target)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().IntVar(&migrateTo, "to", -1, "version to migrate to (default is the required version)")
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "print pending migrations without applying them")
+}
+
+func printPlan(migrator *data.DbMigrator, target int) error {
+	steps, err := migrator.Plan(target)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("migration is not needed")
+		return nil
+	}
+
+	for _, step := range steps {
+		direction := "up"
+		if step.Down {
+			direction = "down"
+		}
+
+		fmt.Printf("-- %v %03d_%v\n", direction, step.Version, step.Name)
+		for _, statement := range step.Statements {
+			fmt.Printf("%v;\n", statement)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+)
+
+// authTokenEnv names the environment variable with the token
+// clients have to present, the launcher sets it
+const authTokenEnv = "UV_AUTH_TOKEN"
+
+var (
+	overrides = &config.Overrides{}
+	port      int
+)
+
+// environment is set up before any command runs
+type environment struct {
+	log    *logrus.Entry
+	config *config.Config
+	db     *sql.DB
+}
+
+var env *environment
+
+var rootCmd = &cobra.Command{
+	Use:   "uv_server",
+	Short: "uv_server downloads and manages the media library",
+	Long: `uv_server serves the media library to the client and downloads new files.
+
+    Without a command the server is started, the other commands operate
+    the library headless, for example:
+       uv_server files list --limit 20
+       uv_server download https://www.youtube.com/watch?v=...
+       uv_server settings set max_concurrent_downloads=3
+
+    Commands which print a result write it to stdout as JSON, progress
+    is written to stderr.
+
+    When the UV_AUTH_TOKEN environment variable is set, clients have to
+    present the token in the Authorization header as a bearer token or
+    in the token query parameter.`,
+	SilenceUsage: true,
+}
+
+func Execute() {
+	err := rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	// Assigned here since setUp refers to rootCmd
+	rootCmd.PersistentPreRunE = setUp
+	rootCmd.PersistentPostRun = tearDown
+	rootCmd.RunE = serve
+
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&overrides.HomeDir, "home", "", "home directory, relative paths are resolved against it (default is the executable directory), tools are always taken from the executable directory")
+	flags.StringVar(&overrides.ConfigPath, "config", "", "config file (default is config/config.yaml in the home directory)")
+	flags.StringVar(&overrides.DbLocation, "db", "", "database file")
+	flags.StringVar(&overrides.LogsLocation, "log-dir", "", "logs directory")
+	flags.StringVar(&overrides.LogLevel, "log-level", "", "log level of every layer, overrides levels from the config")
+	flags.IntVar(&port, "port", 0, "port to listen on")
+}
+
+func setUp(cmd *cobra.Command, args []string) error {
+	if port < 0 || port > math.MaxUint16 {
+		return fmt.Errorf("invalid port: %v", port)
+	}
+	overrides.Port = uint16(port)
+	// Not a flag, so the token is not visible in the process list
+	overrides.AuthToken = os.Getenv(authTokenEnv)
+
+	config := config.NewConfig(overrides)
+
+	loggers.Init(config.LoggerOptions(config.ResolvePath(config.LogsLocation)))
+	if cmd != rootCmd && cmd != serveCmd {
+		loggers.DisableConsoleOutput()
+	}
+
+	log := loggers.ApplicationLogger
+
+	log.Infof("starting %v...", cmd.CommandPath())
+	log.Infof("home directory: %v", config.HomeDir)
+
+	dbPath := config.ResolvePath(config.DbLocation)
+
+	err := os.MkdirAll(filepath.Dir(dbPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return err
+	}
+
+	env = &environment{
+		log:    log,
+		config: config,
+		db:     db,
+	}
+
+	return nil
+}
+
+func tearDown(cmd *cobra.Command, args []string) {
+	env.db.Close()
+	loggers.CloseLogFile()
+}
+
+// prepareLibrary brings the database to the required version and creates
+// the storage directory before commands which work with the library
+func prepareLibrary() error {
+	data.NewDbMigrator(env.config, env.db).MigrateIfNeeded()
+
+	settings, err := data.NewDatabase(env.db).GetSettings()
+	if err != nil {
+		return err
+	}
+
+	return os.MkdirAll(env.config.ResolvePath(settings.StorageDir), 0755)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"uv_server/internal/uv_server/common/metrics"
+	"uv_server/internal/uv_server/data"
+	"uv_server/internal/uv_server/presentation"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the server",
+	Long: `Migrate the database if needed and serve clients, the same as running uv_server without a command.
+    On SIGINT or SIGTERM the server stops accepting requests, lets running jobs
+    finish within the shutdown grace period and cancels the rest, interrupted
+    downloads are resumed when their urls are downloaded again.
+    /healthz answers as soon as the server listens, /readyz once the database
+    is migrated and the directories are initialized.`,
+	Args: cobra.NoArgs,
+	RunE: serve,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
+
+func serve(cmd *cobra.Command, args []string) error {
+	config := env.config
+	db := env.db
+	log := env.log
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	to_clean := make(chan string, 5)
+	metrics.RegisterCleanQueue(to_clean)
+
+	resources := data.Resources{
+		Db:        db,
+		To_clean:  to_clean,
+		Downloads: data.NewDownloadRegistry(),
+		Queue:     data.NewDownloadQueue(db),
+	}
+
+	server := presentation.NewServer(config, &resources)
+
+	// Listening early lets the launcher check health of the server,
+	// it is reported as ready once the library is prepared
+	err := server.Listen()
+	if err != nil {
+		return err
+	}
+
+	data.NewDbMigrator(config, db).MigrateIfNeeded()
+
+	database := data.NewDatabase(db)
+
+	// Downloads of a server which was not shut down gracefully
+	interrupted, err := database.InterruptDownloads()
+	if err != nil {
+		server.Close()
+		return err
+	}
+	if interrupted > 0 {
+		log.Warnf("%v downloads were interrupted", interrupted)
+	}
+
+	settings, err := database.GetSettings()
+	if err != nil {
+		server.Close()
+		return err
+	}
+
+	tmpDir := config.ResolvePath("tmp")
+
+	cleaner := data.NewFileCleaner(to_clean)
+	cleaner.InitializeAndCleanDirectories(
+		config.ResolvePath(settings.StorageDir),
+		tmpDir,
+	)
+
+	cleaned := make(chan struct{})
+	go func() {
+		cleaner.CleanUpLoop()
+		close(cleaned)
+	}()
+
+	var loops sync.WaitGroup
+	loops.Add(2)
+
+	purger := data.NewTrashPurger(config, db)
+	go func() {
+		defer loops.Done()
+		purger.PurgeLoop(ctx)
+	}()
+
+	backups := data.NewDbBackups(config, db)
+	go func() {
+		defer loops.Done()
+		backups.BackupLoop(ctx)
+	}()
+
+	server.SetReady()
+
+	err = server.Run(ctx)
+
+	// The server may stop on its own when the client disconnects
+	stop()
+	loops.Wait()
+
+	if errors.Is(err, presentation.ErrJobsNotStopped) {
+		// Stuck jobs may still send to the cleaner, the tmp
+		// directory is cleaned on the next start
+		log.Error("stopped without cleaning up")
+		// Cobra skips the post run on errors, the database is closed
+		// and the logs are flushed here instead
+		tearDown(cmd, args)
+		return err
+	}
+
+	// Jobs are drained, so nothing is sent to clean up anymore
+	close(to_clean)
+	<-cleaned
+
+	cleanErr := data.CleanDirectory(tmpDir)
+	if cleanErr != nil {
+		log.Errorf("failed to clean tmp directory: %v", cleanErr)
+	}
+
+	log.Info("stopped")
+
+	return err
+}
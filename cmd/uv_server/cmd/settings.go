@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	getsettings "uv_server/internal/uv_server/business/workflows/get_settings"
+	updatesettings "uv_server/internal/uv_server/business/workflows/update_settings"
+	updatejobmessages "uv_server/internal/uv_server/business/workflows/update_settings/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/data"
+)
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Library settings",
+}
+
+var settingsGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Print the settings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		_, err = runWorkflow(queryTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			wf := getsettings.NewGetSettingsWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				data.NewDatabase(env.db),
+			)
+			go wf.Run(wg)
+		})
+
+		return err
+	},
+}
+
+var settingsMoveFiles bool
+
+var settingsSetCmd = &cobra.Command{
+	Use:   "set <key=value>...",
+	Short: "Update the settings",
+	Long: `Update the settings, keys are the names printed by "settings get", for example:
+       uv_server settings set max_concurrent_downloads=3 storage_dir=D:\Music --move-files`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request, err := parseSettings(args)
+		if err != nil {
+			return err
+		}
+		request.MoveFiles = settingsMoveFiles
+
+		err = prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		_, err = runWorkflow(longTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			wf := updatesettings.NewUpdateSettingsWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				data.NewDatabase(env.db),
+				data.NewFilesystem(),
+			)
+			go wf.Run(wg, request)
+		})
+
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(settingsCmd)
+	settingsCmd.AddCommand(settingsGetCmd)
+	settingsCmd.AddCommand(settingsSetCmd)
+	settingsSetCmd.Flags().BoolVar(&settingsMoveFiles, "move-files", false, "move files into the new storage directory")
+}
+
+// parseSettings builds an update request from key=value pairs. Values
+// which are valid JSON are taken as is, the others are taken as strings
+func parseSettings(args []string) (*updatejobmessages.Request, error) {
+	fields := map[string]json.RawMessage{}
+
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %v", arg)
+		}
+
+		if json.Valid([]byte(value)) {
+			fields[key] = json.RawMessage(value)
+			continue
+		}
+
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = quoted
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &updatejobmessages.Request{}
+	err = common.UnmarshalStrict(payload, request)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	if request.IsEmpty() {
+		return nil, errors.New("nothing to update")
+	}
+
+	return request, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	verifylibrary "uv_server/internal/uv_server/business/workflows/verify_library"
+	jobmessages "uv_server/internal/uv_server/business/workflows/verify_library/job_messages"
+	"uv_server/internal/uv_server/data"
+)
+
+var verifyRequest = &jobmessages.Request{}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that files of the library match the database",
+	Long: `Check that files of the library exist and match their checksums and find
+    files in the storage directory which are unknown to the database. The
+    command fails when problems are found and they are not repaired.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := prepareLibrary()
+		if err != nil {
+			return err
+		}
+
+		result, err := runWorkflow(longTimeout, func(
+			uuid string,
+			ctx context.Context,
+			jobIn chan interface{},
+			jobOut chan interface{},
+			wg *sync.WaitGroup,
+		) {
+			wf := verifylibrary.NewVerifyLibraryWf(
+				uuid,
+				env.config,
+				ctx,
+				jobIn,
+				jobOut,
+				data.NewDatabase(env.db),
+				data.NewFilesystem(),
+			)
+			go wf.Run(wg, verifyRequest)
+		})
+		if err != nil {
+			return err
+		}
+
+		if tResult, ok := result.(*jobmessages.Result); ok && !tResult.Repaired &&
+			len(tResult.Missing)+len(tResult.Modified)+len(tResult.Orphaned) > 0 {
+			return errors.New("library has problems")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyRequest.Repair, "repair", false, "remove rows of missing files and record checksums of modified ones")
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+)
+
+const (
+	// queryTimeout matches the default timeout of server jobs
+	queryTimeout = 60 * time.Second
+	// longTimeout is used for workflows which go through the whole library
+	longTimeout = time.Hour
+)
+
+// workflowStarter creates a workflow with the job channels and starts
+// it in a goroutine, wg is already incremented for it
+type workflowStarter func(
+	uuid string,
+	ctx context.Context,
+	jobIn chan interface{},
+	jobOut chan interface{},
+	wg *sync.WaitGroup,
+)
+
+// runWorkflow runs the workflow the same way a job does and returns its
+// last message. Intermediate messages are printed to stderr, the last one
+// to stdout. Interrupting the command cancels the workflow
+func runWorkflow(timeout time.Duration, start workflowStarter) (interface{}, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	jobIn := make(chan interface{})
+	jobOut := make(chan interface{})
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	start(uuid.New().String(), ctx, jobIn, jobOut, wg)
+
+	go func() {
+		wg.Wait()
+		close(jobIn)
+	}()
+
+	var last interface{}
+
+	for msg := range jobIn {
+		switch tMsg := msg.(type) {
+		case *cjmessages.Error:
+			return nil, errors.New(tMsg.Reason)
+		case *cjmessages.Canceled:
+			return nil, errors.New("canceled")
+		case *cjmessages.Done:
+			continue
+		}
+
+		if last != nil {
+			printMessage(os.Stderr, last, false)
+		}
+		last = msg
+	}
+
+	if last != nil {
+		printMessage(os.Stdout, last, true)
+	}
+
+	return last, nil
+}
+
+func printMessage(out *os.File, msg interface{}, indent bool) {
+	var payload []byte
+	var err error
+
+	if indent {
+		payload, err = json.MarshalIndent(msg, "", "  ")
+	} else {
+		payload, err = json.Marshal(msg)
+	}
+	if err != nil {
+		env.log.Fatalf("failed to serialize message: %v", err)
+	}
+
+	fmt.Fprintln(out, string(payload))
+}
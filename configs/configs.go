@@ -0,0 +1,8 @@
+// Package configs keeps the default server configuration, it is
+// embedded into the binary and used when no config file is installed
+package configs
+
+import _ "embed"
+
+//go:embed config.yaml
+var Default []byte
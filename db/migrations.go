@@ -0,0 +1,11 @@
+// Package db keeps the database schema migrations, they are embedded
+// into the server binary
+package db
+
+import "embed"
+
+// Migrations contains NNN_name.sql files applying the version NNN and
+// optional NNN_name.down.sql files reverting it
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
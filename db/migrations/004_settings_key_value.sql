@@ -0,0 +1,11 @@
+CREATE TABLE settings_kv (
+    "key" TEXT PRIMARY KEY,
+    "value" TEXT NOT NULL
+);
+
+INSERT INTO settings_kv ("key", "value")
+SELECT 'storage_dir', storage_dir FROM settings LIMIT 1;
+
+DROP TABLE settings;
+
+ALTER TABLE settings_kv RENAME TO settings;
@@ -0,0 +1,3 @@
+ALTER TABLE files DROP COLUMN checksum;
+
+ALTER TABLE files DROP COLUMN "size";
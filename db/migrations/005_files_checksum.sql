@@ -0,0 +1,3 @@
+ALTER TABLE files ADD COLUMN "size" INTEGER NULL;
+
+ALTER TABLE files ADD COLUMN checksum TEXT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE files DROP COLUMN duration;
+
+ALTER TABLE files DROP COLUMN artist;
+
+ALTER TABLE files DROP COLUMN title;
+
+DELETE FROM sources WHERE source = 'lc';
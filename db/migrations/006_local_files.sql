@@ -0,0 +1,9 @@
+INSERT INTO sources (source, description)
+VALUES 
+	('lc', 'Local');
+
+ALTER TABLE files ADD COLUMN title TEXT NULL;
+
+ALTER TABLE files ADD COLUMN artist TEXT NULL;
+
+ALTER TABLE files ADD COLUMN duration REAL NULL;
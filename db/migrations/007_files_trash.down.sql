@@ -0,0 +1,3 @@
+DROP INDEX files_deleted_at;
+
+ALTER TABLE files DROP COLUMN deleted_at;
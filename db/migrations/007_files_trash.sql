@@ -0,0 +1,3 @@
+ALTER TABLE files ADD COLUMN deleted_at DATETIME NULL;
+
+CREATE INDEX files_deleted_at ON files(deleted_at);
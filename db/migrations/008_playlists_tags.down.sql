@@ -0,0 +1,7 @@
+DROP TABLE file_tags;
+
+DROP TABLE tags;
+
+DROP TABLE playlist_entries;
+
+DROP TABLE playlists;
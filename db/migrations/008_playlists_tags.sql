@@ -0,0 +1,32 @@
+CREATE TABLE playlists (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	added_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE playlist_entries (
+	playlist_id INTEGER NOT NULL,
+	file_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY(playlist_id, file_id),
+	FOREIGN KEY(playlist_id) REFERENCES playlists(id)
+	FOREIGN KEY(file_id) REFERENCES files(id)
+);
+
+CREATE INDEX playlist_entries_file_id ON playlist_entries(file_id);
+
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE file_tags (
+	file_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY(file_id, tag_id),
+	FOREIGN KEY(file_id) REFERENCES files(id)
+	FOREIGN KEY(tag_id) REFERENCES tags(id)
+);
+
+CREATE INDEX file_tags_tag_id ON file_tags(tag_id);
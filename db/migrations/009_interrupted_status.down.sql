@@ -0,0 +1,3 @@
+UPDATE files SET status = 'p' WHERE status = 'i';
+
+DELETE FROM file_statuses WHERE status = 'i';
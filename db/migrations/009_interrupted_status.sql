@@ -0,0 +1,3 @@
+INSERT INTO file_statuses (status, description)
+VALUES
+	('i', 'Interrupted');
@@ -39,6 +39,55 @@ const (
 	GetSettingsRequest
 	GetSettingsResponse
 
+	UpdateSettingsProgress
+
+	VerifyLibraryRequest
+	VerifyLibraryProgress
+	VerifyLibraryResponse
+
+	ImportFilesRequest
+	ImportFilesProgress
+	ImportFilesResponse
+
+	RestoreFilesRequest
+	RestoreFilesError
+
+	EmptyTrashRequest
+	EmptyTrashResponse
+
+	CancelDownloadByFileIdRequest
+
+	GetPlaylistsRequest
+	GetPlaylistsResponse
+	GetPlaylistRequest
+	GetPlaylistResponse
+	CreatePlaylistRequest
+	CreatePlaylistResponse
+	RenamePlaylistRequest
+	DeletePlaylistRequest
+	AddPlaylistEntriesRequest
+	RemovePlaylistEntriesRequest
+	ReorderPlaylistRequest
+
+	GetTagsRequest
+	GetTagsResponse
+	TagFilesRequest
+	UntagFilesRequest
+
+	ExportRequest
+	ExportChunk
+	ExportResponse
+	ImportLibraryRequest
+	ImportLibraryResponse
+
+	SetLogLevelRequest
+	SetLogLevelResponse
+
+	GetDiagnosticsRequest
+	GetDiagnosticsResponse
+
+	DownloadingQueued
+
 	Max
 )
 
@@ -85,6 +134,92 @@ func (t Type) String() string {
 	case GetSettingsResponse:
 		return "GetSettingsResponse"
 
+	case UpdateSettingsProgress:
+		return "UpdateSettingsProgress"
+
+	case VerifyLibraryRequest:
+		return "VerifyLibraryRequest"
+	case VerifyLibraryProgress:
+		return "VerifyLibraryProgress"
+	case VerifyLibraryResponse:
+		return "VerifyLibraryResponse"
+
+	case ImportFilesRequest:
+		return "ImportFilesRequest"
+	case ImportFilesProgress:
+		return "ImportFilesProgress"
+	case ImportFilesResponse:
+		return "ImportFilesResponse"
+
+	case RestoreFilesRequest:
+		return "RestoreFilesRequest"
+	case RestoreFilesError:
+		return "RestoreFilesError"
+
+	case EmptyTrashRequest:
+		return "EmptyTrashRequest"
+	case EmptyTrashResponse:
+		return "EmptyTrashResponse"
+
+	case CancelDownloadByFileIdRequest:
+		return "CancelDownloadByFileIdRequest"
+
+	case GetPlaylistsRequest:
+		return "GetPlaylistsRequest"
+	case GetPlaylistsResponse:
+		return "GetPlaylistsResponse"
+	case GetPlaylistRequest:
+		return "GetPlaylistRequest"
+	case GetPlaylistResponse:
+		return "GetPlaylistResponse"
+	case CreatePlaylistRequest:
+		return "CreatePlaylistRequest"
+	case CreatePlaylistResponse:
+		return "CreatePlaylistResponse"
+	case RenamePlaylistRequest:
+		return "RenamePlaylistRequest"
+	case DeletePlaylistRequest:
+		return "DeletePlaylistRequest"
+	case AddPlaylistEntriesRequest:
+		return "AddPlaylistEntriesRequest"
+	case RemovePlaylistEntriesRequest:
+		return "RemovePlaylistEntriesRequest"
+	case ReorderPlaylistRequest:
+		return "ReorderPlaylistRequest"
+
+	case GetTagsRequest:
+		return "GetTagsRequest"
+	case GetTagsResponse:
+		return "GetTagsResponse"
+	case TagFilesRequest:
+		return "TagFilesRequest"
+	case UntagFilesRequest:
+		return "UntagFilesRequest"
+
+	case ExportRequest:
+		return "ExportRequest"
+	case ExportChunk:
+		return "ExportChunk"
+	case ExportResponse:
+		return "ExportResponse"
+	case ImportLibraryRequest:
+		return "ImportLibraryRequest"
+	case ImportLibraryResponse:
+		return "ImportLibraryResponse"
+
+	case SetLogLevelRequest:
+		return "SetLogLevelRequest"
+	case SetLogLevelResponse:
+		return "SetLogLevelResponse"
+
+	case GetDiagnosticsRequest:
+		return "GetDiagnosticsRequest"
+	case GetDiagnosticsResponse:
+		return "GetDiagnosticsResponse"
+
+	case DownloadingQueued:
+		return "DownloadingQueued"
+
 	default:
 		return fmt.Sprintf("Unknown: %d", t)
 	}
@@ -2,22 +2,53 @@ package data
 
 import (
 	"errors"
+	"time"
 	gfw "uv_server/internal/uv_server/business/workflows/get_file/job_messages"
 	gfsw "uv_server/internal/uv_server/business/workflows/get_files/job_messages"
 )
 
 var NotFound = errors.New("record is not found")
+var AlreadyExists = errors.New("record already exists")
 
 type Database interface {
 	GetFile(id int64) (*File, error)
+	GetFilesByStatus(status FileStatus) ([]File, error)
+	// GetLibraryFiles returns all files which are not in the trash
+	GetLibraryFiles() ([]File, error)
+	GetTrashedFiles(deletedBefore time.Time) ([]File, error)
 	GetFileByUrl(url string) (*File, error)
+	// IsPathTaken reports whether any file, trashed ones included,
+	// has the path, trashed files get it back once restored
+	IsPathTaken(path string) (bool, error)
+	// InsertFile keeps AddedAt of the file when it is set,
+	// AlreadyExists is returned for a taken url or path
 	InsertFile(file *File) (int64, error)
 	UpdateFileStatus(file *File) error
+	// ResumeFile claims an interrupted file for downloading, NotFound is
+	// returned when the file is not interrupted anymore
+	ResumeFile(fileId int64) error
+	// UpdateFilePath returns AlreadyExists for a taken path
 	UpdateFilePath(file *File) error
+	UpdateFileDigest(file *File) error
+	UpdateFileMetadata(file *File) error
+	UpdateFileDeletedAt(file *File) error
 	DeleteFile(file *File) error
 	DeleteFiles(ids []int64) error
 	GetFilesForGFW(request *gfsw.Request) (*gfsw.Result, error)
 	GetFileForGFW(request *gfw.Request) (*gfw.Result, error)
+	GetPlaylists() ([]Playlist, error)
+	GetPlaylist(id int64) (*Playlist, error)
+	InsertPlaylist(name string) (int64, error)
+	UpdatePlaylistName(id int64, name string) error
+	DeletePlaylist(id int64) error
+	GetPlaylistEntries(id int64) ([]int64, error)
+	// SetPlaylistEntries replaces entries of the playlist keeping
+	// the order of file ids
+	SetPlaylistEntries(id int64, fileIds []int64) error
+	GetTags() ([]Tag, error)
+	GetFileTags(fileId int64) ([]string, error)
+	TagFiles(fileIds []int64, tags []string) error
+	UntagFiles(fileIds []int64, tags []string) error
 	GetSettings() (*Settings, error)
 	UpdateSettings(settings *Settings) (*Settings, error)
 }
@@ -0,0 +1,33 @@
+package data
+
+import "time"
+
+type ToolInfo struct {
+	Name      string
+	Path      string
+	Available bool
+	// First line of the version output of the tool
+	Version string
+	// Why the tool is not available
+	Error string
+}
+
+// Diagnostics inspects the environment the server is running in
+type Diagnostics interface {
+	GetDbVersion() (current int, required int, err error)
+	GetTools() []ToolInfo
+	// GetFreeSpace reports bytes available to the server on the volume
+	// containing the path
+	GetFreeSpace(path string) (uint64, error)
+}
+
+type JobInfo struct {
+	Uuid      string
+	Type      string
+	StartedAt time.Time
+}
+
+// ActiveJobs lists jobs running in every session
+type ActiveJobs interface {
+	GetActiveJobs() []JobInfo
+}
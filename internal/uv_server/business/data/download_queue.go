@@ -0,0 +1,13 @@
+package data
+
+import "context"
+
+// DownloadQueue limits downloads running at once across sessions and
+// holds them back outside of the download schedule, downloads start
+// in the order they are queued
+type DownloadQueue interface {
+	// TryAcquire takes a slot when the download may start right away
+	TryAcquire() (release func(), ok bool)
+	// Acquire waits for a slot until the context is done
+	Acquire(ctx context.Context) (release func(), err error)
+}
@@ -0,0 +1,23 @@
+package data
+
+import (
+	"context"
+	"errors"
+)
+
+var AlreadyDownloading = errors.New("file is already being downloaded")
+
+// DownloadRegistry keeps track of active downloads across sessions,
+// so a download can be stopped knowing only the file id
+type DownloadRegistry interface {
+	// Register writes the row of the download with claim and registers
+	// the download under the same lock, the id of the claimed row is
+	// returned. The error of claim is returned as is, AlreadyDownloading
+	// is returned when the claimed file is registered already
+	Register(claim func() (int64, error), cancel context.CancelFunc) (int64, error)
+	Unregister(fileId int64)
+	// Cancel stops the download, the returned channel is closed once
+	// the download is unregistered. False is returned when the file
+	// is not being downloaded
+	Cancel(fileId int64) (<-chan struct{}, bool)
+}
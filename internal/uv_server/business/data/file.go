@@ -2,15 +2,25 @@ package data
 
 import (
 	"database/sql"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
+// TrashDir is a storage subdirectory keeping soft deleted files
+const TrashDir = ".trash"
+
 type FileStatus string
 
 const (
 	FsPending     FileStatus = "p"
 	FsDownloading FileStatus = "d"
 	FsFinished    FileStatus = "f"
+	// The download was stopped by a server shutdown, downloading
+	// the same url again resumes it
+	FsInterrupted FileStatus = "i"
 )
 
 type File struct {
@@ -19,6 +29,94 @@ type File struct {
 	SourceUrl string
 	Source    Source
 	Status    FileStatus
+	Size      sql.NullInt64
+	// Hex encoded SHA-256 of the file content
+	Checksum sql.NullString
+	Title    sql.NullString
+	Artist   sql.NullString
+	// Seconds
+	Duration  sql.NullFloat64
+	DeletedAt sql.NullTime
 	AddedAt   time.Time
 	UpdatedAt time.Time
 }
+
+func (f *File) IsTrashed() bool {
+	return f.DeletedAt.Valid
+}
+
+// IsExternal reports whether the file was imported in place, such files
+// belong to the user and are never moved or removed, only their rows are
+func (f *File) IsExternal() bool {
+	return f.Path.Valid && !IsInStorage(f.Path.String)
+}
+
+type FileDigest struct {
+	Size     int64
+	Checksum string
+}
+
+// ResolveFilePath returns an absolute path of a stored file, files
+// imported without copying keep their absolute location
+func ResolveFilePath(storageDir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(storageDir, filepath.FromSlash(path))
+}
+
+// IsInStorage reports whether the file path is relative to the storage
+func IsInStorage(path string) bool {
+	return !filepath.IsAbs(path)
+}
+
+// TrashPath returns a slash separated path of a trashed file relative
+// to the storage, the file id keeps names of trashed files unique
+func TrashPath(file *File) string {
+	return path.Join(
+		TrashDir,
+		strconv.FormatInt(file.Id, 10),
+		path.Base(filepath.ToSlash(file.Path.String)))
+}
+
+// PurgeFile permanently deletes a trashed file, the trash entry goes
+// first, so a failure leaves a row which can be purged again. Files
+// imported in place are left on disk
+func PurgeFile(
+	database Database,
+	filesystem Filesystem,
+	storageDir string,
+	file *File,
+) error {
+	if !file.IsExternal() {
+		err := filesystem.DeleteDirectory(filepath.Dir(LocateFile(storageDir, file)))
+		if err != nil {
+			return fmt.Errorf("failed to delete file from trash: %w", err)
+		}
+	}
+
+	err := database.DeleteFile(&File{Id: file.Id})
+	if err != nil {
+		return fmt.Errorf("failed to delete file from database: %w", err)
+	}
+
+	return nil
+}
+
+// LocateFile returns the current location of a file on disk
+// taking the trash into account
+func LocateFile(storageDir string, file *File) string {
+	if file.IsTrashed() && !file.IsExternal() {
+		return ResolveFilePath(storageDir, TrashPath(file))
+	}
+
+	return ResolveFilePath(storageDir, file.Path.String)
+}
+
+type MediaMetadata struct {
+	Title  string
+	Artist string
+	// Seconds, 0 when unknown
+	Duration float64
+}
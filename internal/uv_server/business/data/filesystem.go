@@ -2,4 +2,23 @@ package data
 
 type Filesystem interface {
 	DeleteFile(path string) error
+	// DeleteDirectory removes the directory with its content
+	DeleteDirectory(path string) error
+	MoveFile(src string, dst string) error
+	CopyFile(src string, dst string) error
+	Exists(path string) (bool, error)
+	ReadFile(path string) ([]byte, error)
+	// WriteFile replaces the file content atomically creating missing
+	// parent directories
+	WriteFile(path string, content []byte) error
+	EnsureDirectory(path string) error
+	ValidateDirectory(path string) error
+	HashFile(path string) (*FileDigest, error)
+	// ListFiles returns slash separated paths of regular files relative
+	// to the root, entries with names starting with a dot are skipped
+	ListFiles(root string) ([]string, error)
+}
+
+type MetadataExtractor interface {
+	Extract(path string) (*MediaMetadata, error)
 }
@@ -0,0 +1,10 @@
+package data
+
+// LogLevels changes verbosity of the layer loggers at runtime,
+// the levels from the config are restored on restart
+type LogLevels interface {
+	// SetLevel changes the level of the layer, every layer is changed
+	// when the layer is empty
+	SetLevel(layer string, level string) error
+	GetLevels() map[string]string
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	data "uv_server/internal/uv_server/business/data"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockActiveJobs is an autogenerated mock type for the ActiveJobs type
+type MockActiveJobs struct {
+	mock.Mock
+}
+
+type MockActiveJobs_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockActiveJobs) EXPECT() *MockActiveJobs_Expecter {
+	return &MockActiveJobs_Expecter{mock: &_m.Mock}
+}
+
+// GetActiveJobs provides a mock function with no fields
+func (_m *MockActiveJobs) GetActiveJobs() []data.JobInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveJobs")
+	}
+
+	var r0 []data.JobInfo
+	if rf, ok := ret.Get(0).(func() []data.JobInfo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.JobInfo)
+		}
+	}
+
+	return r0
+}
+
+// MockActiveJobs_GetActiveJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActiveJobs'
+type MockActiveJobs_GetActiveJobs_Call struct {
+	*mock.Call
+}
+
+// GetActiveJobs is a helper method to define mock.On call
+func (_e *MockActiveJobs_Expecter) GetActiveJobs() *MockActiveJobs_GetActiveJobs_Call {
+	return &MockActiveJobs_GetActiveJobs_Call{Call: _e.mock.On("GetActiveJobs")}
+}
+
+func (_c *MockActiveJobs_GetActiveJobs_Call) Run(run func()) *MockActiveJobs_GetActiveJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockActiveJobs_GetActiveJobs_Call) Return(_a0 []data.JobInfo) *MockActiveJobs_GetActiveJobs_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockActiveJobs_GetActiveJobs_Call) RunAndReturn(run func() []data.JobInfo) *MockActiveJobs_GetActiveJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockActiveJobs creates a new instance of MockActiveJobs. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockActiveJobs(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockActiveJobs {
+	mock := &MockActiveJobs{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.53.2. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -8,6 +8,8 @@ import (
 	job_messages "uv_server/internal/uv_server/business/workflows/get_files/job_messages"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockDatabase is an autogenerated mock type for the Database type
@@ -115,6 +117,52 @@ func (_c *MockDatabase_DeleteFiles_Call) RunAndReturn(run func([]int64) error) *
 	return _c
 }
 
+// DeletePlaylist provides a mock function with given fields: id
+func (_m *MockDatabase) DeletePlaylist(id int64) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePlaylist")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_DeletePlaylist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeletePlaylist'
+type MockDatabase_DeletePlaylist_Call struct {
+	*mock.Call
+}
+
+// DeletePlaylist is a helper method to define mock.On call
+//   - id int64
+func (_e *MockDatabase_Expecter) DeletePlaylist(id interface{}) *MockDatabase_DeletePlaylist_Call {
+	return &MockDatabase_DeletePlaylist_Call{Call: _e.mock.On("DeletePlaylist", id)}
+}
+
+func (_c *MockDatabase_DeletePlaylist_Call) Run(run func(id int64)) *MockDatabase_DeletePlaylist_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_DeletePlaylist_Call) Return(_a0 error) *MockDatabase_DeletePlaylist_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_DeletePlaylist_Call) RunAndReturn(run func(int64) error) *MockDatabase_DeletePlaylist_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetFile provides a mock function with given fields: id
 func (_m *MockDatabase) GetFile(id int64) (*data.File, error) {
 	ret := _m.Called(id)
@@ -289,6 +337,122 @@ func (_c *MockDatabase_GetFileForGFW_Call) RunAndReturn(run func(*jobmessages.Re
 	return _c
 }
 
+// GetFileTags provides a mock function with given fields: fileId
+func (_m *MockDatabase) GetFileTags(fileId int64) ([]string, error) {
+	ret := _m.Called(fileId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFileTags")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]string, error)); ok {
+		return rf(fileId)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []string); ok {
+		r0 = rf(fileId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(fileId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_GetFileTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFileTags'
+type MockDatabase_GetFileTags_Call struct {
+	*mock.Call
+}
+
+// GetFileTags is a helper method to define mock.On call
+//   - fileId int64
+func (_e *MockDatabase_Expecter) GetFileTags(fileId interface{}) *MockDatabase_GetFileTags_Call {
+	return &MockDatabase_GetFileTags_Call{Call: _e.mock.On("GetFileTags", fileId)}
+}
+
+func (_c *MockDatabase_GetFileTags_Call) Run(run func(fileId int64)) *MockDatabase_GetFileTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_GetFileTags_Call) Return(_a0 []string, _a1 error) *MockDatabase_GetFileTags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_GetFileTags_Call) RunAndReturn(run func(int64) ([]string, error)) *MockDatabase_GetFileTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFilesByStatus provides a mock function with given fields: status
+func (_m *MockDatabase) GetFilesByStatus(status data.FileStatus) ([]data.File, error) {
+	ret := _m.Called(status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFilesByStatus")
+	}
+
+	var r0 []data.File
+	var r1 error
+	if rf, ok := ret.Get(0).(func(data.FileStatus) ([]data.File, error)); ok {
+		return rf(status)
+	}
+	if rf, ok := ret.Get(0).(func(data.FileStatus) []data.File); ok {
+		r0 = rf(status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.File)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(data.FileStatus) error); ok {
+		r1 = rf(status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_GetFilesByStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFilesByStatus'
+type MockDatabase_GetFilesByStatus_Call struct {
+	*mock.Call
+}
+
+// GetFilesByStatus is a helper method to define mock.On call
+//   - status data.FileStatus
+func (_e *MockDatabase_Expecter) GetFilesByStatus(status interface{}) *MockDatabase_GetFilesByStatus_Call {
+	return &MockDatabase_GetFilesByStatus_Call{Call: _e.mock.On("GetFilesByStatus", status)}
+}
+
+func (_c *MockDatabase_GetFilesByStatus_Call) Run(run func(status data.FileStatus)) *MockDatabase_GetFilesByStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(data.FileStatus))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_GetFilesByStatus_Call) Return(_a0 []data.File, _a1 error) *MockDatabase_GetFilesByStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_GetFilesByStatus_Call) RunAndReturn(run func(data.FileStatus) ([]data.File, error)) *MockDatabase_GetFilesByStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetFilesForGFW provides a mock function with given fields: request
 func (_m *MockDatabase) GetFilesForGFW(request *job_messages.Request) (*job_messages.Result, error) {
 	ret := _m.Called(request)
@@ -347,24 +511,24 @@ func (_c *MockDatabase_GetFilesForGFW_Call) RunAndReturn(run func(*job_messages.
 	return _c
 }
 
-// GetSettings provides a mock function with no fields
-func (_m *MockDatabase) GetSettings() (*data.Settings, error) {
+// GetLibraryFiles provides a mock function with no fields
+func (_m *MockDatabase) GetLibraryFiles() ([]data.File, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetSettings")
+		panic("no return value specified for GetLibraryFiles")
 	}
 
-	var r0 *data.Settings
+	var r0 []data.File
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*data.Settings, error)); ok {
+	if rf, ok := ret.Get(0).(func() ([]data.File, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() *data.Settings); ok {
+	if rf, ok := ret.Get(0).(func() []data.File); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*data.Settings)
+			r0 = ret.Get(0).([]data.File)
 		}
 	}
 
@@ -377,54 +541,56 @@ func (_m *MockDatabase) GetSettings() (*data.Settings, error) {
 	return r0, r1
 }
 
-// MockDatabase_GetSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSettings'
-type MockDatabase_GetSettings_Call struct {
+// MockDatabase_GetLibraryFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLibraryFiles'
+type MockDatabase_GetLibraryFiles_Call struct {
 	*mock.Call
 }
 
-// GetSettings is a helper method to define mock.On call
-func (_e *MockDatabase_Expecter) GetSettings() *MockDatabase_GetSettings_Call {
-	return &MockDatabase_GetSettings_Call{Call: _e.mock.On("GetSettings")}
+// GetLibraryFiles is a helper method to define mock.On call
+func (_e *MockDatabase_Expecter) GetLibraryFiles() *MockDatabase_GetLibraryFiles_Call {
+	return &MockDatabase_GetLibraryFiles_Call{Call: _e.mock.On("GetLibraryFiles")}
 }
 
-func (_c *MockDatabase_GetSettings_Call) Run(run func()) *MockDatabase_GetSettings_Call {
+func (_c *MockDatabase_GetLibraryFiles_Call) Run(run func()) *MockDatabase_GetLibraryFiles_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *MockDatabase_GetSettings_Call) Return(_a0 *data.Settings, _a1 error) *MockDatabase_GetSettings_Call {
+func (_c *MockDatabase_GetLibraryFiles_Call) Return(_a0 []data.File, _a1 error) *MockDatabase_GetLibraryFiles_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDatabase_GetSettings_Call) RunAndReturn(run func() (*data.Settings, error)) *MockDatabase_GetSettings_Call {
+func (_c *MockDatabase_GetLibraryFiles_Call) RunAndReturn(run func() ([]data.File, error)) *MockDatabase_GetLibraryFiles_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// InsertFile provides a mock function with given fields: file
-func (_m *MockDatabase) InsertFile(file *data.File) (int64, error) {
-	ret := _m.Called(file)
+// GetPlaylist provides a mock function with given fields: id
+func (_m *MockDatabase) GetPlaylist(id int64) (*data.Playlist, error) {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for InsertFile")
+		panic("no return value specified for GetPlaylist")
 	}
 
-	var r0 int64
+	var r0 *data.Playlist
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*data.File) (int64, error)); ok {
-		return rf(file)
+	if rf, ok := ret.Get(0).(func(int64) (*data.Playlist, error)); ok {
+		return rf(id)
 	}
-	if rf, ok := ret.Get(0).(func(*data.File) int64); ok {
-		r0 = rf(file)
+	if rf, ok := ret.Get(0).(func(int64) *data.Playlist); ok {
+		r0 = rf(id)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*data.Playlist)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*data.File) error); ok {
-		r1 = rf(file)
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -432,122 +598,949 @@ func (_m *MockDatabase) InsertFile(file *data.File) (int64, error) {
 	return r0, r1
 }
 
-// MockDatabase_InsertFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertFile'
-type MockDatabase_InsertFile_Call struct {
+// MockDatabase_GetPlaylist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlaylist'
+type MockDatabase_GetPlaylist_Call struct {
 	*mock.Call
 }
 
-// InsertFile is a helper method to define mock.On call
-//   - file *data.File
-func (_e *MockDatabase_Expecter) InsertFile(file interface{}) *MockDatabase_InsertFile_Call {
-	return &MockDatabase_InsertFile_Call{Call: _e.mock.On("InsertFile", file)}
+// GetPlaylist is a helper method to define mock.On call
+//   - id int64
+func (_e *MockDatabase_Expecter) GetPlaylist(id interface{}) *MockDatabase_GetPlaylist_Call {
+	return &MockDatabase_GetPlaylist_Call{Call: _e.mock.On("GetPlaylist", id)}
 }
 
-func (_c *MockDatabase_InsertFile_Call) Run(run func(file *data.File)) *MockDatabase_InsertFile_Call {
+func (_c *MockDatabase_GetPlaylist_Call) Run(run func(id int64)) *MockDatabase_GetPlaylist_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*data.File))
+		run(args[0].(int64))
 	})
 	return _c
 }
 
-func (_c *MockDatabase_InsertFile_Call) Return(_a0 int64, _a1 error) *MockDatabase_InsertFile_Call {
+func (_c *MockDatabase_GetPlaylist_Call) Return(_a0 *data.Playlist, _a1 error) *MockDatabase_GetPlaylist_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDatabase_InsertFile_Call) RunAndReturn(run func(*data.File) (int64, error)) *MockDatabase_InsertFile_Call {
+func (_c *MockDatabase_GetPlaylist_Call) RunAndReturn(run func(int64) (*data.Playlist, error)) *MockDatabase_GetPlaylist_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateFilePath provides a mock function with given fields: file
-func (_m *MockDatabase) UpdateFilePath(file *data.File) error {
-	ret := _m.Called(file)
+// GetPlaylistEntries provides a mock function with given fields: id
+func (_m *MockDatabase) GetPlaylistEntries(id int64) ([]int64, error) {
+	ret := _m.Called(id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateFilePath")
+		panic("no return value specified for GetPlaylistEntries")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
-		r0 = rf(file)
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64) ([]int64, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int64) []int64); ok {
+		r0 = rf(id)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockDatabase_UpdateFilePath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFilePath'
-type MockDatabase_UpdateFilePath_Call struct {
+// MockDatabase_GetPlaylistEntries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlaylistEntries'
+type MockDatabase_GetPlaylistEntries_Call struct {
 	*mock.Call
 }
 
-// UpdateFilePath is a helper method to define mock.On call
-//   - file *data.File
-func (_e *MockDatabase_Expecter) UpdateFilePath(file interface{}) *MockDatabase_UpdateFilePath_Call {
-	return &MockDatabase_UpdateFilePath_Call{Call: _e.mock.On("UpdateFilePath", file)}
+// GetPlaylistEntries is a helper method to define mock.On call
+//   - id int64
+func (_e *MockDatabase_Expecter) GetPlaylistEntries(id interface{}) *MockDatabase_GetPlaylistEntries_Call {
+	return &MockDatabase_GetPlaylistEntries_Call{Call: _e.mock.On("GetPlaylistEntries", id)}
 }
 
-func (_c *MockDatabase_UpdateFilePath_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFilePath_Call {
+func (_c *MockDatabase_GetPlaylistEntries_Call) Run(run func(id int64)) *MockDatabase_GetPlaylistEntries_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*data.File))
+		run(args[0].(int64))
 	})
 	return _c
 }
 
-func (_c *MockDatabase_UpdateFilePath_Call) Return(_a0 error) *MockDatabase_UpdateFilePath_Call {
-	_c.Call.Return(_a0)
+func (_c *MockDatabase_GetPlaylistEntries_Call) Return(_a0 []int64, _a1 error) *MockDatabase_GetPlaylistEntries_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDatabase_UpdateFilePath_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFilePath_Call {
+func (_c *MockDatabase_GetPlaylistEntries_Call) RunAndReturn(run func(int64) ([]int64, error)) *MockDatabase_GetPlaylistEntries_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateFileStatus provides a mock function with given fields: file
-func (_m *MockDatabase) UpdateFileStatus(file *data.File) error {
-	ret := _m.Called(file)
+// GetPlaylists provides a mock function with no fields
+func (_m *MockDatabase) GetPlaylists() ([]data.Playlist, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateFileStatus")
+		panic("no return value specified for GetPlaylists")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
-		r0 = rf(file)
+	var r0 []data.Playlist
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]data.Playlist, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []data.Playlist); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.Playlist)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockDatabase_UpdateFileStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFileStatus'
-type MockDatabase_UpdateFileStatus_Call struct {
+// MockDatabase_GetPlaylists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlaylists'
+type MockDatabase_GetPlaylists_Call struct {
 	*mock.Call
 }
 
-// UpdateFileStatus is a helper method to define mock.On call
-//   - file *data.File
-func (_e *MockDatabase_Expecter) UpdateFileStatus(file interface{}) *MockDatabase_UpdateFileStatus_Call {
-	return &MockDatabase_UpdateFileStatus_Call{Call: _e.mock.On("UpdateFileStatus", file)}
+// GetPlaylists is a helper method to define mock.On call
+func (_e *MockDatabase_Expecter) GetPlaylists() *MockDatabase_GetPlaylists_Call {
+	return &MockDatabase_GetPlaylists_Call{Call: _e.mock.On("GetPlaylists")}
 }
 
-func (_c *MockDatabase_UpdateFileStatus_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFileStatus_Call {
+func (_c *MockDatabase_GetPlaylists_Call) Run(run func()) *MockDatabase_GetPlaylists_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*data.File))
+		run()
 	})
 	return _c
 }
 
-func (_c *MockDatabase_UpdateFileStatus_Call) Return(_a0 error) *MockDatabase_UpdateFileStatus_Call {
-	_c.Call.Return(_a0)
+func (_c *MockDatabase_GetPlaylists_Call) Return(_a0 []data.Playlist, _a1 error) *MockDatabase_GetPlaylists_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockDatabase_UpdateFileStatus_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFileStatus_Call {
+func (_c *MockDatabase_GetPlaylists_Call) RunAndReturn(run func() ([]data.Playlist, error)) *MockDatabase_GetPlaylists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSettings provides a mock function with no fields
+func (_m *MockDatabase) GetSettings() (*data.Settings, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSettings")
+	}
+
+	var r0 *data.Settings
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*data.Settings, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *data.Settings); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*data.Settings)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_GetSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSettings'
+type MockDatabase_GetSettings_Call struct {
+	*mock.Call
+}
+
+// GetSettings is a helper method to define mock.On call
+func (_e *MockDatabase_Expecter) GetSettings() *MockDatabase_GetSettings_Call {
+	return &MockDatabase_GetSettings_Call{Call: _e.mock.On("GetSettings")}
+}
+
+func (_c *MockDatabase_GetSettings_Call) Run(run func()) *MockDatabase_GetSettings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDatabase_GetSettings_Call) Return(_a0 *data.Settings, _a1 error) *MockDatabase_GetSettings_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_GetSettings_Call) RunAndReturn(run func() (*data.Settings, error)) *MockDatabase_GetSettings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTags provides a mock function with no fields
+func (_m *MockDatabase) GetTags() ([]data.Tag, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTags")
+	}
+
+	var r0 []data.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]data.Tag, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []data.Tag); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_GetTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTags'
+type MockDatabase_GetTags_Call struct {
+	*mock.Call
+}
+
+// GetTags is a helper method to define mock.On call
+func (_e *MockDatabase_Expecter) GetTags() *MockDatabase_GetTags_Call {
+	return &MockDatabase_GetTags_Call{Call: _e.mock.On("GetTags")}
+}
+
+func (_c *MockDatabase_GetTags_Call) Run(run func()) *MockDatabase_GetTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDatabase_GetTags_Call) Return(_a0 []data.Tag, _a1 error) *MockDatabase_GetTags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_GetTags_Call) RunAndReturn(run func() ([]data.Tag, error)) *MockDatabase_GetTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTrashedFiles provides a mock function with given fields: deletedBefore
+func (_m *MockDatabase) GetTrashedFiles(deletedBefore time.Time) ([]data.File, error) {
+	ret := _m.Called(deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTrashedFiles")
+	}
+
+	var r0 []data.File
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]data.File, error)); ok {
+		return rf(deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []data.File); ok {
+		r0 = rf(deletedBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.File)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_GetTrashedFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTrashedFiles'
+type MockDatabase_GetTrashedFiles_Call struct {
+	*mock.Call
+}
+
+// GetTrashedFiles is a helper method to define mock.On call
+//   - deletedBefore time.Time
+func (_e *MockDatabase_Expecter) GetTrashedFiles(deletedBefore interface{}) *MockDatabase_GetTrashedFiles_Call {
+	return &MockDatabase_GetTrashedFiles_Call{Call: _e.mock.On("GetTrashedFiles", deletedBefore)}
+}
+
+func (_c *MockDatabase_GetTrashedFiles_Call) Run(run func(deletedBefore time.Time)) *MockDatabase_GetTrashedFiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_GetTrashedFiles_Call) Return(_a0 []data.File, _a1 error) *MockDatabase_GetTrashedFiles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_GetTrashedFiles_Call) RunAndReturn(run func(time.Time) ([]data.File, error)) *MockDatabase_GetTrashedFiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertFile provides a mock function with given fields: file
+func (_m *MockDatabase) InsertFile(file *data.File) (int64, error) {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertFile")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*data.File) (int64, error)); ok {
+		return rf(file)
+	}
+	if rf, ok := ret.Get(0).(func(*data.File) int64); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(*data.File) error); ok {
+		r1 = rf(file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_InsertFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertFile'
+type MockDatabase_InsertFile_Call struct {
+	*mock.Call
+}
+
+// InsertFile is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) InsertFile(file interface{}) *MockDatabase_InsertFile_Call {
+	return &MockDatabase_InsertFile_Call{Call: _e.mock.On("InsertFile", file)}
+}
+
+func (_c *MockDatabase_InsertFile_Call) Run(run func(file *data.File)) *MockDatabase_InsertFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_InsertFile_Call) Return(_a0 int64, _a1 error) *MockDatabase_InsertFile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_InsertFile_Call) RunAndReturn(run func(*data.File) (int64, error)) *MockDatabase_InsertFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertPlaylist provides a mock function with given fields: name
+func (_m *MockDatabase) InsertPlaylist(name string) (int64, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertPlaylist")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int64, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_InsertPlaylist_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertPlaylist'
+type MockDatabase_InsertPlaylist_Call struct {
+	*mock.Call
+}
+
+// InsertPlaylist is a helper method to define mock.On call
+//   - name string
+func (_e *MockDatabase_Expecter) InsertPlaylist(name interface{}) *MockDatabase_InsertPlaylist_Call {
+	return &MockDatabase_InsertPlaylist_Call{Call: _e.mock.On("InsertPlaylist", name)}
+}
+
+func (_c *MockDatabase_InsertPlaylist_Call) Run(run func(name string)) *MockDatabase_InsertPlaylist_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_InsertPlaylist_Call) Return(_a0 int64, _a1 error) *MockDatabase_InsertPlaylist_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_InsertPlaylist_Call) RunAndReturn(run func(string) (int64, error)) *MockDatabase_InsertPlaylist_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsPathTaken provides a mock function with given fields: path
+func (_m *MockDatabase) IsPathTaken(path string) (bool, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsPathTaken")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDatabase_IsPathTaken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsPathTaken'
+type MockDatabase_IsPathTaken_Call struct {
+	*mock.Call
+}
+
+// IsPathTaken is a helper method to define mock.On call
+//   - path string
+func (_e *MockDatabase_Expecter) IsPathTaken(path interface{}) *MockDatabase_IsPathTaken_Call {
+	return &MockDatabase_IsPathTaken_Call{Call: _e.mock.On("IsPathTaken", path)}
+}
+
+func (_c *MockDatabase_IsPathTaken_Call) Run(run func(path string)) *MockDatabase_IsPathTaken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_IsPathTaken_Call) Return(_a0 bool, _a1 error) *MockDatabase_IsPathTaken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDatabase_IsPathTaken_Call) RunAndReturn(run func(string) (bool, error)) *MockDatabase_IsPathTaken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeFile provides a mock function with given fields: fileId
+func (_m *MockDatabase) ResumeFile(fileId int64) error {
+	ret := _m.Called(fileId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeFile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64) error); ok {
+		r0 = rf(fileId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_ResumeFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeFile'
+type MockDatabase_ResumeFile_Call struct {
+	*mock.Call
+}
+
+// ResumeFile is a helper method to define mock.On call
+//   - fileId int64
+func (_e *MockDatabase_Expecter) ResumeFile(fileId interface{}) *MockDatabase_ResumeFile_Call {
+	return &MockDatabase_ResumeFile_Call{Call: _e.mock.On("ResumeFile", fileId)}
+}
+
+func (_c *MockDatabase_ResumeFile_Call) Run(run func(fileId int64)) *MockDatabase_ResumeFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_ResumeFile_Call) Return(_a0 error) *MockDatabase_ResumeFile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_ResumeFile_Call) RunAndReturn(run func(int64) error) *MockDatabase_ResumeFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetPlaylistEntries provides a mock function with given fields: id, fileIds
+func (_m *MockDatabase) SetPlaylistEntries(id int64, fileIds []int64) error {
+	ret := _m.Called(id, fileIds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPlaylistEntries")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, []int64) error); ok {
+		r0 = rf(id, fileIds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_SetPlaylistEntries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetPlaylistEntries'
+type MockDatabase_SetPlaylistEntries_Call struct {
+	*mock.Call
+}
+
+// SetPlaylistEntries is a helper method to define mock.On call
+//   - id int64
+//   - fileIds []int64
+func (_e *MockDatabase_Expecter) SetPlaylistEntries(id interface{}, fileIds interface{}) *MockDatabase_SetPlaylistEntries_Call {
+	return &MockDatabase_SetPlaylistEntries_Call{Call: _e.mock.On("SetPlaylistEntries", id, fileIds)}
+}
+
+func (_c *MockDatabase_SetPlaylistEntries_Call) Run(run func(id int64, fileIds []int64)) *MockDatabase_SetPlaylistEntries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].([]int64))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_SetPlaylistEntries_Call) Return(_a0 error) *MockDatabase_SetPlaylistEntries_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_SetPlaylistEntries_Call) RunAndReturn(run func(int64, []int64) error) *MockDatabase_SetPlaylistEntries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TagFiles provides a mock function with given fields: fileIds, tags
+func (_m *MockDatabase) TagFiles(fileIds []int64, tags []string) error {
+	ret := _m.Called(fileIds, tags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TagFiles")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int64, []string) error); ok {
+		r0 = rf(fileIds, tags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_TagFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TagFiles'
+type MockDatabase_TagFiles_Call struct {
+	*mock.Call
+}
+
+// TagFiles is a helper method to define mock.On call
+//   - fileIds []int64
+//   - tags []string
+func (_e *MockDatabase_Expecter) TagFiles(fileIds interface{}, tags interface{}) *MockDatabase_TagFiles_Call {
+	return &MockDatabase_TagFiles_Call{Call: _e.mock.On("TagFiles", fileIds, tags)}
+}
+
+func (_c *MockDatabase_TagFiles_Call) Run(run func(fileIds []int64, tags []string)) *MockDatabase_TagFiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int64), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_TagFiles_Call) Return(_a0 error) *MockDatabase_TagFiles_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_TagFiles_Call) RunAndReturn(run func([]int64, []string) error) *MockDatabase_TagFiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UntagFiles provides a mock function with given fields: fileIds, tags
+func (_m *MockDatabase) UntagFiles(fileIds []int64, tags []string) error {
+	ret := _m.Called(fileIds, tags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UntagFiles")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int64, []string) error); ok {
+		r0 = rf(fileIds, tags)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UntagFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UntagFiles'
+type MockDatabase_UntagFiles_Call struct {
+	*mock.Call
+}
+
+// UntagFiles is a helper method to define mock.On call
+//   - fileIds []int64
+//   - tags []string
+func (_e *MockDatabase_Expecter) UntagFiles(fileIds interface{}, tags interface{}) *MockDatabase_UntagFiles_Call {
+	return &MockDatabase_UntagFiles_Call{Call: _e.mock.On("UntagFiles", fileIds, tags)}
+}
+
+func (_c *MockDatabase_UntagFiles_Call) Run(run func(fileIds []int64, tags []string)) *MockDatabase_UntagFiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int64), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UntagFiles_Call) Return(_a0 error) *MockDatabase_UntagFiles_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UntagFiles_Call) RunAndReturn(run func([]int64, []string) error) *MockDatabase_UntagFiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateFileDeletedAt provides a mock function with given fields: file
+func (_m *MockDatabase) UpdateFileDeletedAt(file *data.File) error {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileDeletedAt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdateFileDeletedAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFileDeletedAt'
+type MockDatabase_UpdateFileDeletedAt_Call struct {
+	*mock.Call
+}
+
+// UpdateFileDeletedAt is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) UpdateFileDeletedAt(file interface{}) *MockDatabase_UpdateFileDeletedAt_Call {
+	return &MockDatabase_UpdateFileDeletedAt_Call{Call: _e.mock.On("UpdateFileDeletedAt", file)}
+}
+
+func (_c *MockDatabase_UpdateFileDeletedAt_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFileDeletedAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileDeletedAt_Call) Return(_a0 error) *MockDatabase_UpdateFileDeletedAt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileDeletedAt_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFileDeletedAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateFileDigest provides a mock function with given fields: file
+func (_m *MockDatabase) UpdateFileDigest(file *data.File) error {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileDigest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdateFileDigest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFileDigest'
+type MockDatabase_UpdateFileDigest_Call struct {
+	*mock.Call
+}
+
+// UpdateFileDigest is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) UpdateFileDigest(file interface{}) *MockDatabase_UpdateFileDigest_Call {
+	return &MockDatabase_UpdateFileDigest_Call{Call: _e.mock.On("UpdateFileDigest", file)}
+}
+
+func (_c *MockDatabase_UpdateFileDigest_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFileDigest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileDigest_Call) Return(_a0 error) *MockDatabase_UpdateFileDigest_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileDigest_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFileDigest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateFileMetadata provides a mock function with given fields: file
+func (_m *MockDatabase) UpdateFileMetadata(file *data.File) error {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileMetadata")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdateFileMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFileMetadata'
+type MockDatabase_UpdateFileMetadata_Call struct {
+	*mock.Call
+}
+
+// UpdateFileMetadata is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) UpdateFileMetadata(file interface{}) *MockDatabase_UpdateFileMetadata_Call {
+	return &MockDatabase_UpdateFileMetadata_Call{Call: _e.mock.On("UpdateFileMetadata", file)}
+}
+
+func (_c *MockDatabase_UpdateFileMetadata_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFileMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileMetadata_Call) Return(_a0 error) *MockDatabase_UpdateFileMetadata_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileMetadata_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFileMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateFilePath provides a mock function with given fields: file
+func (_m *MockDatabase) UpdateFilePath(file *data.File) error {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFilePath")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdateFilePath_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFilePath'
+type MockDatabase_UpdateFilePath_Call struct {
+	*mock.Call
+}
+
+// UpdateFilePath is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) UpdateFilePath(file interface{}) *MockDatabase_UpdateFilePath_Call {
+	return &MockDatabase_UpdateFilePath_Call{Call: _e.mock.On("UpdateFilePath", file)}
+}
+
+func (_c *MockDatabase_UpdateFilePath_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFilePath_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFilePath_Call) Return(_a0 error) *MockDatabase_UpdateFilePath_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFilePath_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFilePath_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateFileStatus provides a mock function with given fields: file
+func (_m *MockDatabase) UpdateFileStatus(file *data.File) error {
+	ret := _m.Called(file)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*data.File) error); ok {
+		r0 = rf(file)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdateFileStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFileStatus'
+type MockDatabase_UpdateFileStatus_Call struct {
+	*mock.Call
+}
+
+// UpdateFileStatus is a helper method to define mock.On call
+//   - file *data.File
+func (_e *MockDatabase_Expecter) UpdateFileStatus(file interface{}) *MockDatabase_UpdateFileStatus_Call {
+	return &MockDatabase_UpdateFileStatus_Call{Call: _e.mock.On("UpdateFileStatus", file)}
+}
+
+func (_c *MockDatabase_UpdateFileStatus_Call) Run(run func(file *data.File)) *MockDatabase_UpdateFileStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*data.File))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileStatus_Call) Return(_a0 error) *MockDatabase_UpdateFileStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdateFileStatus_Call) RunAndReturn(run func(*data.File) error) *MockDatabase_UpdateFileStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePlaylistName provides a mock function with given fields: id, name
+func (_m *MockDatabase) UpdatePlaylistName(id int64, name string) error {
+	ret := _m.Called(id, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePlaylistName")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, string) error); ok {
+		r0 = rf(id, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDatabase_UpdatePlaylistName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePlaylistName'
+type MockDatabase_UpdatePlaylistName_Call struct {
+	*mock.Call
+}
+
+// UpdatePlaylistName is a helper method to define mock.On call
+//   - id int64
+//   - name string
+func (_e *MockDatabase_Expecter) UpdatePlaylistName(id interface{}, name interface{}) *MockDatabase_UpdatePlaylistName_Call {
+	return &MockDatabase_UpdatePlaylistName_Call{Call: _e.mock.On("UpdatePlaylistName", id, name)}
+}
+
+func (_c *MockDatabase_UpdatePlaylistName_Call) Run(run func(id int64, name string)) *MockDatabase_UpdatePlaylistName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDatabase_UpdatePlaylistName_Call) Return(_a0 error) *MockDatabase_UpdatePlaylistName_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDatabase_UpdatePlaylistName_Call) RunAndReturn(run func(int64, string) error) *MockDatabase_UpdatePlaylistName_Call {
 	_c.Call.Return(run)
 	return _c
 }
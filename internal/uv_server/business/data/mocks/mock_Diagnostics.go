@@ -0,0 +1,201 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	data "uv_server/internal/uv_server/business/data"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockDiagnostics is an autogenerated mock type for the Diagnostics type
+type MockDiagnostics struct {
+	mock.Mock
+}
+
+type MockDiagnostics_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDiagnostics) EXPECT() *MockDiagnostics_Expecter {
+	return &MockDiagnostics_Expecter{mock: &_m.Mock}
+}
+
+// GetDbVersion provides a mock function with no fields
+func (_m *MockDiagnostics) GetDbVersion() (int, int, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDbVersion")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func() (int, int, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func() int); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockDiagnostics_GetDbVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDbVersion'
+type MockDiagnostics_GetDbVersion_Call struct {
+	*mock.Call
+}
+
+// GetDbVersion is a helper method to define mock.On call
+func (_e *MockDiagnostics_Expecter) GetDbVersion() *MockDiagnostics_GetDbVersion_Call {
+	return &MockDiagnostics_GetDbVersion_Call{Call: _e.mock.On("GetDbVersion")}
+}
+
+func (_c *MockDiagnostics_GetDbVersion_Call) Run(run func()) *MockDiagnostics_GetDbVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDiagnostics_GetDbVersion_Call) Return(current int, required int, err error) *MockDiagnostics_GetDbVersion_Call {
+	_c.Call.Return(current, required, err)
+	return _c
+}
+
+func (_c *MockDiagnostics_GetDbVersion_Call) RunAndReturn(run func() (int, int, error)) *MockDiagnostics_GetDbVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFreeSpace provides a mock function with given fields: path
+func (_m *MockDiagnostics) GetFreeSpace(path string) (uint64, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFreeSpace")
+	}
+
+	var r0 uint64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (uint64, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDiagnostics_GetFreeSpace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFreeSpace'
+type MockDiagnostics_GetFreeSpace_Call struct {
+	*mock.Call
+}
+
+// GetFreeSpace is a helper method to define mock.On call
+//   - path string
+func (_e *MockDiagnostics_Expecter) GetFreeSpace(path interface{}) *MockDiagnostics_GetFreeSpace_Call {
+	return &MockDiagnostics_GetFreeSpace_Call{Call: _e.mock.On("GetFreeSpace", path)}
+}
+
+func (_c *MockDiagnostics_GetFreeSpace_Call) Run(run func(path string)) *MockDiagnostics_GetFreeSpace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockDiagnostics_GetFreeSpace_Call) Return(_a0 uint64, _a1 error) *MockDiagnostics_GetFreeSpace_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDiagnostics_GetFreeSpace_Call) RunAndReturn(run func(string) (uint64, error)) *MockDiagnostics_GetFreeSpace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTools provides a mock function with no fields
+func (_m *MockDiagnostics) GetTools() []data.ToolInfo {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTools")
+	}
+
+	var r0 []data.ToolInfo
+	if rf, ok := ret.Get(0).(func() []data.ToolInfo); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]data.ToolInfo)
+		}
+	}
+
+	return r0
+}
+
+// MockDiagnostics_GetTools_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTools'
+type MockDiagnostics_GetTools_Call struct {
+	*mock.Call
+}
+
+// GetTools is a helper method to define mock.On call
+func (_e *MockDiagnostics_Expecter) GetTools() *MockDiagnostics_GetTools_Call {
+	return &MockDiagnostics_GetTools_Call{Call: _e.mock.On("GetTools")}
+}
+
+func (_c *MockDiagnostics_GetTools_Call) Run(run func()) *MockDiagnostics_GetTools_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDiagnostics_GetTools_Call) Return(_a0 []data.ToolInfo) *MockDiagnostics_GetTools_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDiagnostics_GetTools_Call) RunAndReturn(run func() []data.ToolInfo) *MockDiagnostics_GetTools_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDiagnostics creates a new instance of MockDiagnostics. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDiagnostics(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDiagnostics {
+	mock := &MockDiagnostics{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,151 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockDownloadQueue is an autogenerated mock type for the DownloadQueue type
+type MockDownloadQueue struct {
+	mock.Mock
+}
+
+type MockDownloadQueue_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDownloadQueue) EXPECT() *MockDownloadQueue_Expecter {
+	return &MockDownloadQueue_Expecter{mock: &_m.Mock}
+}
+
+// Acquire provides a mock function with given fields: ctx
+func (_m *MockDownloadQueue) Acquire(ctx context.Context) (func(), error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Acquire")
+	}
+
+	var r0 func()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (func(), error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) func()); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDownloadQueue_Acquire_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Acquire'
+type MockDownloadQueue_Acquire_Call struct {
+	*mock.Call
+}
+
+// Acquire is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockDownloadQueue_Expecter) Acquire(ctx interface{}) *MockDownloadQueue_Acquire_Call {
+	return &MockDownloadQueue_Acquire_Call{Call: _e.mock.On("Acquire", ctx)}
+}
+
+func (_c *MockDownloadQueue_Acquire_Call) Run(run func(ctx context.Context)) *MockDownloadQueue_Acquire_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockDownloadQueue_Acquire_Call) Return(release func(), err error) *MockDownloadQueue_Acquire_Call {
+	_c.Call.Return(release, err)
+	return _c
+}
+
+func (_c *MockDownloadQueue_Acquire_Call) RunAndReturn(run func(context.Context) (func(), error)) *MockDownloadQueue_Acquire_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TryAcquire provides a mock function with no fields
+func (_m *MockDownloadQueue) TryAcquire() (func(), bool) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for TryAcquire")
+	}
+
+	var r0 func()
+	var r1 bool
+	if rf, ok := ret.Get(0).(func() (func(), bool)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() func()); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() bool); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockDownloadQueue_TryAcquire_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TryAcquire'
+type MockDownloadQueue_TryAcquire_Call struct {
+	*mock.Call
+}
+
+// TryAcquire is a helper method to define mock.On call
+func (_e *MockDownloadQueue_Expecter) TryAcquire() *MockDownloadQueue_TryAcquire_Call {
+	return &MockDownloadQueue_TryAcquire_Call{Call: _e.mock.On("TryAcquire")}
+}
+
+func (_c *MockDownloadQueue_TryAcquire_Call) Run(run func()) *MockDownloadQueue_TryAcquire_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDownloadQueue_TryAcquire_Call) Return(release func(), ok bool) *MockDownloadQueue_TryAcquire_Call {
+	_c.Call.Return(release, ok)
+	return _c
+}
+
+func (_c *MockDownloadQueue_TryAcquire_Call) RunAndReturn(run func() (func(), bool)) *MockDownloadQueue_TryAcquire_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDownloadQueue creates a new instance of MockDownloadQueue. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDownloadQueue(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDownloadQueue {
+	mock := &MockDownloadQueue{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,184 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockDownloadRegistry is an autogenerated mock type for the DownloadRegistry type
+type MockDownloadRegistry struct {
+	mock.Mock
+}
+
+type MockDownloadRegistry_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDownloadRegistry) EXPECT() *MockDownloadRegistry_Expecter {
+	return &MockDownloadRegistry_Expecter{mock: &_m.Mock}
+}
+
+// Cancel provides a mock function with given fields: fileId
+func (_m *MockDownloadRegistry) Cancel(fileId int64) (<-chan struct{}, bool) {
+	ret := _m.Called(fileId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Cancel")
+	}
+
+	var r0 <-chan struct{}
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(int64) (<-chan struct{}, bool)); ok {
+		return rf(fileId)
+	}
+	if rf, ok := ret.Get(0).(func(int64) <-chan struct{}); ok {
+		r0 = rf(fileId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int64) bool); ok {
+		r1 = rf(fileId)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// MockDownloadRegistry_Cancel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Cancel'
+type MockDownloadRegistry_Cancel_Call struct {
+	*mock.Call
+}
+
+// Cancel is a helper method to define mock.On call
+//   - fileId int64
+func (_e *MockDownloadRegistry_Expecter) Cancel(fileId interface{}) *MockDownloadRegistry_Cancel_Call {
+	return &MockDownloadRegistry_Cancel_Call{Call: _e.mock.On("Cancel", fileId)}
+}
+
+func (_c *MockDownloadRegistry_Cancel_Call) Run(run func(fileId int64)) *MockDownloadRegistry_Cancel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Cancel_Call) Return(_a0 <-chan struct{}, _a1 bool) *MockDownloadRegistry_Cancel_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Cancel_Call) RunAndReturn(run func(int64) (<-chan struct{}, bool)) *MockDownloadRegistry_Cancel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Register provides a mock function with given fields: claim, cancel
+func (_m *MockDownloadRegistry) Register(claim func() (int64, error), cancel context.CancelFunc) (int64, error) {
+	ret := _m.Called(claim, cancel)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(func() (int64, error), context.CancelFunc) (int64, error)); ok {
+		return rf(claim, cancel)
+	}
+	if rf, ok := ret.Get(0).(func(func() (int64, error), context.CancelFunc) int64); ok {
+		r0 = rf(claim, cancel)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(func() (int64, error), context.CancelFunc) error); ok {
+		r1 = rf(claim, cancel)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDownloadRegistry_Register_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Register'
+type MockDownloadRegistry_Register_Call struct {
+	*mock.Call
+}
+
+// Register is a helper method to define mock.On call
+//   - claim func()(int64 , error)
+//   - cancel context.CancelFunc
+func (_e *MockDownloadRegistry_Expecter) Register(claim interface{}, cancel interface{}) *MockDownloadRegistry_Register_Call {
+	return &MockDownloadRegistry_Register_Call{Call: _e.mock.On("Register", claim, cancel)}
+}
+
+func (_c *MockDownloadRegistry_Register_Call) Run(run func(claim func() (int64, error), cancel context.CancelFunc)) *MockDownloadRegistry_Register_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(func() (int64, error)), args[1].(context.CancelFunc))
+	})
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Register_Call) Return(_a0 int64, _a1 error) *MockDownloadRegistry_Register_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Register_Call) RunAndReturn(run func(func() (int64, error), context.CancelFunc) (int64, error)) *MockDownloadRegistry_Register_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unregister provides a mock function with given fields: fileId
+func (_m *MockDownloadRegistry) Unregister(fileId int64) {
+	_m.Called(fileId)
+}
+
+// MockDownloadRegistry_Unregister_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unregister'
+type MockDownloadRegistry_Unregister_Call struct {
+	*mock.Call
+}
+
+// Unregister is a helper method to define mock.On call
+//   - fileId int64
+func (_e *MockDownloadRegistry_Expecter) Unregister(fileId interface{}) *MockDownloadRegistry_Unregister_Call {
+	return &MockDownloadRegistry_Unregister_Call{Call: _e.mock.On("Unregister", fileId)}
+}
+
+func (_c *MockDownloadRegistry_Unregister_Call) Run(run func(fileId int64)) *MockDownloadRegistry_Unregister_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int64))
+	})
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Unregister_Call) Return() *MockDownloadRegistry_Unregister_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockDownloadRegistry_Unregister_Call) RunAndReturn(run func(int64)) *MockDownloadRegistry_Unregister_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockDownloadRegistry creates a new instance of MockDownloadRegistry. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDownloadRegistry(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDownloadRegistry {
+	mock := &MockDownloadRegistry{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
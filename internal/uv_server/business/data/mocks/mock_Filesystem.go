@@ -1,8 +1,12 @@
-// Code generated by mockery v2.53.3. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	data "uv_server/internal/uv_server/business/data"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockFilesystem is an autogenerated mock type for the Filesystem type
 type MockFilesystem struct {
@@ -17,6 +21,99 @@ func (_m *MockFilesystem) EXPECT() *MockFilesystem_Expecter {
 	return &MockFilesystem_Expecter{mock: &_m.Mock}
 }
 
+// CopyFile provides a mock function with given fields: src, dst
+func (_m *MockFilesystem) CopyFile(src string, dst string) error {
+	ret := _m.Called(src, dst)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CopyFile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(src, dst)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_CopyFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CopyFile'
+type MockFilesystem_CopyFile_Call struct {
+	*mock.Call
+}
+
+// CopyFile is a helper method to define mock.On call
+//   - src string
+//   - dst string
+func (_e *MockFilesystem_Expecter) CopyFile(src interface{}, dst interface{}) *MockFilesystem_CopyFile_Call {
+	return &MockFilesystem_CopyFile_Call{Call: _e.mock.On("CopyFile", src, dst)}
+}
+
+func (_c *MockFilesystem_CopyFile_Call) Run(run func(src string, dst string)) *MockFilesystem_CopyFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_CopyFile_Call) Return(_a0 error) *MockFilesystem_CopyFile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_CopyFile_Call) RunAndReturn(run func(string, string) error) *MockFilesystem_CopyFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteDirectory provides a mock function with given fields: path
+func (_m *MockFilesystem) DeleteDirectory(path string) error {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDirectory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_DeleteDirectory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDirectory'
+type MockFilesystem_DeleteDirectory_Call struct {
+	*mock.Call
+}
+
+// DeleteDirectory is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) DeleteDirectory(path interface{}) *MockFilesystem_DeleteDirectory_Call {
+	return &MockFilesystem_DeleteDirectory_Call{Call: _e.mock.On("DeleteDirectory", path)}
+}
+
+func (_c *MockFilesystem_DeleteDirectory_Call) Run(run func(path string)) *MockFilesystem_DeleteDirectory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_DeleteDirectory_Call) Return(_a0 error) *MockFilesystem_DeleteDirectory_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_DeleteDirectory_Call) RunAndReturn(run func(string) error) *MockFilesystem_DeleteDirectory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteFile provides a mock function with given fields: path
 func (_m *MockFilesystem) DeleteFile(path string) error {
 	ret := _m.Called(path)
@@ -63,6 +160,422 @@ func (_c *MockFilesystem_DeleteFile_Call) RunAndReturn(run func(string) error) *
 	return _c
 }
 
+// EnsureDirectory provides a mock function with given fields: path
+func (_m *MockFilesystem) EnsureDirectory(path string) error {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureDirectory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_EnsureDirectory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnsureDirectory'
+type MockFilesystem_EnsureDirectory_Call struct {
+	*mock.Call
+}
+
+// EnsureDirectory is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) EnsureDirectory(path interface{}) *MockFilesystem_EnsureDirectory_Call {
+	return &MockFilesystem_EnsureDirectory_Call{Call: _e.mock.On("EnsureDirectory", path)}
+}
+
+func (_c *MockFilesystem_EnsureDirectory_Call) Run(run func(path string)) *MockFilesystem_EnsureDirectory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_EnsureDirectory_Call) Return(_a0 error) *MockFilesystem_EnsureDirectory_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_EnsureDirectory_Call) RunAndReturn(run func(string) error) *MockFilesystem_EnsureDirectory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Exists provides a mock function with given fields: path
+func (_m *MockFilesystem) Exists(path string) (bool, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFilesystem_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
+type MockFilesystem_Exists_Call struct {
+	*mock.Call
+}
+
+// Exists is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) Exists(path interface{}) *MockFilesystem_Exists_Call {
+	return &MockFilesystem_Exists_Call{Call: _e.mock.On("Exists", path)}
+}
+
+func (_c *MockFilesystem_Exists_Call) Run(run func(path string)) *MockFilesystem_Exists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_Exists_Call) Return(_a0 bool, _a1 error) *MockFilesystem_Exists_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFilesystem_Exists_Call) RunAndReturn(run func(string) (bool, error)) *MockFilesystem_Exists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HashFile provides a mock function with given fields: path
+func (_m *MockFilesystem) HashFile(path string) (*data.FileDigest, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HashFile")
+	}
+
+	var r0 *data.FileDigest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*data.FileDigest, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) *data.FileDigest); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*data.FileDigest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFilesystem_HashFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HashFile'
+type MockFilesystem_HashFile_Call struct {
+	*mock.Call
+}
+
+// HashFile is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) HashFile(path interface{}) *MockFilesystem_HashFile_Call {
+	return &MockFilesystem_HashFile_Call{Call: _e.mock.On("HashFile", path)}
+}
+
+func (_c *MockFilesystem_HashFile_Call) Run(run func(path string)) *MockFilesystem_HashFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_HashFile_Call) Return(_a0 *data.FileDigest, _a1 error) *MockFilesystem_HashFile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFilesystem_HashFile_Call) RunAndReturn(run func(string) (*data.FileDigest, error)) *MockFilesystem_HashFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListFiles provides a mock function with given fields: root
+func (_m *MockFilesystem) ListFiles(root string) ([]string, error) {
+	ret := _m.Called(root)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiles")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
+		return rf(root)
+	}
+	if rf, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = rf(root)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(root)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFilesystem_ListFiles_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListFiles'
+type MockFilesystem_ListFiles_Call struct {
+	*mock.Call
+}
+
+// ListFiles is a helper method to define mock.On call
+//   - root string
+func (_e *MockFilesystem_Expecter) ListFiles(root interface{}) *MockFilesystem_ListFiles_Call {
+	return &MockFilesystem_ListFiles_Call{Call: _e.mock.On("ListFiles", root)}
+}
+
+func (_c *MockFilesystem_ListFiles_Call) Run(run func(root string)) *MockFilesystem_ListFiles_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_ListFiles_Call) Return(_a0 []string, _a1 error) *MockFilesystem_ListFiles_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFilesystem_ListFiles_Call) RunAndReturn(run func(string) ([]string, error)) *MockFilesystem_ListFiles_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MoveFile provides a mock function with given fields: src, dst
+func (_m *MockFilesystem) MoveFile(src string, dst string) error {
+	ret := _m.Called(src, dst)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveFile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(src, dst)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_MoveFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MoveFile'
+type MockFilesystem_MoveFile_Call struct {
+	*mock.Call
+}
+
+// MoveFile is a helper method to define mock.On call
+//   - src string
+//   - dst string
+func (_e *MockFilesystem_Expecter) MoveFile(src interface{}, dst interface{}) *MockFilesystem_MoveFile_Call {
+	return &MockFilesystem_MoveFile_Call{Call: _e.mock.On("MoveFile", src, dst)}
+}
+
+func (_c *MockFilesystem_MoveFile_Call) Run(run func(src string, dst string)) *MockFilesystem_MoveFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_MoveFile_Call) Return(_a0 error) *MockFilesystem_MoveFile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_MoveFile_Call) RunAndReturn(run func(string, string) error) *MockFilesystem_MoveFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReadFile provides a mock function with given fields: path
+func (_m *MockFilesystem) ReadFile(path string) ([]byte, error) {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadFile")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]byte, error)); ok {
+		return rf(path)
+	}
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockFilesystem_ReadFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReadFile'
+type MockFilesystem_ReadFile_Call struct {
+	*mock.Call
+}
+
+// ReadFile is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) ReadFile(path interface{}) *MockFilesystem_ReadFile_Call {
+	return &MockFilesystem_ReadFile_Call{Call: _e.mock.On("ReadFile", path)}
+}
+
+func (_c *MockFilesystem_ReadFile_Call) Run(run func(path string)) *MockFilesystem_ReadFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_ReadFile_Call) Return(_a0 []byte, _a1 error) *MockFilesystem_ReadFile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockFilesystem_ReadFile_Call) RunAndReturn(run func(string) ([]byte, error)) *MockFilesystem_ReadFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ValidateDirectory provides a mock function with given fields: path
+func (_m *MockFilesystem) ValidateDirectory(path string) error {
+	ret := _m.Called(path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateDirectory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_ValidateDirectory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ValidateDirectory'
+type MockFilesystem_ValidateDirectory_Call struct {
+	*mock.Call
+}
+
+// ValidateDirectory is a helper method to define mock.On call
+//   - path string
+func (_e *MockFilesystem_Expecter) ValidateDirectory(path interface{}) *MockFilesystem_ValidateDirectory_Call {
+	return &MockFilesystem_ValidateDirectory_Call{Call: _e.mock.On("ValidateDirectory", path)}
+}
+
+func (_c *MockFilesystem_ValidateDirectory_Call) Run(run func(path string)) *MockFilesystem_ValidateDirectory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_ValidateDirectory_Call) Return(_a0 error) *MockFilesystem_ValidateDirectory_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_ValidateDirectory_Call) RunAndReturn(run func(string) error) *MockFilesystem_ValidateDirectory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteFile provides a mock function with given fields: path, content
+func (_m *MockFilesystem) WriteFile(path string, content []byte) error {
+	ret := _m.Called(path, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteFile")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(path, content)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockFilesystem_WriteFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteFile'
+type MockFilesystem_WriteFile_Call struct {
+	*mock.Call
+}
+
+// WriteFile is a helper method to define mock.On call
+//   - path string
+//   - content []byte
+func (_e *MockFilesystem_Expecter) WriteFile(path interface{}, content interface{}) *MockFilesystem_WriteFile_Call {
+	return &MockFilesystem_WriteFile_Call{Call: _e.mock.On("WriteFile", path, content)}
+}
+
+func (_c *MockFilesystem_WriteFile_Call) Run(run func(path string, content []byte)) *MockFilesystem_WriteFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *MockFilesystem_WriteFile_Call) Return(_a0 error) *MockFilesystem_WriteFile_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockFilesystem_WriteFile_Call) RunAndReturn(run func(string, []byte) error) *MockFilesystem_WriteFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockFilesystem creates a new instance of MockFilesystem. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockFilesystem(t interface {
@@ -0,0 +1,126 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockLogLevels is an autogenerated mock type for the LogLevels type
+type MockLogLevels struct {
+	mock.Mock
+}
+
+type MockLogLevels_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockLogLevels) EXPECT() *MockLogLevels_Expecter {
+	return &MockLogLevels_Expecter{mock: &_m.Mock}
+}
+
+// GetLevels provides a mock function with no fields
+func (_m *MockLogLevels) GetLevels() map[string]string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLevels")
+	}
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func() map[string]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	return r0
+}
+
+// MockLogLevels_GetLevels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLevels'
+type MockLogLevels_GetLevels_Call struct {
+	*mock.Call
+}
+
+// GetLevels is a helper method to define mock.On call
+func (_e *MockLogLevels_Expecter) GetLevels() *MockLogLevels_GetLevels_Call {
+	return &MockLogLevels_GetLevels_Call{Call: _e.mock.On("GetLevels")}
+}
+
+func (_c *MockLogLevels_GetLevels_Call) Run(run func()) *MockLogLevels_GetLevels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockLogLevels_GetLevels_Call) Return(_a0 map[string]string) *MockLogLevels_GetLevels_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockLogLevels_GetLevels_Call) RunAndReturn(run func() map[string]string) *MockLogLevels_GetLevels_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLevel provides a mock function with given fields: layer, level
+func (_m *MockLogLevels) SetLevel(layer string, level string) error {
+	ret := _m.Called(layer, level)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLevel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(layer, level)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockLogLevels_SetLevel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLevel'
+type MockLogLevels_SetLevel_Call struct {
+	*mock.Call
+}
+
+// SetLevel is a helper method to define mock.On call
+//   - layer string
+//   - level string
+func (_e *MockLogLevels_Expecter) SetLevel(layer interface{}, level interface{}) *MockLogLevels_SetLevel_Call {
+	return &MockLogLevels_SetLevel_Call{Call: _e.mock.On("SetLevel", layer, level)}
+}
+
+func (_c *MockLogLevels_SetLevel_Call) Run(run func(layer string, level string)) *MockLogLevels_SetLevel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockLogLevels_SetLevel_Call) Return(_a0 error) *MockLogLevels_SetLevel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockLogLevels_SetLevel_Call) RunAndReturn(run func(string, string) error) *MockLogLevels_SetLevel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockLogLevels creates a new instance of MockLogLevels. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockLogLevels(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockLogLevels {
+	mock := &MockLogLevels{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
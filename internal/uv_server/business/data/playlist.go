@@ -0,0 +1,38 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MaxNameLength = 128
+
+type Playlist struct {
+	Id        int64
+	Name      string
+	Entries   int
+	AddedAt   time.Time
+	UpdatedAt time.Time
+}
+
+type Tag struct {
+	Name  string
+	Files int
+}
+
+// NormalizeName trims a playlist or a tag name and checks its length
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", fmt.Errorf("name is empty")
+	}
+
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", fmt.Errorf("name is longer than %v characters", MaxNameLength)
+	}
+
+	return name, nil
+}
@@ -1,5 +1,119 @@
 package data
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"uv_server/internal/uv_server/common/filenames"
+	"uv_server/internal/uv_server/common/schedule"
+)
+
+const (
+	SettingStorageDir             = "storage_dir"
+	SettingMaxConcurrentDownloads = "max_concurrent_downloads"
+	SettingDefaultFormat          = "default_format"
+	SettingFilenameTemplate       = "filename_template"
+	SettingBandwidthLimit         = "bandwidth_limit"
+	SettingDownloadBandwidthLimit = "download_bandwidth_limit"
+	SettingDownloadSchedule       = "download_schedule"
+	SettingTrashRetentionDays     = "trash_retention_days"
+)
+
+const (
+	MinConcurrentDownloads = 1
+	MaxConcurrentDownloads = 16
+)
+
+var SupportedFormats = []string{"mp3", "m4a", "opus", "flac", "wav"}
+
 type Settings struct {
-	StorageDir string `json:"storage_dir"`
+	StorageDir             string `json:"storage_dir"`
+	MaxConcurrentDownloads int    `json:"max_concurrent_downloads"`
+	DefaultFormat          string `json:"default_format"`
+	FilenameTemplate       string `json:"filename_template"`
+	// Bytes per second of all downloads, 0 means unlimited
+	BandwidthLimit int64 `json:"bandwidth_limit"`
+	// Bytes per second of a single download, 0 means unlimited
+	DownloadBandwidthLimit int64 `json:"download_bandwidth_limit"`
+	// Windows during which queued downloads may start, like
+	// "mon-fri 18:00-08:00, sat-sun 00:00-24:00", empty means any time
+	DownloadSchedule string `json:"download_schedule"`
+	// Trashed files are purged after this many days, 0 keeps them forever
+	TrashRetentionDays int `json:"trash_retention_days"`
+}
+
+// DownloadRate returns the bandwidth limit of a single download, the
+// global limit is shared equally by the downloads which may run at once
+func (s *Settings) DownloadRate() int64 {
+	limit := s.DownloadBandwidthLimit
+
+	if s.BandwidthLimit > 0 {
+		share := max(s.BandwidthLimit/int64(s.MaxConcurrentDownloads), 1)
+		if limit == 0 || share < limit {
+			limit = share
+		}
+	}
+
+	return limit
+}
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		StorageDir:             "./storage",
+		MaxConcurrentDownloads: 3,
+		DefaultFormat:          "mp3",
+		FilenameTemplate:       "{title}",
+		BandwidthLimit:         0,
+		DownloadBandwidthLimit: 0,
+		DownloadSchedule:       "",
+		TrashRetentionDays:     30,
+	}
+}
+
+// Validate checks values which do not depend on the environment,
+// storage directory existence is checked by the workflows
+func (s *Settings) Validate() error {
+	if strings.TrimSpace(s.StorageDir) == "" {
+		return fmt.Errorf("\"%v\" is empty", SettingStorageDir)
+	}
+
+	if s.MaxConcurrentDownloads < MinConcurrentDownloads ||
+		s.MaxConcurrentDownloads > MaxConcurrentDownloads {
+		return fmt.Errorf(
+			"\"%v\" must be in range [%v, %v]",
+			SettingMaxConcurrentDownloads,
+			MinConcurrentDownloads,
+			MaxConcurrentDownloads)
+	}
+
+	if !slices.Contains(SupportedFormats, s.DefaultFormat) {
+		return fmt.Errorf(
+			"\"%v\" must be one of: %v",
+			SettingDefaultFormat,
+			strings.Join(SupportedFormats, ", "))
+	}
+
+	err := filenames.ValidateTemplate(s.FilenameTemplate)
+	if err != nil {
+		return fmt.Errorf("\"%v\" is invalid: %w", SettingFilenameTemplate, err)
+	}
+
+	if s.BandwidthLimit < 0 {
+		return fmt.Errorf("\"%v\" must not be negative", SettingBandwidthLimit)
+	}
+
+	if s.DownloadBandwidthLimit < 0 {
+		return fmt.Errorf("\"%v\" must not be negative", SettingDownloadBandwidthLimit)
+	}
+
+	_, err = schedule.Parse(s.DownloadSchedule)
+	if err != nil {
+		return fmt.Errorf("\"%v\" is invalid: %w", SettingDownloadSchedule, err)
+	}
+
+	if s.TrashRetentionDays < 0 {
+		return fmt.Errorf("\"%v\" must not be negative", SettingTrashRetentionDays)
+	}
+
+	return nil
 }
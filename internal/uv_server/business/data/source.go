@@ -4,5 +4,6 @@ type Source string
 
 const (
 	Youtube Source = "yt"
+	Local   Source = "lc"
 	Unknown Source = "un"
 )
@@ -0,0 +1,5 @@
+package jobmessages
+
+type Request struct {
+	Id *int64 `json:"id"`
+}
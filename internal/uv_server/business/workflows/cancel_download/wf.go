@@ -0,0 +1,83 @@
+package canceldownload
+
+import (
+	"context"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/cancel_download/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CancelDownloadWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	registry data.DownloadRegistry
+}
+
+func NewCancelDownloadWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	registry data.DownloadRegistry,
+) *CancelDownloadWf {
+	object := &CancelDownloadWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "CancelDownloadWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.registry = registry
+
+	return object
+}
+
+// Run cancels the download and waits until the downloading workflow
+// removes the file row and schedules its temporary directory for cleaning
+func (w *CancelDownloadWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	log := w.log.WithField("id", *request.Id)
+
+	done, ok := w.registry.Cancel(*request.Id)
+	if !ok {
+		log.Error("file is not being downloaded")
+		w.jobIn <- &cjmessages.Error{Reason: "file is not being downloaded"}
+		return
+	}
+
+	select {
+	case <-done:
+		log.Debug("download is cancelled")
+		w.jobIn <- &cjmessages.Done{}
+	case <-w.jobCtx.Done():
+		log.Debugf("workflow cancelled: %v", w.jobCtx.Err())
+
+		switch w.jobCtx.Err() {
+		case context.DeadlineExceeded:
+			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+		case context.Canceled:
+			w.jobIn <- &cjmessages.Canceled{}
+		}
+	}
+}
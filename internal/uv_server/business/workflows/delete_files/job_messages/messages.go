@@ -2,8 +2,31 @@ package jobmessages
 
 type Request struct {
 	Ids []int64 `json:"ids"`
+	// Files are removed immediately instead of being moved to the trash
+	Permanent bool `json:"permanent"`
+	// Rows of files which are already missing on disk are removed
+	// from the database instead of failing
+	AllowMissing bool `json:"allowMissing"`
+}
+
+type Reason string
+
+const (
+	ReasonNotFound        Reason = "not_found"
+	ReasonCancelFailed    Reason = "cancel_failed"
+	ReasonNoPath          Reason = "no_path"
+	ReasonAlreadyTrashed  Reason = "already_trashed"
+	ReasonFileMissing     Reason = "file_missing"
+	ReasonFilesystemError Reason = "filesystem_error"
+	ReasonDatabaseError   Reason = "database_error"
+)
+
+type Failure struct {
+	Id     int64  `json:"id"`
+	Reason Reason `json:"reason"`
 }
 
 type Error struct {
-	FailedIds []int64 `json:"failedIds"`
+	FailedIds []int64   `json:"failedIds"`
+	Failures  []Failure `json:"failures"`
 }
@@ -2,10 +2,11 @@ package deletefile
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"os"
-	"path"
+	"path/filepath"
 	"sync"
+	"time"
 	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
 	"uv_server/internal/uv_server/business/data"
 	jobmessages "uv_server/internal/uv_server/business/workflows/delete_files/job_messages"
@@ -26,6 +27,7 @@ type DeleteFilesWf struct {
 
 	database   data.Database
 	filesystem data.Filesystem
+	registry   data.DownloadRegistry
 }
 
 func NewDeleteFilesWf(
@@ -36,6 +38,7 @@ func NewDeleteFilesWf(
 	job_out <-chan interface{},
 	database data.Database,
 	filesystem data.Filesystem,
+	registry data.DownloadRegistry,
 ) *DeleteFilesWf {
 	object := &DeleteFilesWf{}
 
@@ -54,6 +57,7 @@ func NewDeleteFilesWf(
 
 	object.database = database
 	object.filesystem = filesystem
+	object.registry = registry
 
 	return object
 }
@@ -61,78 +65,218 @@ func NewDeleteFilesWf(
 func (w *DeleteFilesWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 	defer wg.Done()
 
-	failedFiles := []int64{}
+	result := &jobmessages.Error{
+		FailedIds: []int64{},
+		Failures:  []jobmessages.Failure{},
+	}
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		w.log.Errorf("failed to get settings: %v", err)
+		w.jobIn <- &cjmessages.Error{Reason: "failed to get settings"}
+		return
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
 
 	for _, id := range request.Ids {
-		err := w.deleteFile(id)
-		if err != nil {
-			failedFiles = append(failedFiles, id)
+		// Files processed so far stay deleted, every file is either
+		// deleted completely or left untouched
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+
+			switch err {
+			case context.DeadlineExceeded:
+				w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+			case context.Canceled:
+				w.jobIn <- &cjmessages.Canceled{}
+			}
+			return
 		}
-	}
 
-	select {
-	case <-w.jobCtx.Done():
-		w.log.Debugf("workflow cancelled: %v", w.jobCtx.Err().Error())
+		err := w.deleteFile(storageDir, id, request)
 
-		switch w.jobCtx.Err() {
-		case context.DeadlineExceeded:
-			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
-		case context.Canceled:
-			w.jobIn <- &cjmessages.Canceled{}
+		var tErr *deleteError
+		if errors.As(err, &tErr) {
+			result.FailedIds = append(result.FailedIds, id)
+			result.Failures = append(result.Failures, jobmessages.Failure{
+				Id:     id,
+				Reason: tErr.reason,
+			})
+		} else if err != nil {
+			w.log.Fatalf("unexpected error: %v", err)
 		}
-	default:
 	}
 
-	if len(failedFiles) != 0 {
-		w.jobIn <- &jobmessages.Error{FailedIds: failedFiles}
+	if len(result.FailedIds) != 0 {
+		w.jobIn <- result
 		return
 	}
 
 	w.jobIn <- &cjmessages.Done{}
 }
 
-func (w *DeleteFilesWf) deleteFile(id int64) error {
+type deleteError struct {
+	reason jobmessages.Reason
+}
+
+func (e *deleteError) Error() string {
+	return string(e.reason)
+}
+
+func (w *DeleteFilesWf) deleteFile(
+	storageDir string,
+	id int64,
+	request *jobmessages.Request,
+) error {
 	log := w.log.WithField("id", id)
 
 	file, err := w.database.GetFile(id)
-	if err != nil {
+	if errors.Is(err, data.NotFound) {
+		log.Error("file is not found")
+		return &deleteError{jobmessages.ReasonNotFound}
+	} else if err != nil {
 		log.Errorf("failed to get file, error is: %v", err)
-		return errors.New("failed to get file from database")
+		return &deleteError{jobmessages.ReasonDatabaseError}
 	}
 
 	if file.Status != data.FsFinished {
-		err := errors.New("file is not downloaded yet")
-		log.Error(err)
-		return err
+		return w.cancelDownload(file)
 	}
 
 	if !file.Path.Valid {
-		err := errors.New("file does not have a path")
-		log.Error(err)
-		return err
+		log.Error("file does not have a path")
+		return &deleteError{jobmessages.ReasonNoPath}
 	}
 
-	//  ToDo: integrate with settings
-	wd, err := os.Getwd()
+	if file.IsTrashed() && !request.Permanent {
+		log.Error("file is already in trash")
+		return &deleteError{jobmessages.ReasonAlreadyTrashed}
+	}
+
+	if file.IsExternal() {
+		return w.deleteExternalFile(file, request)
+	}
+
+	src := data.LocateFile(storageDir, file)
+
+	exists, err := w.filesystem.Exists(src)
 	if err != nil {
-		w.log.Fatal(err)
+		log.Errorf("failed to check file, error is: %v", err)
+		return &deleteError{jobmessages.ReasonFilesystemError}
 	}
 
-	storageDir := path.Join(wd, "storage")
-	path := path.Join(storageDir, file.Path.String)
-	//
+	if !exists {
+		if !request.AllowMissing {
+			log.Errorf("file is missing on disk: %v", src)
+			return &deleteError{jobmessages.ReasonFileMissing}
+		}
+
+		log.Warnf("file is missing on disk, deleting database entry: %v", src)
+
+		err = w.database.DeleteFile(&data.File{Id: id})
+		if err != nil {
+			log.Errorf("failed to delete file from database, error is: %v", err)
+			return &deleteError{jobmessages.ReasonDatabaseError}
+		}
+
+		return nil
+	}
+
+	// The file is staged in the trash first, so the database change
+	// can be compensated by moving it back
+	dst := data.ResolveFilePath(storageDir, data.TrashPath(file))
+
+	if src != dst {
+		err = w.filesystem.MoveFile(src, dst)
+		if err != nil {
+			log.Errorf("failed to move file to trash, error is: %v", err)
+			return &deleteError{jobmessages.ReasonFilesystemError}
+		}
+	}
+
+	if request.Permanent {
+		err = w.database.DeleteFile(&data.File{Id: id})
+	} else {
+		file.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		err = w.database.UpdateFileDeletedAt(file)
+	}
 
-	err = w.filesystem.DeleteFile(path)
 	if err != nil {
-		log.Errorf("failed to delete file from filesystem, error is: %v", err)
-		return errors.New("failed to delete file from filesystem")
+		log.Errorf("failed to update database, error is: %v", err)
+
+		if src != dst {
+			err = w.filesystem.MoveFile(dst, src)
+			if err != nil {
+				log.Errorf("failed to move file back from trash: %v", err)
+			}
+		}
+
+		return &deleteError{jobmessages.ReasonDatabaseError}
+	}
+
+	if request.Permanent {
+		// The row is gone, a leftover trash entry is only a disk leak
+		err = w.filesystem.DeleteDirectory(filepath.Dir(dst))
+		if err != nil {
+			log.Errorf("failed to delete file from trash: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteExternalFile drops the row of a file imported in place, the file
+// itself belongs to the user and stays where it is
+func (w *DeleteFilesWf) deleteExternalFile(
+	file *data.File,
+	request *jobmessages.Request,
+) error {
+	log := w.log.WithField("id", file.Id)
+
+	var err error
+	if request.Permanent {
+		err = w.database.DeleteFile(&data.File{Id: file.Id})
+	} else {
+		file.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		err = w.database.UpdateFileDeletedAt(file)
 	}
 
-	err = w.database.DeleteFile(&data.File{Id: id})
 	if err != nil {
-		log.Errorf("failed to delete file from database, error is: %v", err)
-		return errors.New("failed to delete file from database")
+		log.Errorf("failed to update database, error is: %v", err)
+		return &deleteError{jobmessages.ReasonDatabaseError}
 	}
 
+	log.Infof("file imported in place is left on disk: %v", file.Path.String)
+
 	return nil
 }
+
+// cancelDownload stops an active download, the downloading workflow
+// removes the row and the temporary directory itself
+func (w *DeleteFilesWf) cancelDownload(file *data.File) error {
+	log := w.log.WithField("id", file.Id)
+
+	done, ok := w.registry.Cancel(file.Id)
+	if !ok {
+		// Leftover of a download which did not finish properly
+		log.Warn("file is not being downloaded, deleting database entry")
+
+		err := w.database.DeleteFile(&data.File{Id: file.Id})
+		if err != nil {
+			log.Errorf("failed to delete file from database, error is: %v", err)
+			return &deleteError{jobmessages.ReasonDatabaseError}
+		}
+
+		return nil
+	}
+
+	select {
+	case <-done:
+		log.Debug("download is cancelled")
+		return nil
+	case <-w.jobCtx.Done():
+		log.Error("download is not cancelled in time")
+		return &deleteError{jobmessages.ReasonCancelFailed}
+	}
+}
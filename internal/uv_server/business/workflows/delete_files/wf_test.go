@@ -0,0 +1,239 @@
+package deletefile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/delete_files/job_messages"
+	"uv_server/internal/uv_server/config"
+)
+
+const storageDir = "/storage"
+
+func newDeleteFilesWf(
+	ctx context.Context,
+	jobIn chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+	registry data.DownloadRegistry,
+) *DeleteFilesWf {
+	wf := &DeleteFilesWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.config = &config.Config{HomeDir: "/"}
+	wf.jobCtx = ctx
+	wf.jobIn = jobIn
+	wf.database = database
+	wf.filesystem = filesystem
+	wf.registry = registry
+
+	return wf
+}
+
+func finishedFile(id int64) *data.File {
+	return &data.File{
+		Id:     id,
+		Path:   sql.NullString{String: "a.mp3", Valid: true},
+		Status: data.FsFinished,
+	}
+}
+
+type testDeleteFile_TableEntry struct {
+	name    string
+	request *jobmessages.Request
+	// Whether the file is being downloaded
+	active  bool
+	file    *data.File
+	getErr  error
+	exists  bool
+	moveErr error
+	dbErr   error
+	reason  jobmessages.Reason
+	// Whether the file has to be moved back from the trash
+	reverted bool
+}
+
+func TestDeleteFile(t *testing.T) {
+	trashed := finishedFile(1)
+	trashed.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	downloading := finishedFile(1)
+	downloading.Status = data.FsDownloading
+
+	testData := []testDeleteFile_TableEntry{
+		{
+			name:    "moved to trash",
+			request: &jobmessages.Request{},
+			file:    finishedFile(1),
+			exists:  true,
+		},
+		{
+			name:    "deleted permanently",
+			request: &jobmessages.Request{Permanent: true},
+			file:    finishedFile(1),
+			exists:  true,
+		},
+		{
+			name:    "not found",
+			request: &jobmessages.Request{},
+			getErr:  data.NotFound,
+			reason:  jobmessages.ReasonNotFound,
+		},
+		{
+			name:    "download cancelled",
+			request: &jobmessages.Request{},
+			file:    downloading,
+			active:  true,
+		},
+		{
+			name:    "download leftover",
+			request: &jobmessages.Request{},
+			file:    downloading,
+		},
+		{
+			name:    "already trashed",
+			request: &jobmessages.Request{},
+			file:    trashed,
+			reason:  jobmessages.ReasonAlreadyTrashed,
+		},
+		{
+			name:    "missing on disk",
+			request: &jobmessages.Request{},
+			file:    finishedFile(1),
+			reason:  jobmessages.ReasonFileMissing,
+		},
+		{
+			name:    "missing on disk allowed",
+			request: &jobmessages.Request{AllowMissing: true},
+			file:    finishedFile(1),
+		},
+		{
+			name:    "move failed",
+			request: &jobmessages.Request{},
+			file:    finishedFile(1),
+			exists:  true,
+			moveErr: errors.New("move failed"),
+			reason:  jobmessages.ReasonFilesystemError,
+		},
+		{
+			name:     "database failed",
+			request:  &jobmessages.Request{},
+			file:     finishedFile(1),
+			exists:   true,
+			dbErr:    errors.New("db failed"),
+			reason:   jobmessages.ReasonDatabaseError,
+			reverted: true,
+		},
+	}
+
+	src := filepath.Join(storageDir, "a.mp3")
+	dst := filepath.Join(storageDir, ".trash", "1", "a.mp3")
+
+	for _, entry := range testData {
+		t.Run(entry.name, func(t *testing.T) {
+			dbMock := dmocks.NewMockDatabase(t)
+			fsMock := dmocks.NewMockFilesystem(t)
+			registryMock := dmocks.NewMockDownloadRegistry(t)
+
+			done := make(chan struct{})
+			close(done)
+			registryMock.On("Cancel", int64(1)).
+				Return((<-chan struct{})(done), entry.active).Maybe()
+
+			dbMock.On("GetFile", int64(1)).Return(entry.file, entry.getErr)
+			fsMock.On("Exists", src).Return(entry.exists, nil).Maybe()
+			fsMock.On("MoveFile", src, dst).Return(entry.moveErr).Maybe()
+			fsMock.On("DeleteDirectory", filepath.Dir(dst)).Return(nil).Maybe()
+			dbMock.On("DeleteFile", mock.Anything).Return(entry.dbErr).Maybe()
+			dbMock.On("UpdateFileDeletedAt", mock.Anything).Return(entry.dbErr).Maybe()
+
+			if entry.reverted {
+				fsMock.On("MoveFile", dst, src).Return(nil).Once()
+			}
+
+			wf := newDeleteFilesWf(
+				context.Background(), nil, dbMock, fsMock, registryMock)
+
+			err := wf.deleteFile(storageDir, 1, entry.request)
+
+			if entry.reason == "" {
+				assert.NoError(t, err)
+			} else {
+				var tErr *deleteError
+				assert.ErrorAs(t, err, &tErr)
+				assert.Equal(t, entry.reason, tErr.reason)
+			}
+
+			if entry.reverted {
+				fsMock.AssertCalled(t, "MoveFile", dst, src)
+			}
+
+			if entry.active {
+				dbMock.AssertNotCalled(t, "DeleteFile", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRun_CancelledBetweenFiles(t *testing.T) {
+	dbMock := dmocks.NewMockDatabase(t)
+	fsMock := dmocks.NewMockFilesystem(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobIn := make(chan interface{}, 1)
+
+	settings := data.DefaultSettings()
+	settings.StorageDir = storageDir
+
+	dbMock.On("GetSettings").Return(settings, nil)
+	dbMock.On("GetFile", int64(1)).Return(finishedFile(1), nil)
+	fsMock.On("Exists", mock.Anything).Return(true, nil)
+	fsMock.On("MoveFile", mock.Anything, mock.Anything).Return(nil)
+	dbMock.On("UpdateFileDeletedAt", mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { cancel() })
+
+	wf := newDeleteFilesWf(ctx, jobIn, dbMock, fsMock, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	wf.Run(&wg, &jobmessages.Request{Ids: []int64{1, 2}})
+
+	assert.Equal(t, &cjmessages.Canceled{}, <-jobIn)
+	dbMock.AssertNotCalled(t, "GetFile", int64(2))
+}
+
+func TestDeleteFile_ImportedInPlace(t *testing.T) {
+	for _, permanent := range []bool{false, true} {
+		dbMock := dmocks.NewMockDatabase(t)
+		// No filesystem calls are expected, the file belongs to the user
+		fsMock := dmocks.NewMockFilesystem(t)
+
+		file := finishedFile(1)
+		file.Path.String = "/music/a.mp3"
+
+		dbMock.On("GetFile", int64(1)).Return(file, nil)
+		if permanent {
+			dbMock.On("DeleteFile", &data.File{Id: 1}).Return(nil)
+		} else {
+			dbMock.On("UpdateFileDeletedAt", mock.MatchedBy(func(f *data.File) bool {
+				return f.Id == 1 && f.IsTrashed()
+			})).Return(nil)
+		}
+
+		wf := newDeleteFilesWf(context.Background(), nil, dbMock, fsMock, nil)
+
+		err := wf.deleteFile(storageDir, 1, &jobmessages.Request{Permanent: permanent})
+		assert.NoError(t, err)
+	}
+}
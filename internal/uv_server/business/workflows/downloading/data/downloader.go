@@ -2,6 +2,15 @@ package data
 
 import "sync"
 
+type Options struct {
+	StorageDir       string
+	Format           string
+	FilenameTemplate string
+	Source           string
+	// Bytes per second, 0 means unlimited
+	RateLimit int64
+}
+
 type Downloader interface {
-	Download(wg *sync.WaitGroup, url string, storageDir string)
+	Download(wg *sync.WaitGroup, url string, options *Options)
 }
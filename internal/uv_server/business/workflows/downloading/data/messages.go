@@ -4,10 +4,40 @@ type Error struct {
 	Reason string
 }
 
+type Phase string
+
+const (
+	PhaseFetchingMetadata Phase = "fetching_metadata"
+	PhaseDownloading      Phase = "downloading"
+	PhasePostProcessing   Phase = "post_processing"
+	// The file is moved into the storage and its checksum is computed
+	PhaseFinalizing Phase = "finalizing"
+)
+
+// Progress values which are not known are 0
 type Progress struct {
+	Phase      Phase
 	Percentage float64
+
+	DownloadedBytes int64
+	TotalBytes      int64
+	// Bytes per second
+	Speed float64
+	// Seconds left
+	Eta float64
 }
 
 type Done struct {
+	// Path relative to the storage directory
 	Filename string
+	Size     int64
+	// Hex encoded SHA-256 of the stored file
+	Checksum string
+
+	Title      string
+	Uploader   string
+	VideoId    string
+	UploadDate string
+	// Seconds, 0 when unknown
+	Duration float64
 }
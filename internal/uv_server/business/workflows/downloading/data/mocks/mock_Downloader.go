@@ -1,11 +1,13 @@
-// Code generated by mockery v2.53.2. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
-	sync "sync"
+	data "uv_server/internal/uv_server/business/workflows/downloading/data"
 
 	mock "github.com/stretchr/testify/mock"
+
+	sync "sync"
 )
 
 // MockDownloader is an autogenerated mock type for the Downloader type
@@ -21,9 +23,9 @@ func (_m *MockDownloader) EXPECT() *MockDownloader_Expecter {
 	return &MockDownloader_Expecter{mock: &_m.Mock}
 }
 
-// Download provides a mock function with given fields: wg, url, storageDir
-func (_m *MockDownloader) Download(wg *sync.WaitGroup, url string, storageDir string) {
-	_m.Called(wg, url, storageDir)
+// Download provides a mock function with given fields: wg, url, options
+func (_m *MockDownloader) Download(wg *sync.WaitGroup, url string, options *data.Options) {
+	_m.Called(wg, url, options)
 }
 
 // MockDownloader_Download_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Download'
@@ -34,14 +36,14 @@ type MockDownloader_Download_Call struct {
 // Download is a helper method to define mock.On call
 //   - wg *sync.WaitGroup
 //   - url string
-//   - storageDir string
-func (_e *MockDownloader_Expecter) Download(wg interface{}, url interface{}, storageDir interface{}) *MockDownloader_Download_Call {
-	return &MockDownloader_Download_Call{Call: _e.mock.On("Download", wg, url, storageDir)}
+//   - options *data.Options
+func (_e *MockDownloader_Expecter) Download(wg interface{}, url interface{}, options interface{}) *MockDownloader_Download_Call {
+	return &MockDownloader_Download_Call{Call: _e.mock.On("Download", wg, url, options)}
 }
 
-func (_c *MockDownloader_Download_Call) Run(run func(wg *sync.WaitGroup, url string, storageDir string)) *MockDownloader_Download_Call {
+func (_c *MockDownloader_Download_Call) Run(run func(wg *sync.WaitGroup, url string, options *data.Options)) *MockDownloader_Download_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*sync.WaitGroup), args[1].(string), args[2].(string))
+		run(args[0].(*sync.WaitGroup), args[1].(string), args[2].(*data.Options))
 	})
 	return _c
 }
@@ -51,7 +53,7 @@ func (_c *MockDownloader_Download_Call) Return() *MockDownloader_Download_Call {
 	return _c
 }
 
-func (_c *MockDownloader_Download_Call) RunAndReturn(run func(*sync.WaitGroup, string, string)) *MockDownloader_Download_Call {
+func (_c *MockDownloader_Download_Call) RunAndReturn(run func(*sync.WaitGroup, string, *data.Options)) *MockDownloader_Download_Call {
 	_c.Run(run)
 	return _c
 }
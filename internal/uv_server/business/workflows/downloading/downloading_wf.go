@@ -12,7 +12,9 @@ import (
 	"uv_server/internal/uv_server/business/data"
 	wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
 	jobmessages "uv_server/internal/uv_server/business/workflows/downloading/job_messages"
+	"uv_server/internal/uv_server/common"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 	"uv_server/internal/uv_server/config"
 
 	"github.com/sirupsen/logrus"
@@ -30,9 +32,22 @@ type DownloadingWf struct {
 	downloaderOut <-chan interface{}
 	downloader    wfData.Downloader
 
-	database data.Database
+	database   data.Database
+	filesystem data.Filesystem
+	registry   data.DownloadRegistry
+	queue      data.DownloadQueue
+	// Cancels the workflow context, used to stop the download
+	// on behalf of other jobs
+	cancel context.CancelFunc
+	// Limits the download itself, the wait in the queue is not counted
+	timeout time.Duration
 
 	fileId int64
+	// Resolved once the download starts
+	storageDir string
+
+	// Minimal time between progress messages of the same phase
+	progressInterval time.Duration
 
 	startDownloading func(
 		downloaderWg *sync.WaitGroup,
@@ -42,7 +57,7 @@ type DownloadingWf struct {
 	startDownloadingFromYoutube func(
 		downloaderWg *sync.WaitGroup,
 		url string,
-		storageDir string,
+		options *wfData.Options,
 	) error
 }
 
@@ -55,6 +70,11 @@ func NewDownloadingWf(
 	downloader wfData.Downloader,
 	downloaderOut <-chan interface{},
 	database data.Database,
+	filesystem data.Filesystem,
+	registry data.DownloadRegistry,
+	queue data.DownloadQueue,
+	cancel context.CancelFunc,
+	timeout time.Duration,
 ) *DownloadingWf {
 	object := &DownloadingWf{}
 
@@ -65,6 +85,7 @@ func NewDownloadingWf(
 			"uuid":      uuid},
 	)
 	object.config = config
+	object.progressInterval = time.Duration(config.ProgressIntervalMs) * time.Millisecond
 
 	object.jobCtx = jobCtx
 
@@ -75,6 +96,11 @@ func NewDownloadingWf(
 	object.downloaderOut = downloaderOut
 
 	object.database = database
+	object.filesystem = filesystem
+	object.registry = registry
+	object.queue = queue
+	object.cancel = cancel
+	object.timeout = timeout
 
 	object.injectInternalDependencies()
 
@@ -92,9 +118,9 @@ func (w *DownloadingWf) injectInternalDependencies() {
 	w.startDownloadingFromYoutube = func(
 		downloaderWg *sync.WaitGroup,
 		url string,
-		storageDir string,
+		options *wfData.Options,
 	) error {
-		return startDownloadingFromYoutube(w, downloaderWg, url, storageDir)
+		return startDownloadingFromYoutube(w, downloaderWg, url, options)
 	}
 }
 
@@ -113,6 +139,19 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 		return
 	}
 
+	release, ok := w.waitForSlot()
+	if !ok {
+		return
+	}
+	defer release()
+
+	// The downloader shares the job context, so it is canceled
+	// once the deadline passes
+	ctx, cancel := context.WithTimeout(w.jobCtx, w.timeout)
+	defer cancel()
+	defer context.AfterFunc(ctx, w.cancel)()
+	w.jobCtx = ctx
+
 	var downloaderWg sync.WaitGroup
 	err := w.startDownloading(&downloaderWg, url)
 	if err != nil {
@@ -121,8 +160,12 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 		return
 	}
 
-	w.jobIn <- &jobmessages.Progress{Id: w.fileId, Percentage: 0}
-	lastProgressTs := time.Now()
+	// Unregistering happens after the file row is finalized or removed
+	defer w.registry.Unregister(w.fileId)
+
+	progress := newProgressThrottle(w.fileId, w.progressInterval)
+	w.jobIn <- progress.first()
+	startedAt := time.Now()
 
 	for {
 		select {
@@ -130,6 +173,11 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 			w.log.Debugf("workflow cancelled: %v", w.jobCtx.Err().Error())
 			downloaderWg.Wait()
 
+			if errors.Is(context.Cause(w.jobCtx), common.ErrShutdown) {
+				w.interrupt()
+				return
+			}
+
 			err := w.database.DeleteFile(&data.File{Id: w.fileId})
 			if err != nil {
 				w.log.Fatalf(
@@ -147,10 +195,8 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 			return
 		case msg := <-w.downloaderOut:
 			if tMsg, ok := msg.(*wfData.Progress); ok {
-				now := time.Now()
-				if now.Sub(lastProgressTs).Seconds() > 0 {
-					w.jobIn <- &jobmessages.Progress{Id: w.fileId, Percentage: tMsg.Percentage}
-					lastProgressTs = now
+				if msg := progress.next(tMsg, time.Now()); msg != nil {
+					w.jobIn <- msg
 				}
 			} else if tMsg, ok := msg.(*wfData.Error); ok {
 				downloaderWg.Wait()
@@ -165,14 +211,35 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 				return
 			} else if tMsg, ok := msg.(*wfData.Done); ok {
 				file := &data.File{
-					Id:     w.fileId,
-					Path:   sql.NullString{String: tMsg.Filename, Valid: true},
-					Status: data.FsFinished,
+					Id:       w.fileId,
+					Path:     sql.NullString{String: tMsg.Filename, Valid: true},
+					Status:   data.FsFinished,
+					Size:     sql.NullInt64{Int64: tMsg.Size, Valid: true},
+					Checksum: sql.NullString{String: tMsg.Checksum, Valid: true},
+					Title:    sql.NullString{String: tMsg.Title, Valid: tMsg.Title != ""},
+					Artist:   sql.NullString{String: tMsg.Uploader, Valid: tMsg.Uploader != ""},
+					Duration: sql.NullFloat64{Float64: tMsg.Duration, Valid: tMsg.Duration > 0},
 				}
 
 				err := w.database.UpdateFilePath(file)
 				if err != nil {
-					w.log.Fatalf("failed to update path for file with id %v", w.fileId)
+					w.log.Errorf(
+						"failed to update path for file with id %v, error is %v",
+						w.fileId, err)
+					downloaderWg.Wait()
+					w.discardFile(tMsg.Filename)
+					w.jobIn <- &cjmessages.Error{Reason: "failed to store downloaded file"}
+					return
+				}
+
+				err = w.database.UpdateFileDigest(file)
+				if err != nil {
+					w.log.Fatalf("failed to update digest for file with id %v", w.fileId)
+				}
+
+				err = w.database.UpdateFileMetadata(file)
+				if err != nil {
+					w.log.Fatalf("failed to update metadata for file with id %v", w.fileId)
 				}
 
 				err = w.database.UpdateFileStatus(file)
@@ -181,7 +248,13 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 				}
 
 				downloaderWg.Wait()
-				w.jobIn <- &jobmessages.Progress{Id: w.fileId, Percentage: 100}
+
+				metrics.DownloadedBytes.Add(float64(tMsg.Size))
+				if took := time.Since(startedAt).Seconds(); took > 0 {
+					metrics.DownloadThroughput.Observe(float64(tMsg.Size) / took)
+				}
+
+				w.jobIn <- progress.last()
 				w.jobIn <- &jobmessages.Done{Id: w.fileId}
 				return
 			} else {
@@ -193,6 +266,50 @@ func (w *DownloadingWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 	}
 }
 
+// waitForSlot holds the download back while too many downloads run
+// or the download schedule does not allow starting it
+func (w *DownloadingWf) waitForSlot() (func(), bool) {
+	release, ok := w.queue.TryAcquire()
+	if ok {
+		return release, true
+	}
+
+	w.log.Debugf("download is queued")
+	w.jobIn <- &jobmessages.Queued{}
+
+	release, err := w.queue.Acquire(w.jobCtx)
+	if err == nil {
+		return release, true
+	}
+
+	w.log.Debugf("queued download cancelled: %v", err)
+
+	if errors.Is(context.Cause(w.jobCtx), common.ErrShutdown) {
+		w.jobIn <- &cjmessages.Error{Reason: common.ErrShutdown.Error()}
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+	} else {
+		w.jobIn <- &cjmessages.Canceled{}
+	}
+
+	return nil, false
+}
+
+// interrupt keeps the file row of a download stopped by a shutdown,
+// so the download can be resumed later
+func (w *DownloadingWf) interrupt() {
+	err := w.database.UpdateFileStatus(&data.File{
+		Id:     w.fileId,
+		Status: data.FsInterrupted,
+	})
+	if err != nil {
+		w.log.Fatalf("failed to update status for file with id %v", w.fileId)
+	}
+
+	w.log.Infof("download of file with id %v is interrupted", w.fileId)
+	w.jobIn <- &cjmessages.Error{Reason: common.ErrShutdown.Error()}
+}
+
 func isYoutube(url string) bool {
 	return youtubeRegex.MatchString(url)
 }
@@ -237,38 +354,110 @@ func startDownloading(
 		w.log.Fatalf("failed to get file by url")
 	}
 
-	if file != nil {
-		return fmt.Errorf("file already exists")
-	}
 	settings, err := w.database.GetSettings()
 	if err != nil {
-		w.log.Fatalf("failed to get storage dir: %v", err)
+		w.log.Fatalf("failed to get settings: %v", err)
 	}
 
-	storageDir := settings.StorageDir
+	w.storageDir = w.config.ResolvePath(settings.StorageDir)
 
-	if source == data.Youtube {
-		err := w.startDownloadingFromYoutube(downloaderWg, url, storageDir)
+	if file != nil && file.IsTrashed() {
+		// Urls are unique, the trashed file gives way to the new one
+		w.log.Infof("purging trashed file with id %v", file.Id)
+
+		err = data.PurgeFile(w.database, w.filesystem, w.storageDir, file)
 		if err != nil {
-			return err
+			w.log.Errorf("failed to purge trashed file: %v", err)
+			return fmt.Errorf("failed to purge trashed file")
 		}
-	} else {
+
+		file = nil
+	}
+
+	if file != nil && file.Status != data.FsInterrupted {
+		return fmt.Errorf("file already exists")
+	}
+
+	options := &wfData.Options{
+		StorageDir:       settings.StorageDir,
+		Format:           settings.DefaultFormat,
+		FilenameTemplate: settings.FilenameTemplate,
+		Source:           string(source),
+		RateLimit:        settings.DownloadRate(),
+	}
+
+	if source != data.Youtube {
 		w.log.Fatalf("downloading for %v is not implemented", source)
 	}
 
-	w.fileId, err = w.database.InsertFile(&data.File{
-		SourceUrl: url,
-		Source:    source,
-		Status:    data.FsDownloading,
-	})
+	// The row is written and registered at once, otherwise deleting it
+	// would take the live download for a leftover
+	w.fileId, err = w.registry.Register(func() (int64, error) {
+		if file != nil {
+			// Interrupted downloads start over keeping the file id
+			return file.Id, w.database.ResumeFile(file.Id)
+		}
 
+		return w.database.InsertFile(&data.File{
+			SourceUrl: url,
+			Source:    source,
+			Status:    data.FsDownloading,
+		})
+	}, w.cancel)
+
+	if errors.Is(err, data.AlreadyExists) {
+		return fmt.Errorf("file already exists")
+	} else if errors.Is(err, data.NotFound) || errors.Is(err, data.AlreadyDownloading) {
+		return fmt.Errorf("file is already being downloaded")
+	} else if err != nil {
+		w.log.Errorf("failed to add file to database: %v", err)
+		return fmt.Errorf("failed to add file to database")
+	}
+
+	err = w.startDownloadingFromYoutube(downloaderWg, url, options)
 	if err != nil {
-		w.log.Fatal(err)
+		w.registry.Unregister(w.fileId)
+		w.dropFile(file != nil)
+		return err
 	}
 
 	return nil
 }
 
+// discardFile removes the stored file of a download which could not be
+// recorded and the row of the download
+func (w *DownloadingWf) discardFile(relPath string) {
+	err := w.filesystem.DeleteFile(data.ResolveFilePath(w.storageDir, relPath))
+	if err != nil {
+		w.log.Errorf("failed to delete downloaded file %v: %v", relPath, err)
+	}
+
+	err = w.database.DeleteFile(&data.File{Id: w.fileId})
+	if err != nil {
+		w.log.Fatalf(
+			"failed to delete file with id %v, error is %v",
+			w.fileId, err)
+	}
+}
+
+// dropFile undoes the row written for a download which did not start,
+// interrupted downloads keep their row
+func (w *DownloadingWf) dropFile(interrupted bool) {
+	var err error
+	if interrupted {
+		err = w.database.UpdateFileStatus(&data.File{
+			Id:     w.fileId,
+			Status: data.FsInterrupted,
+		})
+	} else {
+		err = w.database.DeleteFile(&data.File{Id: w.fileId})
+	}
+
+	if err != nil {
+		w.log.Fatalf("failed to drop file with id %v, error is %v", w.fileId, err)
+	}
+}
+
 func (w *DownloadingWf) normalizeUrl(
 	url string,
 	source data.Source,
@@ -286,14 +475,14 @@ func startDownloadingFromYoutube(
 	w *DownloadingWf,
 	downloaderWg *sync.WaitGroup,
 	url string,
-	storageDir string,
+	options *wfData.Options,
 ) error {
 	log := w.log.WithField("source", "youtube")
 
 	log.Debugf("starting downloading")
 
 	downloaderWg.Add(1)
-	go w.downloader.Download(downloaderWg, url, storageDir)
+	go w.downloader.Download(downloaderWg, url, options)
 
 	return nil
 }
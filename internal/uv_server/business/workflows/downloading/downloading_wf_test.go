@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -17,6 +18,8 @@ import (
 	wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
 	bdmocks "uv_server/internal/uv_server/business/workflows/downloading/data/mocks"
 	jobmessages "uv_server/internal/uv_server/business/workflows/downloading/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/config"
 
 	"uv_server/internal/uv_server/business/data"
 )
@@ -27,10 +30,30 @@ type testGetSourceFromUrl_TableEntry struct {
 	err    error
 }
 
+// claimRow stands for the registry writing the row of the download
+func claimRow(claim func() (int64, error), cancel context.CancelFunc) (int64, error) {
+	return claim()
+}
+
 func newDownloadingWf() *DownloadingWf {
 	wf := &DownloadingWf{}
 	wf.log = logrus.New().WithField("layer", "Business")
 
+	registry := &dmocks.MockDownloadRegistry{}
+	registry.On("Register", mock.Anything, mock.Anything).Return(claimRow)
+	registry.On("Unregister", mock.Anything).Return()
+	wf.registry = registry
+
+	queue := &dmocks.MockDownloadQueue{}
+	queue.On("TryAcquire").Return(func() {}, true)
+	wf.queue = queue
+
+	wf.config = &config.Config{HomeDir: "/home"}
+	wf.jobCtx = context.Background()
+	wf.cancel = func() {}
+	wf.timeout = time.Minute
+	wf.progressInterval = time.Second
+
 	wf.injectInternalDependencies()
 
 	return wf
@@ -131,9 +154,9 @@ func TestStartDownloading_AlreadyDownloaded(t *testing.T) {
 	wf.startDownloadingFromYoutube = func(
 		downloaderWg *sync.WaitGroup,
 		url string,
-		storageDir string,
+		options *wfData.Options,
 	) error {
-		return downloaderMock.do(downloaderWg, url, storageDir)
+		return downloaderMock.do(downloaderWg, url, options)
 	}
 	wf.database = dbMock
 
@@ -141,6 +164,7 @@ func TestStartDownloading_AlreadyDownloaded(t *testing.T) {
 	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
 
 	dbMock.On("GetFileByUrl", url).Return(&data.File{}, nil)
+	dbMock.On("GetSettings").Return(data.DefaultSettings(), nil)
 
 	err := wf.startDownloading(&downloaderWg, url)
 	assert.NotNil(t, err, "operation should have failed")
@@ -148,6 +172,74 @@ func TestStartDownloading_AlreadyDownloaded(t *testing.T) {
 	dbMock.AssertExpectations(t)
 }
 
+func TestStartDownloading_TrashedIsPurged(t *testing.T) {
+	downloaderMock := new(StartDownloadingFromYoutubeMock)
+	dbMock := dmocks.NewMockDatabase(t)
+	fsMock := dmocks.NewMockFilesystem(t)
+
+	wf := newDownloadingWf()
+	wf.startDownloadingFromYoutube = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+		options *wfData.Options,
+	) error {
+		return downloaderMock.do(downloaderWg, url, options)
+	}
+	wf.database = dbMock
+	wf.filesystem = fsMock
+
+	var downloaderWg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+
+	trashed := &data.File{
+		Id:        1,
+		Path:      sql.NullString{String: "song.mp3", Valid: true},
+		Status:    data.FsFinished,
+		DeletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	dbMock.On("GetFileByUrl", url).Return(trashed, nil)
+	// Relative storage directories are resolved against the home directory
+	settings := data.DefaultSettings()
+	settings.StorageDir = "storage"
+	dbMock.On("GetSettings").Return(settings, nil)
+
+	fsMock.EXPECT().DeleteDirectory(
+		filepath.Dir(data.LocateFile("/home/storage", trashed))).Return(nil)
+	dbMock.EXPECT().DeleteFile(&data.File{Id: trashed.Id}).Return(nil)
+
+	downloaderMock.On("do", &downloaderWg, url, mock.Anything).Return(nil)
+	dbMock.On("InsertFile", mock.Anything).Return(int64(2), nil)
+
+	err := wf.startDownloading(&downloaderWg, url)
+	assert.Nil(t, err, "operation should not have failed")
+
+	assert.Equal(t, int64(2), wf.fileId)
+
+	dbMock.AssertExpectations(t)
+	downloaderMock.AssertExpectations(t)
+}
+
+func TestStartDownloading_ResumeTakenByAnother(t *testing.T) {
+	dbMock := dmocks.NewMockDatabase(t)
+
+	wf := newDownloadingWf()
+	wf.database = dbMock
+
+	var downloaderWg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+
+	interrupted := &data.File{Id: 1, Status: data.FsInterrupted}
+	dbMock.On("GetFileByUrl", url).Return(interrupted, nil)
+	dbMock.On("GetSettings").Return(data.DefaultSettings(), nil)
+	// A concurrent resume has claimed the file first
+	dbMock.On("ResumeFile", interrupted.Id).Return(data.NotFound)
+
+	err := wf.startDownloading(&downloaderWg, url)
+	assert.EqualError(t, err, "file is already being downloaded")
+
+	dbMock.AssertExpectations(t)
+}
+
 func TestStartDownloading_HappyPass(t *testing.T) {
 	downloaderMock := new(StartDownloadingFromYoutubeMock)
 	dbMock := dmocks.NewMockDatabase(t)
@@ -156,9 +248,9 @@ func TestStartDownloading_HappyPass(t *testing.T) {
 	wf.startDownloadingFromYoutube = func(
 		downloaderWg *sync.WaitGroup,
 		url string,
-		storageDir string,
+		options *wfData.Options,
 	) error {
-		return downloaderMock.do(downloaderWg, url, storageDir)
+		return downloaderMock.do(downloaderWg, url, options)
 	}
 	wf.database = dbMock
 
@@ -166,9 +258,15 @@ func TestStartDownloading_HappyPass(t *testing.T) {
 	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
 
 	dbMock.On("GetFileByUrl", url).Return(nil, nil)
-	storage := &data.Settings{StorageDir: "./storage"}
-	dbMock.On("GetSettings").Return(storage, nil)
-	downloaderMock.On("do", &downloaderWg, url, storage.StorageDir).Return(nil)
+	settings := data.DefaultSettings()
+	dbMock.On("GetSettings").Return(settings, nil)
+	options := &wfData.Options{
+		StorageDir:       settings.StorageDir,
+		Format:           settings.DefaultFormat,
+		FilenameTemplate: settings.FilenameTemplate,
+		Source:           string(data.Youtube),
+	}
+	downloaderMock.On("do", &downloaderWg, url, options).Return(nil)
 
 	fileId := int64(1)
 
@@ -191,6 +289,52 @@ func TestStartDownloading_HappyPass(t *testing.T) {
 	downloaderMock.AssertExpectations(t)
 }
 
+func TestStartDownloading_ClaimedWhileRegistering(t *testing.T) {
+	downloaderMock := new(StartDownloadingFromYoutubeMock)
+	dbMock := dmocks.NewMockDatabase(t)
+	registryMock := dmocks.NewMockDownloadRegistry(t)
+
+	wf := newDownloadingWf()
+	wf.startDownloadingFromYoutube = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+		options *wfData.Options,
+	) error {
+		return downloaderMock.do(downloaderWg, url, options)
+	}
+	wf.database = dbMock
+	wf.registry = registryMock
+
+	var downloaderWg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	fileId := int64(1)
+
+	dbMock.On("GetFileByUrl", url).Return(nil, nil)
+	dbMock.On("GetSettings").Return(data.DefaultSettings(), nil)
+	// The row must not be seen unregistered by a deletion
+	registering := false
+	dbMock.On("InsertFile", mock.Anything).Return(fileId, nil).
+		Run(func(mock.Arguments) { assert.True(t, registering) })
+	register := registryMock.EXPECT().Register(mock.Anything, mock.Anything).
+		RunAndReturn(func(claim func() (int64, error), cancel context.CancelFunc) (int64, error) {
+			registering = true
+			defer func() { registering = false }()
+			return claim()
+		})
+	start := downloaderMock.On("do", &downloaderWg, url, mock.Anything).
+		Return(errors.New("failed")).
+		NotBefore(register.Call)
+	registryMock.On("Unregister", fileId).Return().NotBefore(start)
+	dbMock.On("DeleteFile", &data.File{Id: fileId}).Return(nil).NotBefore(start)
+
+	err := wf.startDownloading(&downloaderWg, url)
+	assert.NotNil(t, err, "operation should have failed")
+
+	dbMock.AssertExpectations(t)
+	registryMock.AssertExpectations(t)
+	downloaderMock.AssertExpectations(t)
+}
+
 func TestStartDownloadingFromYoutube(t *testing.T) {
 	downloaderMock := bdmocks.NewMockDownloader(t)
 
@@ -199,11 +343,16 @@ func TestStartDownloadingFromYoutube(t *testing.T) {
 
 	var downloaderWg sync.WaitGroup
 	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
-	const storage = "./storage"
+	options := &wfData.Options{
+		StorageDir:       "./storage",
+		Format:           "mp3",
+		FilenameTemplate: "{title}",
+		Source:           string(data.Youtube),
+	}
 
-	downloaderMock.On("Download", &downloaderWg, url, storage).Return(nil)
+	downloaderMock.On("Download", &downloaderWg, url, options).Return(nil)
 
-	err := wf.startDownloadingFromYoutube(&downloaderWg, url, storage)
+	err := wf.startDownloadingFromYoutube(&downloaderWg, url, options)
 	assert.Nil(t, err, "operation should not have failed")
 	time.Sleep(time.Second)
 
@@ -309,6 +458,186 @@ func TestRun_ContextCancelled(t *testing.T) {
 	dbMock.AssertExpectations(t)
 }
 
+func TestRun_ShutdownInterruptsDownload(t *testing.T) {
+	downloaderMock := new(StartDownloadingMock)
+	dbMock := dmocks.NewMockDatabase(t)
+
+	jobIn := make(chan interface{}, 1)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	wf := newDownloadingWf()
+	wf.jobIn = jobIn
+	wf.jobCtx = ctx
+	wf.database = dbMock
+	wf.downloaderOut = make(chan interface{}, 1)
+	wf.fileId = 1
+	wf.startDownloading = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+	) error {
+		return downloaderMock.do(downloaderWg, url)
+	}
+
+	var wg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	request := jobmessages.Request{Url: &url}
+
+	downloaderMock.On("do", mock.Anything, url).Return(nil)
+
+	// The file row is kept so the download can be resumed
+	dbMock.On("UpdateFileStatus", &data.File{Id: 1, Status: data.FsInterrupted}).Return(nil)
+
+	cancel(common.ErrShutdown)
+
+	wg.Add(1)
+	go wf.Run(&wg, &request)
+
+	msg := <-jobIn
+	_, ok := msg.(*jobmessages.Progress)
+	assert.True(t, ok)
+
+	wg.Wait()
+
+	msg = <-jobIn
+	tMsg := msg.(*cjmessages.Error)
+	assert.Equal(t, common.ErrShutdown.Error(), tMsg.Reason)
+
+	downloaderMock.AssertExpectations(t)
+	dbMock.AssertExpectations(t)
+}
+
+func TestRun_Queued(t *testing.T) {
+	downloaderMock := new(StartDownloadingMock)
+
+	jobIn := make(chan interface{}, 2)
+
+	wf := newDownloadingWf()
+	wf.jobIn = jobIn
+	wf.jobCtx = context.Background()
+	wf.startDownloading = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+	) error {
+		return downloaderMock.do(downloaderWg, url)
+	}
+
+	released := false
+	queue := dmocks.NewMockDownloadQueue(t)
+	queue.On("TryAcquire").Return(nil, false)
+	queue.On("Acquire", wf.jobCtx).Return(func() { released = true }, nil)
+	wf.queue = queue
+
+	var wg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	request := jobmessages.Request{Url: &url}
+
+	downloaderMock.On("do", mock.Anything, url).Return(errors.New("failed"))
+
+	wg.Add(1)
+	go wf.Run(&wg, &request)
+	wg.Wait()
+
+	_, ok := (<-jobIn).(*jobmessages.Queued)
+	assert.True(t, ok)
+	_, ok = (<-jobIn).(*cjmessages.Error)
+	assert.True(t, ok)
+
+	// The slot is given back once the download is over
+	assert.True(t, released)
+
+	downloaderMock.AssertExpectations(t)
+}
+
+func TestRun_QueueWaitIsNotTimed(t *testing.T) {
+	downloaderMock := new(StartDownloadingMock)
+	dbMock := dmocks.NewMockDatabase(t)
+
+	jobIn := make(chan interface{}, 3)
+
+	wf := newDownloadingWf()
+	wf.jobIn = jobIn
+	wf.database = dbMock
+	wf.downloaderOut = make(chan interface{})
+	wf.fileId = 1
+	wf.timeout = 100 * time.Millisecond
+
+	canceled := make(chan struct{})
+	wf.cancel = func() { close(canceled) }
+
+	wf.startDownloading = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+	) error {
+		// The deadline is started after the wait in the queue
+		assert.NoError(t, wf.jobCtx.Err())
+		return downloaderMock.do(downloaderWg, url)
+	}
+
+	// The queue is busy for longer than the download may take
+	queue := dmocks.NewMockDownloadQueue(t)
+	queue.On("TryAcquire").Return(nil, false)
+	queue.On("Acquire", wf.jobCtx).Return(func() {}, nil).
+		WaitUntil(time.After(3 * wf.timeout))
+	wf.queue = queue
+
+	var wg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	request := jobmessages.Request{Url: &url}
+
+	downloaderMock.On("do", mock.Anything, url).Return(nil)
+	dbMock.On("DeleteFile", &data.File{Id: 1}).Return(nil)
+
+	wg.Add(1)
+	go wf.Run(&wg, &request)
+	wg.Wait()
+
+	_, ok := (<-jobIn).(*jobmessages.Queued)
+	assert.True(t, ok)
+	_, ok = (<-jobIn).(*jobmessages.Progress)
+	assert.True(t, ok)
+	assert.Equal(t, &cjmessages.Error{Reason: "Timeout exceeded"}, <-jobIn)
+
+	// The downloader is stopped once the download times out
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("downloader is not canceled")
+	}
+
+	downloaderMock.AssertExpectations(t)
+	dbMock.AssertExpectations(t)
+}
+
+func TestRun_QueuedCancelled(t *testing.T) {
+	jobIn := make(chan interface{}, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wf := newDownloadingWf()
+	wf.jobIn = jobIn
+	wf.jobCtx = ctx
+
+	queue := dmocks.NewMockDownloadQueue(t)
+	queue.On("TryAcquire").Return(nil, false)
+	queue.On("Acquire", ctx).Return(nil, context.Canceled)
+	wf.queue = queue
+
+	var wg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	request := jobmessages.Request{Url: &url}
+
+	wg.Add(1)
+	go wf.Run(&wg, &request)
+	wg.Wait()
+
+	_, ok := (<-jobIn).(*jobmessages.Queued)
+	assert.True(t, ok)
+	_, ok = (<-jobIn).(*cjmessages.Canceled)
+	assert.True(t, ok)
+}
+
 func TestRun_HappyPass(t *testing.T) {
 	downloaderMock := new(StartDownloadingMock)
 	dbMock := dmocks.NewMockDatabase(t)
@@ -344,6 +673,14 @@ func TestRun_HappyPass(t *testing.T) {
 			updateFilePathFile = args.Get(0).(*data.File)
 		})
 
+	var updateFileDigestFile *data.File
+	dbMock.On("UpdateFileDigest", mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) {
+			updateFileDigestFile = args.Get(0).(*data.File)
+		})
+
+	dbMock.On("UpdateFileMetadata", mock.Anything).Return(nil)
+
 	var UpdateFileStatus *data.File
 	dbMock.On("UpdateFileStatus", mock.Anything).Return(nil).
 		Run(func(args mock.Arguments) {
@@ -354,8 +691,10 @@ func TestRun_HappyPass(t *testing.T) {
 	go wf.Run(&wg, &request)
 
 	expectedPercentage := 33.0
-	expectedPercentage2 := 33.0
+	expectedPercentage2 := 66.0
 	filename := "filename"
+	size := int64(42)
+	checksum := "checksum"
 
 	go func() {
 		time.Sleep(1200 * time.Millisecond)
@@ -363,7 +702,7 @@ func TestRun_HappyPass(t *testing.T) {
 		downloaderOut <- &wfData.Progress{Percentage: 50.0}
 		time.Sleep(1200 * time.Millisecond)
 		downloaderOut <- &wfData.Progress{Percentage: expectedPercentage2}
-		downloaderOut <- &wfData.Done{Filename: filename}
+		downloaderOut <- &wfData.Done{Filename: filename, Size: size, Checksum: checksum}
 	}()
 
 	msg := <-jobIn
@@ -390,6 +729,9 @@ func TestRun_HappyPass(t *testing.T) {
 
 	assert.Equal(t, updateFilePathFile.Id, wf.fileId)
 	assert.Equal(t, updateFilePathFile.Path, sql.NullString{String: filename, Valid: true})
+	assert.Equal(t, updateFileDigestFile.Id, wf.fileId)
+	assert.Equal(t, updateFileDigestFile.Size, sql.NullInt64{Int64: size, Valid: true})
+	assert.Equal(t, updateFileDigestFile.Checksum, sql.NullString{String: checksum, Valid: true})
 	assert.Equal(t, UpdateFileStatus.Id, wf.fileId)
 	assert.Equal(t, UpdateFileStatus.Status, data.FsFinished)
 
@@ -397,6 +739,52 @@ func TestRun_HappyPass(t *testing.T) {
 	dbMock.AssertExpectations(t)
 }
 
+func TestRun_PathTaken(t *testing.T) {
+	downloaderMock := new(StartDownloadingMock)
+	dbMock := dmocks.NewMockDatabase(t)
+	fsMock := dmocks.NewMockFilesystem(t)
+
+	jobIn := make(chan interface{}, 2)
+	downloaderOut := make(chan interface{}, 1)
+
+	wf := newDownloadingWf()
+	wf.jobIn = jobIn
+	wf.database = dbMock
+	wf.filesystem = fsMock
+	wf.downloaderOut = downloaderOut
+	wf.fileId = 1
+	wf.storageDir = "/home/storage"
+	wf.startDownloading = func(
+		downloaderWg *sync.WaitGroup,
+		url string,
+	) error {
+		return downloaderMock.do(downloaderWg, url)
+	}
+
+	var wg sync.WaitGroup
+	url := "https://www.youtube.com/watch?v=2AB3_l0iqSk"
+	request := jobmessages.Request{Url: &url}
+
+	downloaderMock.On("do", mock.Anything, url).Return(nil)
+
+	// The server keeps running, the stored file and the row are removed
+	dbMock.EXPECT().UpdateFilePath(mock.Anything).Return(data.AlreadyExists)
+	fsMock.EXPECT().DeleteFile(filepath.Join("/home/storage", "song.mp3")).Return(nil)
+	dbMock.EXPECT().DeleteFile(&data.File{Id: 1}).Return(nil)
+
+	downloaderOut <- &wfData.Done{Filename: "song.mp3"}
+
+	wg.Add(1)
+	go wf.Run(&wg, &request)
+	wg.Wait()
+
+	_, ok := (<-jobIn).(*jobmessages.Progress)
+	assert.True(t, ok)
+	assert.Equal(t, &cjmessages.Error{Reason: "failed to store downloaded file"}, <-jobIn)
+
+	downloaderMock.AssertExpectations(t)
+}
+
 func TestRun_DownloadingFailed(t *testing.T) {
 	downloaderMock := new(StartDownloadingMock)
 	dbMock := dmocks.NewMockDatabase(t)
@@ -1,12 +1,31 @@
 package jobmessages
 
+import wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
+
 type Request struct {
 	Url *string `json:"url"`
 }
 
+// Queued is sent when the download waits for other downloads
+// or for the download schedule
+type Queued struct{}
+
+// Progress values which are not known are omitted
 type Progress struct {
-	Id         int64   `json:"id"`
+	Id    int64        `json:"id"`
+	Phase wfData.Phase `json:"phase"`
+	// Progress of the current phase, it starts over with every phase,
+	// so clients should show it together with the phase. It does not go
+	// backwards within a phase when the downloader fetches several
+	// streams, e.g. audio after video
 	Percentage float64 `json:"percentage"`
+
+	DownloadedBytes int64 `json:"downloadedBytes,omitempty"`
+	TotalBytes      int64 `json:"totalBytes,omitempty"`
+	// Bytes per second
+	Speed float64 `json:"speed,omitempty"`
+	// Seconds left
+	Eta float64 `json:"eta,omitempty"`
 }
 
 type Done struct {
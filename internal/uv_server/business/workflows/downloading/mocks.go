@@ -3,6 +3,8 @@ package downloading
 import (
 	"sync"
 
+	wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,9 +15,9 @@ type StartDownloadingFromYoutubeMock struct {
 func (m *StartDownloadingFromYoutubeMock) do(
 	downloaderWg *sync.WaitGroup,
 	url string,
-	storageDir string,
+	options *wfData.Options,
 ) error {
-	args := m.Called(downloaderWg, url, storageDir)
+	args := m.Called(downloaderWg, url, options)
 	return args.Error(0)
 }
 
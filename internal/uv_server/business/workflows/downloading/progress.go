@@ -0,0 +1,78 @@
+package downloading
+
+import (
+	"time"
+	wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/downloading/job_messages"
+)
+
+// progressThrottle limits the rate of progress messages, messages of
+// a new phase are never dropped. Percentage belongs to the current
+// phase, the downloader starts it over with every stream, so it is kept
+// from going backwards until the phase changes
+type progressThrottle struct {
+	fileId   int64
+	interval time.Duration
+
+	phase      wfData.Phase
+	percentage float64
+	sentAt     time.Time
+}
+
+func newProgressThrottle(fileId int64, interval time.Duration) *progressThrottle {
+	return &progressThrottle{
+		fileId:   fileId,
+		interval: interval,
+		phase:    wfData.PhaseFetchingMetadata,
+	}
+}
+
+func (p *progressThrottle) first() *jobmessages.Progress {
+	p.sentAt = time.Now()
+	return &jobmessages.Progress{Id: p.fileId, Phase: p.phase}
+}
+
+// next returns nil when the progress is dropped
+func (p *progressThrottle) next(
+	progress *wfData.Progress,
+	now time.Time,
+) *jobmessages.Progress {
+	phase := progress.Phase
+	if phase == "" {
+		phase = wfData.PhaseDownloading
+	}
+
+	percentage := max(0, min(progress.Percentage, 100))
+	if phase == p.phase {
+		percentage = max(p.percentage, percentage)
+	}
+
+	// Dropped progress counts too, otherwise the next stream could
+	// still send less than was dropped
+	p.percentage = percentage
+
+	if phase == p.phase && now.Sub(p.sentAt) < p.interval {
+		return nil
+	}
+
+	p.phase = phase
+	p.sentAt = now
+
+	return &jobmessages.Progress{
+		Id:              p.fileId,
+		Phase:           phase,
+		Percentage:      percentage,
+		DownloadedBytes: progress.DownloadedBytes,
+		TotalBytes:      progress.TotalBytes,
+		Speed:           progress.Speed,
+		Eta:             progress.Eta,
+	}
+}
+
+func (p *progressThrottle) last() *jobmessages.Progress {
+	return &jobmessages.Progress{
+		Id:         p.fileId,
+		Phase:      wfData.PhaseFinalizing,
+		Percentage: 100,
+	}
+}
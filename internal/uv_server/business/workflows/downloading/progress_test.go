@@ -0,0 +1,77 @@
+package downloading
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wfData "uv_server/internal/uv_server/business/workflows/downloading/data"
+)
+
+func TestProgressThrottle(t *testing.T) {
+	throttle := newProgressThrottle(1, time.Second)
+
+	first := throttle.first()
+	assert.Equal(t, wfData.PhaseFetchingMetadata, first.Phase)
+
+	now := time.Now()
+
+	// A new phase is sent right away
+	msg := throttle.next(&wfData.Progress{
+		Phase:           wfData.PhaseDownloading,
+		Percentage:      40,
+		DownloadedBytes: 400,
+		TotalBytes:      1000,
+		Speed:           100,
+		Eta:             6,
+	}, now)
+	require.NotNil(t, msg)
+	assert.Equal(t, wfData.PhaseDownloading, msg.Phase)
+	assert.Equal(t, 40.0, msg.Percentage)
+	assert.Equal(t, int64(400), msg.DownloadedBytes)
+	assert.Equal(t, int64(1000), msg.TotalBytes)
+	assert.Equal(t, 100.0, msg.Speed)
+	assert.Equal(t, 6.0, msg.Eta)
+
+	msg = throttle.next(&wfData.Progress{
+		Phase:      wfData.PhaseDownloading,
+		Percentage: 60,
+	}, now.Add(500*time.Millisecond))
+	assert.Nil(t, msg)
+
+	// The next stream starts over, the percentage does not go back
+	msg = throttle.next(&wfData.Progress{
+		Phase:      wfData.PhaseDownloading,
+		Percentage: 10,
+	}, now.Add(1500*time.Millisecond))
+	require.NotNil(t, msg)
+	assert.Equal(t, 60.0, msg.Percentage)
+
+	msg = throttle.next(&wfData.Progress{
+		Phase:      wfData.PhaseDownloading,
+		Percentage: 80,
+	}, now.Add(2500*time.Millisecond))
+	require.NotNil(t, msg)
+	assert.Equal(t, 80.0, msg.Percentage)
+
+	msg = throttle.next(&wfData.Progress{
+		Phase:      wfData.PhaseDownloading,
+		Percentage: 100.5,
+	}, now.Add(3500*time.Millisecond))
+	require.NotNil(t, msg)
+	assert.Equal(t, 100.0, msg.Percentage)
+
+	// A new phase starts over
+	msg = throttle.next(&wfData.Progress{
+		Phase: wfData.PhasePostProcessing,
+	}, now.Add(3600*time.Millisecond))
+	require.NotNil(t, msg)
+	assert.Equal(t, wfData.PhasePostProcessing, msg.Phase)
+	assert.Equal(t, 0.0, msg.Percentage)
+
+	last := throttle.last()
+	assert.Equal(t, wfData.PhaseFinalizing, last.Phase)
+	assert.Equal(t, 100.0, last.Percentage)
+}
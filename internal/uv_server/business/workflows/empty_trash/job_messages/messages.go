@@ -0,0 +1,12 @@
+package jobmessages
+
+// Request purges the listed trashed files, the whole trash
+// is emptied when no ids are given
+type Request struct {
+	Ids []int64 `json:"ids"`
+}
+
+type Result struct {
+	Purged    []int64 `json:"purged"`
+	FailedIds []int64 `json:"failedIds"`
+}
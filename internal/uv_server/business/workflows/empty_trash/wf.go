@@ -0,0 +1,151 @@
+package emptytrash
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/empty_trash/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EmptyTrashWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+func NewEmptyTrashWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+) *EmptyTrashWf {
+	object := &EmptyTrashWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "EmptyTrashWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+
+	return object
+}
+
+func (w *EmptyTrashWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	result := &jobmessages.Result{Purged: []int64{}, FailedIds: []int64{}}
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		w.log.Errorf("failed to get settings: %v", err)
+		w.jobIn <- &cjmessages.Error{Reason: "failed to get settings"}
+		return
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+
+	files, err := w.getFiles(request.Ids)
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	for _, file := range files {
+		switch w.jobCtx.Err() {
+		case context.DeadlineExceeded:
+			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+			return
+		case context.Canceled:
+			w.jobIn <- &cjmessages.Canceled{}
+			return
+		}
+
+		err := w.purgeFile(storageDir, file)
+		if err != nil {
+			result.FailedIds = append(result.FailedIds, file.Id)
+		} else {
+			result.Purged = append(result.Purged, file.Id)
+		}
+	}
+
+	w.jobIn <- result
+}
+
+// getFiles returns files to be purged, requested ids which can not
+// be loaded are returned as bare entries failing on purge
+func (w *EmptyTrashWf) getFiles(ids []int64) ([]*data.File, error) {
+	if len(ids) == 0 {
+		trashed, err := w.database.GetTrashedFiles(time.Now())
+		if err != nil {
+			w.log.Errorf("failed to get trashed files: %v", err)
+			return nil, errors.New("failed to get trashed files")
+		}
+
+		files := make([]*data.File, 0, len(trashed))
+		for i := range trashed {
+			files = append(files, &trashed[i])
+		}
+
+		return files, nil
+	}
+
+	files := make([]*data.File, 0, len(ids))
+
+	for _, id := range ids {
+		file, err := w.database.GetFile(id)
+		if err != nil {
+			w.log.WithField("id", id).Errorf("failed to get file: %v", err)
+			files = append(files, &data.File{Id: id})
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func (w *EmptyTrashWf) purgeFile(storageDir string, file *data.File) error {
+	log := w.log.WithField("id", file.Id)
+
+	if !file.IsTrashed() {
+		err := errors.New("file is not in trash")
+		log.Error(err)
+		return err
+	}
+
+	err := data.PurgeFile(w.database, w.filesystem, storageDir, file)
+	if err != nil {
+		log.Errorf("failed to purge file, error is: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package emptytrash
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/empty_trash/job_messages"
+	"uv_server/internal/uv_server/config"
+)
+
+func TestRun(t *testing.T) {
+	dbMock := dmocks.NewMockDatabase(t)
+	fsMock := dmocks.NewMockFilesystem(t)
+
+	jobIn := make(chan interface{}, 1)
+	deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
+
+	settings := data.DefaultSettings()
+	settings.StorageDir = "/storage"
+
+	dbMock.EXPECT().GetSettings().Return(settings, nil)
+	dbMock.EXPECT().GetTrashedFiles(mock.Anything).Return([]data.File{
+		{Id: 1, Path: sql.NullString{String: "a.mp3", Valid: true}, DeletedAt: deletedAt},
+		// Imported in place, only the row is purged
+		{Id: 2, Path: sql.NullString{String: "/music/b.mp3", Valid: true}, DeletedAt: deletedAt},
+	}, nil)
+	fsMock.EXPECT().DeleteDirectory("/storage/.trash/1").Return(nil)
+	dbMock.EXPECT().DeleteFile(&data.File{Id: 1}).Return(nil)
+	dbMock.EXPECT().DeleteFile(&data.File{Id: 2}).Return(nil)
+
+	wf := &EmptyTrashWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.config = &config.Config{HomeDir: "/"}
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+	wf.database = dbMock
+	wf.filesystem = fsMock
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	wf.Run(&wg, &jobmessages.Request{})
+
+	assert.Equal(t, &jobmessages.Result{Purged: []int64{1, 2}, FailedIds: []int64{}}, <-jobIn)
+}
@@ -0,0 +1,63 @@
+package jobmessages
+
+import "time"
+
+type Format string
+
+const (
+	FormatM3u8 Format = "m3u8"
+	FormatJson Format = "json"
+	FormatCsv  Format = "csv"
+)
+
+// LibraryVersion is incremented on incompatible changes of the JSON dump
+const LibraryVersion = 1
+
+type Request struct {
+	Format Format `json:"format"`
+	// M3U8 only, the whole library is exported when not set
+	PlaylistId *int64 `json:"playlistId"`
+	// The export is streamed back in chunks when not set
+	Path *string `json:"path"`
+}
+
+type Chunk struct {
+	Data string `json:"data"`
+}
+
+type Result struct {
+	// Absolute path of the written file, not set for streamed exports
+	Path  *string `json:"path,omitempty"`
+	Files int     `json:"files"`
+	Size  int     `json:"size"`
+}
+
+// Library is the JSON dump of the library, it is also accepted by
+// the library import
+type Library struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Files      []LibraryFile     `json:"files"`
+	Playlists  []LibraryPlaylist `json:"playlists"`
+}
+
+type LibraryFile struct {
+	// Id is used only to reference the file within the dump
+	Id        int64     `json:"id"`
+	Path      *string   `json:"path"`
+	SourceUrl string    `json:"sourceUrl"`
+	Source    string    `json:"source"`
+	Status    string    `json:"status"`
+	Size      *int64    `json:"size"`
+	Checksum  *string   `json:"checksum"`
+	Title     *string   `json:"title"`
+	Artist    *string   `json:"artist"`
+	Duration  *float64  `json:"duration"`
+	Tags      []string  `json:"tags"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+type LibraryPlaylist struct {
+	Name    string  `json:"name"`
+	FileIds []int64 `json:"fileIds"`
+}
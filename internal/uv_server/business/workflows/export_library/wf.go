@@ -0,0 +1,449 @@
+package exportlibrary
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Streamed exports are split into chunks of this size in bytes
+const chunkSize = 64 * 1024
+
+var csvHeader = []string{
+	"id", "path", "source_url", "source", "status", "size", "checksum",
+	"title", "artist", "duration", "tags", "added_at",
+}
+
+type ExportLibraryWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+func NewExportLibraryWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+) *ExportLibraryWf {
+	object := &ExportLibraryWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "ExportLibraryWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+
+	return object
+}
+
+func (w *ExportLibraryWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	result, err := w.export(request)
+
+	if errors.Is(err, context.Canceled) {
+		w.jobIn <- &cjmessages.Canceled{}
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+		return
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func (w *ExportLibraryWf) export(
+	request *jobmessages.Request,
+) (*jobmessages.Result, error) {
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		return nil, errors.New("failed to get settings")
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+
+	var outPath string
+	if request.Path != nil {
+		outPath = w.config.ResolvePath(*request.Path)
+	}
+
+	var content []byte
+	var files int
+
+	switch request.Format {
+	case jobmessages.FormatM3u8:
+		// Entries are relative to the playlist file so it can be opened
+		// from its location, streamed playlists are relative to the storage
+		baseDir := storageDir
+		if request.Path != nil {
+			baseDir = filepath.Dir(outPath)
+		}
+
+		content, files, err = w.exportM3u8(request.PlaylistId, storageDir, baseDir)
+	case jobmessages.FormatJson:
+		content, files, err = w.exportJson()
+	case jobmessages.FormatCsv:
+		content, files, err = w.exportCsv()
+	default:
+		w.log.Fatalf("unknown export format: %v", request.Format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &jobmessages.Result{Files: files, Size: len(content)}
+
+	if request.Path == nil {
+		return result, w.stream(content)
+	}
+
+	w.log.Infof("writing %v export to %v", request.Format, outPath)
+
+	err = w.filesystem.WriteFile(outPath, content)
+	if err != nil {
+		w.log.Errorf("failed to write export: %v", err)
+		return nil, errors.New("failed to write export")
+	}
+
+	result.Path = &outPath
+
+	return result, nil
+}
+
+// stream sends the content in chunks, chunks never split a UTF-8 sequence
+func (w *ExportLibraryWf) stream(content []byte) error {
+	for len(content) > 0 {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			return err
+		}
+
+		end := min(chunkSize, len(content))
+		for end < len(content) && end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+
+		w.jobIn <- &jobmessages.Chunk{Data: string(content[:end])}
+		content = content[end:]
+	}
+
+	return nil
+}
+
+func (w *ExportLibraryWf) exportM3u8(
+	playlistId *int64,
+	storageDir string,
+	baseDir string,
+) ([]byte, int, error) {
+	var files []data.File
+	var name string
+	var err error
+
+	if playlistId != nil {
+		name, files, err = w.getPlaylistFiles(*playlistId)
+	} else {
+		files, err = w.database.GetLibraryFiles()
+		if err != nil {
+			err = errors.New("failed to get files")
+		}
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	count := 0
+
+	buf.WriteString("#EXTM3U\n")
+	if name != "" {
+		fmt.Fprintf(&buf, "#PLAYLIST:%v\n", oneLine(name))
+	}
+
+	for _, file := range files {
+		// Only downloaded files can be played
+		if file.Status != data.FsFinished || !file.Path.Valid || file.IsTrashed() {
+			continue
+		}
+
+		duration := -1
+		if file.Duration.Valid {
+			duration = int(math.Round(file.Duration.Float64))
+		}
+
+		fmt.Fprintf(&buf, "#EXTINF:%v,%v\n", duration, oneLine(displayName(&file)))
+		buf.WriteString(entryPath(storageDir, baseDir, file.Path.String) + "\n")
+		count++
+	}
+
+	return buf.Bytes(), count, nil
+}
+
+func (w *ExportLibraryWf) getPlaylistFiles(id int64) (string, []data.File, error) {
+	playlist, err := w.database.GetPlaylist(id)
+	if errors.Is(err, data.NotFound) {
+		return "", nil, errors.New("playlist is not found")
+	} else if err != nil {
+		return "", nil, errors.New("failed to get playlist")
+	}
+
+	fileIds, err := w.database.GetPlaylistEntries(id)
+	if err != nil {
+		return "", nil, errors.New("failed to get playlist entries")
+	}
+
+	files := make([]data.File, 0, len(fileIds))
+
+	for _, fileId := range fileIds {
+		file, err := w.database.GetFile(fileId)
+		if err != nil {
+			w.log.Errorf("failed to get file %v: %v", fileId, err)
+			return "", nil, errors.New("failed to get file")
+		}
+
+		files = append(files, *file)
+	}
+
+	return playlist.Name, files, nil
+}
+
+func (w *ExportLibraryWf) exportJson() ([]byte, int, error) {
+	library, err := w.collectLibrary()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	content, err := json.MarshalIndent(library, "", "  ")
+	if err != nil {
+		w.log.Fatalf("failed to serialize library: %v", err)
+	}
+
+	return content, len(library.Files), nil
+}
+
+func (w *ExportLibraryWf) exportCsv() ([]byte, int, error) {
+	library, err := w.collectLibrary()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	writer.Write(csvHeader)
+
+	for _, file := range library.Files {
+		writer.Write([]string{
+			strconv.FormatInt(file.Id, 10),
+			valueOrEmpty(file.Path),
+			file.SourceUrl,
+			file.Source,
+			file.Status,
+			formatOrEmpty(file.Size, func(size int64) string {
+				return strconv.FormatInt(size, 10)
+			}),
+			valueOrEmpty(file.Checksum),
+			valueOrEmpty(file.Title),
+			valueOrEmpty(file.Artist),
+			formatOrEmpty(file.Duration, func(duration float64) string {
+				return strconv.FormatFloat(duration, 'f', -1, 64)
+			}),
+			strings.Join(file.Tags, ";"),
+			file.AddedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		w.log.Fatalf("failed to write csv: %v", err)
+	}
+
+	return buf.Bytes(), len(library.Files), nil
+}
+
+// collectLibrary reads files with their tags and playlists,
+// trashed files are not exported
+func (w *ExportLibraryWf) collectLibrary() (*jobmessages.Library, error) {
+	files, err := w.database.GetLibraryFiles()
+	if err != nil {
+		return nil, errors.New("failed to get files")
+	}
+
+	library := &jobmessages.Library{
+		Version:    jobmessages.LibraryVersion,
+		ExportedAt: time.Now().UTC(),
+		Files:      make([]jobmessages.LibraryFile, 0, len(files)),
+		Playlists:  []jobmessages.LibraryPlaylist{},
+	}
+
+	exported := map[int64]bool{}
+
+	for _, file := range files {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			return nil, err
+		}
+
+		tags, err := w.database.GetFileTags(file.Id)
+		if err != nil {
+			return nil, errors.New("failed to get file tags")
+		}
+
+		library.Files = append(library.Files, toLibraryFile(&file, tags))
+		exported[file.Id] = true
+	}
+
+	playlists, err := w.database.GetPlaylists()
+	if err != nil {
+		return nil, errors.New("failed to get playlists")
+	}
+
+	for _, playlist := range playlists {
+		fileIds, err := w.database.GetPlaylistEntries(playlist.Id)
+		if err != nil {
+			return nil, errors.New("failed to get playlist entries")
+		}
+
+		entries := make([]int64, 0, len(fileIds))
+		for _, fileId := range fileIds {
+			if exported[fileId] {
+				entries = append(entries, fileId)
+			}
+		}
+
+		library.Playlists = append(library.Playlists, jobmessages.LibraryPlaylist{
+			Name:    playlist.Name,
+			FileIds: entries,
+		})
+	}
+
+	return library, nil
+}
+
+func toLibraryFile(file *data.File, tags []string) jobmessages.LibraryFile {
+	result := jobmessages.LibraryFile{
+		Id:        file.Id,
+		SourceUrl: file.SourceUrl,
+		Source:    string(file.Source),
+		Status:    string(file.Status),
+		Tags:      tags,
+		AddedAt:   file.AddedAt,
+	}
+
+	if file.Path.Valid {
+		result.Path = &file.Path.String
+	}
+	if file.Size.Valid {
+		result.Size = &file.Size.Int64
+	}
+	if file.Checksum.Valid {
+		result.Checksum = &file.Checksum.String
+	}
+	if file.Title.Valid {
+		result.Title = &file.Title.String
+	}
+	if file.Artist.Valid {
+		result.Artist = &file.Artist.String
+	}
+	if file.Duration.Valid {
+		result.Duration = &file.Duration.Float64
+	}
+
+	return result
+}
+
+// entryPath returns a slash separated path of the file relative to
+// the base directory, files outside of the storage keep absolute paths
+func entryPath(storageDir string, baseDir string, path string) string {
+	abs := data.ResolveFilePath(storageDir, path)
+	if !data.IsInStorage(path) {
+		return filepath.ToSlash(abs)
+	}
+
+	rel, err := filepath.Rel(baseDir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func displayName(file *data.File) string {
+	title := file.Title.String
+	if !file.Title.Valid {
+		title = strings.TrimSuffix(filepath.Base(file.Path.String), filepath.Ext(file.Path.String))
+	}
+
+	if file.Artist.Valid {
+		return file.Artist.String + " - " + title
+	}
+
+	return title
+}
+
+// oneLine keeps M3U directives on a single line
+func oneLine(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func formatOrEmpty[T any](value *T, format func(T) string) string {
+	if value == nil {
+		return ""
+	}
+
+	return format(*value)
+}
@@ -0,0 +1,44 @@
+package exportlibrary
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	jobmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+)
+
+func TestStream_KeepsUtf8Sequences(t *testing.T) {
+	// The two byte rune is placed across the chunk boundary
+	content := strings.Repeat("a", chunkSize-1) + "ü" + strings.Repeat("b", 10)
+
+	jobIn := make(chan interface{}, 3)
+
+	wf := &ExportLibraryWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+
+	err := wf.stream([]byte(content))
+	assert.NoError(t, err)
+	close(jobIn)
+
+	var result strings.Builder
+	for msg := range jobIn {
+		chunk := msg.(*jobmessages.Chunk)
+		assert.True(t, utf8.ValidString(chunk.Data))
+		result.WriteString(chunk.Data)
+	}
+
+	assert.Equal(t, content, result.String())
+}
+
+func TestEntryPath(t *testing.T) {
+	assert.Equal(t, "a.mp3", entryPath("/storage", "/storage", "a.mp3"))
+	assert.Equal(t, "../storage/sub/a.mp3", entryPath("/storage", "/exports", "sub/a.mp3"))
+	assert.Equal(t, "/music/a.mp3", entryPath("/storage", "/exports", "/music/a.mp3"))
+}
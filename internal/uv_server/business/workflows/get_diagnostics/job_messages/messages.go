@@ -0,0 +1,44 @@
+package jobmessages
+
+import "time"
+
+type Config struct {
+	Port                       uint16 `json:"port"`
+	HomeDir                    string `json:"homeDir"`
+	InstallDir                 string `json:"installDir"`
+	DbLocation                 string `json:"dbLocation"`
+	LogsLocation               string `json:"logsLocation"`
+	FfmpegLocation             string `json:"ffmpegLocation"`
+	ToolsLocation              string `json:"toolsLocation"`
+	StorageDir                 string `json:"storageDir"`
+	BackupsLocation            string `json:"backupsLocation"`
+	BackupIntervalHours        int    `json:"backupIntervalHours"`
+	BackupsToKeep              int    `json:"backupsToKeep"`
+	ShutdownGracePeriodSeconds int    `json:"shutdownGracePeriodSeconds"`
+	AllowClientReconnect       bool   `json:"allowClientReconnect"`
+}
+
+type Tool struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Available bool   `json:"available"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type Job struct {
+	Uuid      string    `json:"uuid"`
+	Type      string    `json:"type"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type Result struct {
+	ServerVersion     string `json:"serverVersion"`
+	DbVersion         int    `json:"dbVersion"`
+	RequiredDbVersion int    `json:"requiredDbVersion"`
+	Config            Config `json:"config"`
+	Tools             []Tool `json:"tools"`
+	// Bytes available in the storage directory
+	FreeSpace uint64 `json:"freeSpace"`
+	Jobs      []Job  `json:"jobs"`
+}
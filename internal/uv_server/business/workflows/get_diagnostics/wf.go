@@ -0,0 +1,138 @@
+package getdiagnostics
+
+import (
+	"context"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/get_diagnostics/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GetDiagnosticsWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database    data.Database
+	diagnostics data.Diagnostics
+	activeJobs  data.ActiveJobs
+}
+
+func NewGetDiagnosticsWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	diagnostics data.Diagnostics,
+	activeJobs data.ActiveJobs,
+) *GetDiagnosticsWf {
+	object := &GetDiagnosticsWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "GetDiagnosticsWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.diagnostics = diagnostics
+	object.activeJobs = activeJobs
+
+	return object
+}
+
+// Run collects the state of the server, tools which are not available
+// are reported in the result instead of failing the request
+func (w *GetDiagnosticsWf) Run(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		w.log.Errorf("failed to get settings: %v", err)
+		w.jobIn <- &cjmessages.InternalError
+		return
+	}
+
+	dbVersion, requiredDbVersion, err := w.diagnostics.GetDbVersion()
+	if err != nil {
+		w.log.Errorf("failed to get database version: %v", err)
+		w.jobIn <- &cjmessages.InternalError
+		return
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+
+	freeSpace, err := w.diagnostics.GetFreeSpace(storageDir)
+	if err != nil {
+		w.log.Errorf("failed to get free space of %v: %v", storageDir, err)
+		w.jobIn <- &cjmessages.InternalError
+		return
+	}
+
+	result := &jobmessages.Result{
+		ServerVersion:     common.Version,
+		DbVersion:         dbVersion,
+		RequiredDbVersion: requiredDbVersion,
+		Config: jobmessages.Config{
+			Port:                       w.config.Port,
+			HomeDir:                    w.config.HomeDir,
+			InstallDir:                 w.config.InstallDir,
+			DbLocation:                 w.config.DbLocation,
+			LogsLocation:               w.config.LogsLocation,
+			FfmpegLocation:             w.config.FfmpegLocation,
+			ToolsLocation:              w.config.ToolsLocation,
+			StorageDir:                 storageDir,
+			BackupsLocation:            w.config.BackupsLocation,
+			BackupIntervalHours:        w.config.BackupIntervalHours,
+			BackupsToKeep:              w.config.BackupsToKeep,
+			ShutdownGracePeriodSeconds: w.config.ShutdownGracePeriodSeconds,
+			AllowClientReconnect:       w.config.AllowClientReconnect,
+		},
+		Tools:     []jobmessages.Tool{},
+		FreeSpace: freeSpace,
+		Jobs:      []jobmessages.Job{},
+	}
+
+	for _, tool := range w.diagnostics.GetTools() {
+		result.Tools = append(result.Tools, jobmessages.Tool{
+			Name:      tool.Name,
+			Path:      tool.Path,
+			Available: tool.Available,
+			Version:   tool.Version,
+			Error:     tool.Error,
+		})
+	}
+
+	for _, job := range w.activeJobs.GetActiveJobs() {
+		// The diagnostics job itself is not interesting
+		if job.Uuid == w.uuid {
+			continue
+		}
+
+		result.Jobs = append(result.Jobs, jobmessages.Job{
+			Uuid:      job.Uuid,
+			Type:      job.Type,
+			StartedAt: job.StartedAt,
+		})
+	}
+
+	w.jobIn <- result
+}
@@ -0,0 +1,96 @@
+package getdiagnostics
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/get_diagnostics/job_messages"
+	"uv_server/internal/uv_server/config"
+)
+
+const uuid = "diagnostics"
+
+func newGetDiagnosticsWf(
+	jobIn chan interface{},
+	database data.Database,
+	diagnostics data.Diagnostics,
+	activeJobs data.ActiveJobs,
+) *GetDiagnosticsWf {
+	wf := &GetDiagnosticsWf{}
+	wf.uuid = uuid
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.config = &config.Config{HomeDir: "/home"}
+	wf.jobIn = jobIn
+	wf.database = database
+	wf.diagnostics = diagnostics
+	wf.activeJobs = activeJobs
+
+	return wf
+}
+
+func run(wf *GetDiagnosticsWf) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	wf.Run(wg)
+	wg.Wait()
+}
+
+func TestGetDiagnostics(t *testing.T) {
+	database := dmocks.NewMockDatabase(t)
+	diagnostics := dmocks.NewMockDiagnostics(t)
+	activeJobs := dmocks.NewMockActiveJobs(t)
+
+	startedAt := time.Now()
+
+	database.EXPECT().GetSettings().Return(&data.Settings{StorageDir: "storage"}, nil)
+	diagnostics.EXPECT().GetDbVersion().Return(3, 4, nil)
+	diagnostics.EXPECT().GetFreeSpace("/home/storage").Return(1024, nil)
+	diagnostics.EXPECT().GetTools().Return([]data.ToolInfo{
+		{Name: "ffmpeg", Path: "/ffmpeg", Available: true, Version: "ffmpeg version 7.1"},
+		{Name: "downloader", Path: "/downloader", Error: "not found"},
+	})
+	activeJobs.EXPECT().GetActiveJobs().Return([]data.JobInfo{
+		{Uuid: "download", Type: "DownloadRequest", StartedAt: startedAt},
+		{Uuid: uuid, Type: "GetDiagnosticsRequest", StartedAt: startedAt},
+	})
+
+	jobIn := make(chan interface{}, 1)
+	run(newGetDiagnosticsWf(jobIn, database, diagnostics, activeJobs))
+
+	result := (<-jobIn).(*jobmessages.Result)
+
+	assert.Equal(t, 3, result.DbVersion)
+	assert.Equal(t, 4, result.RequiredDbVersion)
+	assert.Equal(t, "/home/storage", result.Config.StorageDir)
+	assert.Equal(t, uint64(1024), result.FreeSpace)
+	assert.Equal(t, []jobmessages.Tool{
+		{Name: "ffmpeg", Path: "/ffmpeg", Available: true, Version: "ffmpeg version 7.1"},
+		{Name: "downloader", Path: "/downloader", Error: "not found"},
+	}, result.Tools)
+	assert.Equal(t, []jobmessages.Job{
+		{Uuid: "download", Type: "DownloadRequest", StartedAt: startedAt},
+	}, result.Jobs)
+}
+
+func TestGetDiagnostics_FreeSpaceError(t *testing.T) {
+	database := dmocks.NewMockDatabase(t)
+	diagnostics := dmocks.NewMockDiagnostics(t)
+	activeJobs := dmocks.NewMockActiveJobs(t)
+
+	database.EXPECT().GetSettings().Return(&data.Settings{StorageDir: "/storage"}, nil)
+	diagnostics.EXPECT().GetDbVersion().Return(4, 4, nil)
+	diagnostics.EXPECT().GetFreeSpace("/storage").Return(0, errors.New("no such directory"))
+
+	jobIn := make(chan interface{}, 1)
+	run(newGetDiagnosticsWf(jobIn, database, diagnostics, activeJobs))
+
+	assert.Equal(t, &cjmessages.InternalError, <-jobIn)
+}
@@ -7,11 +7,16 @@ type Request struct {
 }
 
 type Result struct {
-	Id        int       `json:"id"`
-	Path      *string   `json:"path"`
-	SourceUrl string    `json:"sourceUrl"`
-	Source    string    `json:"source"`
-	Status    string    `json:"status"`
-	AddedAt   time.Time `json:"addedAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	Id        int        `json:"id"`
+	Path      *string    `json:"path"`
+	SourceUrl string     `json:"sourceUrl"`
+	Source    string     `json:"source"`
+	Status    string     `json:"status"`
+	Title     *string    `json:"title"`
+	Artist    *string    `json:"artist"`
+	Duration  *float64   `json:"duration"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	Tags      []string   `json:"tags"`
+	AddedAt   time.Time  `json:"addedAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
 }
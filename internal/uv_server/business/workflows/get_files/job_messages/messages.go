@@ -5,13 +5,19 @@ import "time"
 type Request struct {
 	Limit  *int `json:"limit"`
 	Offset *int `json:"offset"`
+	// Only trashed files are returned when set to true
+	Trashed *bool   `json:"trashed"`
+	Tag     *string `json:"tag"`
+	// Files of the playlist are returned in the playlist order
+	PlaylistId *int64 `json:"playlistId"`
 }
 
 type File struct {
-	Id      int       `json:"id"`
-	Source  string    `json:"source"`
-	Status  string    `json:"status"`
-	AddedAt time.Time `json:"addedAt"`
+	Id        int        `json:"id"`
+	Source    string     `json:"source"`
+	Status    string     `json:"status"`
+	AddedAt   time.Time  `json:"addedAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
 }
 
 type Result struct {
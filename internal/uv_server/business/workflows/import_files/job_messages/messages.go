@@ -0,0 +1,45 @@
+package jobmessages
+
+type Mode string
+
+const (
+	// Files are registered where they are
+	ModeNone Mode = "none"
+	ModeCopy Mode = "copy"
+	ModeMove Mode = "move"
+)
+
+type Request struct {
+	Dir       string `json:"dir"`
+	Mode      Mode   `json:"mode"`
+	Recursive bool   `json:"recursive"`
+}
+
+type Progress struct {
+	Processed  int     `json:"processed"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+type FileStatus string
+
+const (
+	FileImported FileStatus = "imported"
+	FileSkipped  FileStatus = "skipped"
+	FileFailed   FileStatus = "failed"
+)
+
+type FileResult struct {
+	// Path relative to the imported directory
+	Path   string     `json:"path"`
+	Status FileStatus `json:"status"`
+	Id     *int64     `json:"id,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+type Result struct {
+	Imported int          `json:"imported"`
+	Skipped  int          `json:"skipped"`
+	Failed   int          `json:"failed"`
+	Files    []FileResult `json:"files"`
+}
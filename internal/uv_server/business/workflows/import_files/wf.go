@@ -0,0 +1,349 @@
+package importfiles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/import_files/job_messages"
+	"uv_server/internal/uv_server/common/filenames"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+var mediaExtensions = []string{
+	".mp3", ".m4a", ".opus", ".flac", ".wav", ".ogg", ".aac", ".wma",
+}
+
+type ImportFilesWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+	extractor  data.MetadataExtractor
+}
+
+func NewImportFilesWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+	extractor data.MetadataExtractor,
+) *ImportFilesWf {
+	object := &ImportFilesWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "ImportFilesWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+	object.extractor = extractor
+
+	return object
+}
+
+func (w *ImportFilesWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	result, err := w.importFiles(request)
+
+	if errors.Is(err, context.Canceled) {
+		w.jobIn <- &cjmessages.Canceled{}
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+		return
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func (w *ImportFilesWf) importFiles(
+	request *jobmessages.Request,
+) (*jobmessages.Result, error) {
+	result := &jobmessages.Result{Files: []jobmessages.FileResult{}}
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+	dir := w.config.ResolvePath(request.Dir)
+
+	w.log.Infof("importing files from %v", dir)
+
+	paths, err := w.filesystem.ListFiles(dir)
+	if err != nil {
+		w.log.Errorf("failed to list directory: %v", err)
+		return nil, errors.New("failed to list directory")
+	}
+
+	paths = slices.DeleteFunc(paths, func(relPath string) bool {
+		if !request.Recursive && strings.Contains(relPath, "/") {
+			return true
+		}
+
+		ext := strings.ToLower(path.Ext(relPath))
+		return !slices.Contains(mediaExtensions, ext)
+	})
+
+	total := len(paths)
+
+	w.jobIn <- &jobmessages.Progress{Processed: 0, Total: total, Percentage: 0}
+
+	for i, relPath := range paths {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			return nil, err
+		}
+
+		fileResult := w.importFile(storageDir, dir, relPath, request.Mode, settings)
+		result.Files = append(result.Files, *fileResult)
+
+		switch fileResult.Status {
+		case jobmessages.FileImported:
+			result.Imported++
+		case jobmessages.FileSkipped:
+			result.Skipped++
+		case jobmessages.FileFailed:
+			result.Failed++
+		}
+
+		w.jobIn <- &jobmessages.Progress{
+			Processed:  i + 1,
+			Total:      total,
+			Percentage: float64(i+1) / float64(total) * 100,
+		}
+	}
+
+	return result, nil
+}
+
+func (w *ImportFilesWf) importFile(
+	storageDir string,
+	dir string,
+	relPath string,
+	mode jobmessages.Mode,
+	settings *data.Settings,
+) *jobmessages.FileResult {
+	log := w.log.WithField("path", relPath)
+	result := &jobmessages.FileResult{Path: relPath}
+
+	failed := func(reason string) *jobmessages.FileResult {
+		result.Status = jobmessages.FileFailed
+		result.Reason = reason
+		return result
+	}
+
+	src := filepath.Join(dir, filepath.FromSlash(relPath))
+	sourceUrl := fileUrl(src)
+
+	existing, err := w.database.GetFileByUrl(sourceUrl)
+	if err == nil && existing.IsTrashed() {
+		// Urls are unique, the trashed file gives way to the new one
+		log.Infof("purging trashed file with id %v", existing.Id)
+
+		err = data.PurgeFile(w.database, w.filesystem, storageDir, existing)
+		if err != nil {
+			log.Errorf("failed to purge trashed file: %v", err)
+			return failed("failed to purge trashed file")
+		}
+	} else if err == nil {
+		log.Debug("file is already imported")
+		result.Status = jobmessages.FileSkipped
+		result.Reason = "already imported"
+		return result
+	} else if !errors.Is(err, data.NotFound) {
+		log.Errorf("failed to get file by url: %v", err)
+		return failed("failed to query database")
+	}
+
+	digest, err := w.filesystem.HashFile(src)
+	if err != nil {
+		log.Errorf("failed to hash file: %v", err)
+		return failed("failed to read file")
+	}
+
+	metadata, err := w.extractor.Extract(src)
+	if err != nil {
+		log.Warnf("failed to extract metadata: %v", err)
+		metadata = &data.MediaMetadata{
+			Title: strings.TrimSuffix(filepath.Base(src), filepath.Ext(src)),
+		}
+	}
+
+	storedPath, err := w.transfer(storageDir, src, mode, metadata, settings)
+	if err != nil {
+		log.Errorf("failed to transfer file: %v", err)
+		return failed(fmt.Sprintf("failed to %v file into storage", mode))
+	}
+
+	file := &data.File{
+		Path:      sql.NullString{String: storedPath, Valid: true},
+		SourceUrl: sourceUrl,
+		Source:    data.Local,
+		Status:    data.FsFinished,
+		Size:      sql.NullInt64{Int64: digest.Size, Valid: true},
+		Checksum:  sql.NullString{String: digest.Checksum, Valid: true},
+		Title:     sql.NullString{String: metadata.Title, Valid: metadata.Title != ""},
+		Artist:    sql.NullString{String: metadata.Artist, Valid: metadata.Artist != ""},
+		Duration:  sql.NullFloat64{Float64: metadata.Duration, Valid: metadata.Duration > 0},
+	}
+
+	id, err := w.database.InsertFile(file)
+	if err != nil {
+		log.Errorf("failed to insert file: %v", err)
+		w.revertTransfer(storageDir, src, storedPath, mode)
+		return failed("failed to insert file into database")
+	}
+
+	result.Status = jobmessages.FileImported
+	result.Id = &id
+
+	return result
+}
+
+// transfer places the file according to the mode and returns the path
+// to be stored in the database
+func (w *ImportFilesWf) transfer(
+	storageDir string,
+	src string,
+	mode jobmessages.Mode,
+	metadata *data.MediaMetadata,
+	settings *data.Settings,
+) (string, error) {
+	// Files which are already in the storage are never copied
+	if rel, ok := relativeToStorage(storageDir, src); ok {
+		return rel, nil
+	}
+
+	if mode == jobmessages.ModeNone {
+		return src, nil
+	}
+
+	name, err := filenames.Render(settings.FilenameTemplate, &filenames.Metadata{
+		Title:    metadata.Title,
+		Uploader: metadata.Artist,
+		Source:   string(data.Local),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Trashed files keep their paths to be restored to
+	var existsErr error
+	relPath := filenames.Deduplicate(
+		name+strings.ToLower(filepath.Ext(src)),
+		func(relPath string) bool {
+			exists, err := w.filesystem.Exists(data.ResolveFilePath(storageDir, relPath))
+			if err != nil || exists {
+				existsErr = err
+				return exists
+			}
+
+			taken, err := w.database.IsPathTaken(relPath)
+			if err != nil {
+				existsErr = err
+			}
+			return taken
+		})
+	if existsErr != nil {
+		return "", existsErr
+	}
+
+	dst := data.ResolveFilePath(storageDir, relPath)
+
+	if mode == jobmessages.ModeMove {
+		err = w.filesystem.MoveFile(src, dst)
+	} else {
+		err = w.filesystem.CopyFile(src, dst)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return relPath, nil
+}
+
+func (w *ImportFilesWf) revertTransfer(
+	storageDir string,
+	src string,
+	storedPath string,
+	mode jobmessages.Mode,
+) {
+	if !data.IsInStorage(storedPath) {
+		return
+	}
+
+	dst := data.ResolveFilePath(storageDir, storedPath)
+	if dst == src {
+		return
+	}
+
+	var err error
+	if mode == jobmessages.ModeMove {
+		err = w.filesystem.MoveFile(dst, src)
+	} else {
+		err = w.filesystem.DeleteFile(dst)
+	}
+
+	if err != nil {
+		w.log.Errorf("failed to revert transfer of %v: %v", src, err)
+	}
+}
+
+func relativeToStorage(storageDir string, path string) (string, bool) {
+	rel, err := filepath.Rel(storageDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+func fileUrl(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
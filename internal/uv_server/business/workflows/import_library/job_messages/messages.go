@@ -0,0 +1,25 @@
+package jobmessages
+
+import exportmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+
+// Request carries either a path to a JSON dump or the dump itself
+type Request struct {
+	Path    *string                 `json:"path"`
+	Library *exportmessages.Library `json:"library"`
+}
+
+type Failure struct {
+	SourceUrl string `json:"sourceUrl"`
+	Reason    string `json:"reason"`
+}
+
+type Result struct {
+	Restored int `json:"restored"`
+	// Files which are already in the library
+	Skipped  int       `json:"skipped"`
+	Failed   int       `json:"failed"`
+	Failures []Failure `json:"failures"`
+	// Playlists with names which are already taken are skipped
+	Playlists        int `json:"playlists"`
+	SkippedPlaylists int `json:"skippedPlaylists"`
+}
@@ -0,0 +1,282 @@
+package importlibrary
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	exportmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+	jobmessages "uv_server/internal/uv_server/business/workflows/import_library/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ImportLibraryWf restores rows from a JSON dump produced by the
+// library export, files themselves are never transferred
+type ImportLibraryWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+func NewImportLibraryWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+) *ImportLibraryWf {
+	object := &ImportLibraryWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "ImportLibraryWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+
+	return object
+}
+
+func (w *ImportLibraryWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	result, err := w.importLibrary(request)
+
+	if errors.Is(err, context.Canceled) {
+		w.jobIn <- &cjmessages.Canceled{}
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+		return
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func (w *ImportLibraryWf) importLibrary(
+	request *jobmessages.Request,
+) (*jobmessages.Result, error) {
+	library, err := w.loadLibrary(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if library.Version != exportmessages.LibraryVersion {
+		return nil, fmt.Errorf("unsupported library version %v", library.Version)
+	}
+
+	result := &jobmessages.Result{Failures: []jobmessages.Failure{}}
+
+	// Ids of the dump mapped to ids of the restored or existing files
+	ids := map[int64]int64{}
+
+	for _, file := range library.Files {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			return nil, err
+		}
+
+		existing, err := w.database.GetFileByUrl(file.SourceUrl)
+		if err == nil {
+			ids[file.Id] = existing.Id
+			result.Skipped++
+			continue
+		} else if !errors.Is(err, data.NotFound) {
+			return nil, errors.New("failed to query database")
+		}
+
+		id, err := w.restoreFile(&file)
+		if err != nil {
+			w.log.Errorf("failed to restore %v: %v", file.SourceUrl, err)
+			result.Failed++
+			result.Failures = append(result.Failures, jobmessages.Failure{
+				SourceUrl: file.SourceUrl,
+				Reason:    err.Error(),
+			})
+			continue
+		}
+
+		ids[file.Id] = id
+		result.Restored++
+	}
+
+	for _, playlist := range library.Playlists {
+		restored, err := w.restorePlaylist(&playlist, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		if restored {
+			result.Playlists++
+		} else {
+			result.SkippedPlaylists++
+		}
+	}
+
+	w.log.Infof(
+		"library import finished: restored %v, skipped %v, failed %v",
+		result.Restored, result.Skipped, result.Failed)
+
+	return result, nil
+}
+
+func (w *ImportLibraryWf) loadLibrary(
+	request *jobmessages.Request,
+) (*exportmessages.Library, error) {
+	if request.Library != nil {
+		return request.Library, nil
+	}
+
+	path := w.config.ResolvePath(*request.Path)
+
+	content, err := w.filesystem.ReadFile(path)
+	if err != nil {
+		w.log.Errorf("failed to read library: %v", err)
+		return nil, errors.New("failed to read library")
+	}
+
+	library := &exportmessages.Library{}
+	err = json.Unmarshal(content, library)
+	if err != nil {
+		w.log.Errorf("failed to parse library: %v", err)
+		return nil, errors.New("failed to parse library")
+	}
+
+	return library, nil
+}
+
+func (w *ImportLibraryWf) restoreFile(file *exportmessages.LibraryFile) (int64, error) {
+	source := data.Source(file.Source)
+	if source != data.Youtube && source != data.Local {
+		return 0, fmt.Errorf("unknown source %q", file.Source)
+	}
+
+	status := data.FileStatus(file.Status)
+	switch status {
+	case data.FsPending, data.FsFinished, data.FsInterrupted:
+	case data.FsDownloading:
+		// The download was interrupted by the export
+		status = data.FsPending
+	default:
+		return 0, fmt.Errorf("unknown status %q", file.Status)
+	}
+
+	row := &data.File{
+		Path:      nullString(file.Path),
+		SourceUrl: file.SourceUrl,
+		Source:    source,
+		Status:    status,
+		Title:     nullString(file.Title),
+		Artist:    nullString(file.Artist),
+		Checksum:  nullString(file.Checksum),
+		AddedAt:   file.AddedAt,
+	}
+
+	if file.Size != nil {
+		row.Size = sql.NullInt64{Int64: *file.Size, Valid: true}
+	}
+	if file.Duration != nil {
+		row.Duration = sql.NullFloat64{Float64: *file.Duration, Valid: true}
+	}
+
+	id, err := w.database.InsertFile(row)
+	if err != nil {
+		return 0, errors.New("failed to insert file into database")
+	}
+
+	tags := make([]string, 0, len(file.Tags))
+	for _, tag := range file.Tags {
+		tag, err := data.NormalizeName(tag)
+		if err != nil {
+			w.log.Warnf("skipping invalid tag of %v: %v", file.SourceUrl, err)
+			continue
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if len(tags) != 0 {
+		err = w.database.TagFiles([]int64{id}, tags)
+		if err != nil {
+			return 0, errors.New("failed to tag file")
+		}
+	}
+
+	return id, nil
+}
+
+// restorePlaylist creates the playlist with entries which are known,
+// returns false when the playlist already exists
+func (w *ImportLibraryWf) restorePlaylist(
+	playlist *exportmessages.LibraryPlaylist,
+	ids map[int64]int64,
+) (bool, error) {
+	name, err := data.NormalizeName(playlist.Name)
+	if err != nil {
+		w.log.Warnf("skipping playlist %q: %v", playlist.Name, err)
+		return false, nil
+	}
+
+	id, err := w.database.InsertPlaylist(name)
+	if errors.Is(err, data.AlreadyExists) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.New("failed to create playlist")
+	}
+
+	entries := make([]int64, 0, len(playlist.FileIds))
+	for _, fileId := range playlist.FileIds {
+		id, ok := ids[fileId]
+		if ok && !slices.Contains(entries, id) {
+			entries = append(entries, id)
+		}
+	}
+
+	err = w.database.SetPlaylistEntries(id, entries)
+	if err != nil {
+		return false, errors.New("failed to update playlist entries")
+	}
+
+	return true, nil
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: *value, Valid: true}
+}
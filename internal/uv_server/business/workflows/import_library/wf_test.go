@@ -0,0 +1,140 @@
+package importlibrary
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	exportmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+	jobmessages "uv_server/internal/uv_server/business/workflows/import_library/job_messages"
+)
+
+func newImportLibraryWf(t *testing.T) (*ImportLibraryWf, *dmocks.MockDatabase, chan interface{}) {
+	dbMock := dmocks.NewMockDatabase(t)
+	jobIn := make(chan interface{}, 1)
+
+	wf := &ImportLibraryWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+	wf.database = dbMock
+
+	return wf, dbMock, jobIn
+}
+
+func run(wf *ImportLibraryWf, library *exportmessages.Library) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	wf.Run(&wg, &jobmessages.Request{Library: library})
+}
+
+func libraryFile(id int64, url string, status data.FileStatus) exportmessages.LibraryFile {
+	return exportmessages.LibraryFile{
+		Id:        id,
+		SourceUrl: url,
+		Source:    string(data.Youtube),
+		Status:    string(status),
+		Tags:      []string{},
+		AddedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRun_VersionMismatch(t *testing.T) {
+	wf, _, jobIn := newImportLibraryWf(t)
+
+	run(wf, &exportmessages.Library{Version: exportmessages.LibraryVersion + 1})
+
+	assert.Equal(t, &cjmessages.Error{Reason: "unsupported library version 2"}, <-jobIn)
+}
+
+func TestRun_ExistingUrlIsSkipped(t *testing.T) {
+	wf, dbMock, jobIn := newImportLibraryWf(t)
+
+	dbMock.EXPECT().GetFileByUrl("existing").Return(&data.File{Id: 7}, nil)
+
+	run(wf, &exportmessages.Library{
+		Version: exportmessages.LibraryVersion,
+		Files:   []exportmessages.LibraryFile{libraryFile(1, "existing", data.FsFinished)},
+	})
+
+	assert.Equal(t, &jobmessages.Result{
+		Skipped:  1,
+		Failures: []jobmessages.Failure{},
+	}, <-jobIn)
+}
+
+func TestRun_DownloadingBecomesPending(t *testing.T) {
+	wf, dbMock, jobIn := newImportLibraryWf(t)
+
+	dbMock.EXPECT().GetFileByUrl("url").Return(nil, data.NotFound)
+
+	var row *data.File
+	dbMock.EXPECT().InsertFile(mock.Anything).Return(1, nil).
+		Run(func(file *data.File) { row = file })
+
+	run(wf, &exportmessages.Library{
+		Version: exportmessages.LibraryVersion,
+		Files:   []exportmessages.LibraryFile{libraryFile(1, "url", data.FsDownloading)},
+	})
+
+	assert.Equal(t, &jobmessages.Result{
+		Restored: 1,
+		Failures: []jobmessages.Failure{},
+	}, <-jobIn)
+	assert.Equal(t, data.FsPending, row.Status)
+}
+
+func TestRun_PlaylistEntriesAreRemapped(t *testing.T) {
+	wf, dbMock, jobIn := newImportLibraryWf(t)
+
+	dbMock.EXPECT().GetFileByUrl("existing").Return(&data.File{Id: 7}, nil)
+	dbMock.EXPECT().GetFileByUrl("new").Return(nil, data.NotFound)
+	dbMock.EXPECT().InsertFile(mock.Anything).Return(8, nil)
+	dbMock.EXPECT().InsertPlaylist("favourites").Return(3, nil)
+	// Unknown ids of the dump are dropped
+	dbMock.EXPECT().SetPlaylistEntries(int64(3), []int64{8, 7}).Return(nil)
+
+	run(wf, &exportmessages.Library{
+		Version: exportmessages.LibraryVersion,
+		Files: []exportmessages.LibraryFile{
+			libraryFile(1, "existing", data.FsFinished),
+			libraryFile(2, "new", data.FsFinished),
+		},
+		Playlists: []exportmessages.LibraryPlaylist{
+			{Name: "favourites", FileIds: []int64{2, 1, 5}},
+		},
+	})
+
+	assert.Equal(t, &jobmessages.Result{
+		Restored:  1,
+		Skipped:   1,
+		Failures:  []jobmessages.Failure{},
+		Playlists: 1,
+	}, <-jobIn)
+}
+
+func TestRun_TakenPlaylistNameIsSkipped(t *testing.T) {
+	wf, dbMock, jobIn := newImportLibraryWf(t)
+
+	dbMock.EXPECT().InsertPlaylist("favourites").Return(0, data.AlreadyExists)
+
+	run(wf, &exportmessages.Library{
+		Version: exportmessages.LibraryVersion,
+		Playlists: []exportmessages.LibraryPlaylist{
+			{Name: "favourites", FileIds: []int64{1}},
+		},
+	})
+
+	assert.Equal(t, &jobmessages.Result{
+		Failures:         []jobmessages.Failure{},
+		SkippedPlaylists: 1,
+	}, <-jobIn)
+}
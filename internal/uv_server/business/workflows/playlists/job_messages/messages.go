@@ -0,0 +1,63 @@
+package jobmessages
+
+import "time"
+
+type GetPlaylistsRequest struct{}
+
+type GetPlaylistRequest struct {
+	Id *int64 `json:"id"`
+}
+
+type CreateRequest struct {
+	Name *string `json:"name"`
+}
+
+type RenameRequest struct {
+	Id   *int64  `json:"id"`
+	Name *string `json:"name"`
+}
+
+type DeleteRequest struct {
+	Id *int64 `json:"id"`
+}
+
+// AddEntriesRequest inserts files at the position, files are appended
+// when the position is not set. Files which are already in the playlist
+// keep their positions
+type AddEntriesRequest struct {
+	Id       *int64  `json:"id"`
+	FileIds  []int64 `json:"fileIds"`
+	Position *int    `json:"position"`
+}
+
+type RemoveEntriesRequest struct {
+	Id      *int64  `json:"id"`
+	FileIds []int64 `json:"fileIds"`
+}
+
+// ReorderRequest contains all entries of the playlist in a new order
+type ReorderRequest struct {
+	Id      *int64  `json:"id"`
+	FileIds []int64 `json:"fileIds"`
+}
+
+type Playlist struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Entries   int       `json:"entries"`
+	AddedAt   time.Time `json:"addedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PlaylistsResult struct {
+	Playlists []Playlist `json:"playlists"`
+}
+
+type PlaylistResult struct {
+	Playlist
+	FileIds []int64 `json:"fileIds"`
+}
+
+type CreateResult struct {
+	Id int64 `json:"id"`
+}
@@ -0,0 +1,303 @@
+package playlists
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/playlists/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PlaylistsWf serves all playlist requests, each request is a single
+// short database operation
+type PlaylistsWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database data.Database
+}
+
+func NewPlaylistsWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+) *PlaylistsWf {
+	object := &PlaylistsWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "PlaylistsWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+
+	return object
+}
+
+func (w *PlaylistsWf) Run(wg *sync.WaitGroup, request interface{}) {
+	defer wg.Done()
+
+	var result interface{}
+	var err error
+
+	switch tRequest := request.(type) {
+	case *jobmessages.GetPlaylistsRequest:
+		result, err = w.getPlaylists()
+	case *jobmessages.GetPlaylistRequest:
+		result, err = w.getPlaylist(*tRequest.Id)
+	case *jobmessages.CreateRequest:
+		result, err = w.create(*tRequest.Name)
+	case *jobmessages.RenameRequest:
+		err = w.rename(*tRequest.Id, *tRequest.Name)
+	case *jobmessages.DeleteRequest:
+		err = w.delete(*tRequest.Id)
+	case *jobmessages.AddEntriesRequest:
+		err = w.addEntries(*tRequest.Id, tRequest.FileIds, tRequest.Position)
+	case *jobmessages.RemoveEntriesRequest:
+		err = w.removeEntries(*tRequest.Id, tRequest.FileIds)
+	case *jobmessages.ReorderRequest:
+		err = w.reorder(*tRequest.Id, tRequest.FileIds)
+	default:
+		w.log.Fatalf("unknown request: %v", reflect.TypeOf(request))
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	if result == nil {
+		w.jobIn <- &cjmessages.Done{}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func toPlaylist(playlist *data.Playlist) jobmessages.Playlist {
+	return jobmessages.Playlist{
+		Id:        playlist.Id,
+		Name:      playlist.Name,
+		Entries:   playlist.Entries,
+		AddedAt:   playlist.AddedAt,
+		UpdatedAt: playlist.UpdatedAt,
+	}
+}
+
+func (w *PlaylistsWf) getPlaylists() (*jobmessages.PlaylistsResult, error) {
+	playlists, err := w.database.GetPlaylists()
+	if err != nil {
+		return nil, errors.New("failed to get playlists")
+	}
+
+	result := &jobmessages.PlaylistsResult{
+		Playlists: make([]jobmessages.Playlist, 0, len(playlists)),
+	}
+
+	for i := range playlists {
+		result.Playlists = append(result.Playlists, toPlaylist(&playlists[i]))
+	}
+
+	return result, nil
+}
+
+func (w *PlaylistsWf) getPlaylist(id int64) (*jobmessages.PlaylistResult, error) {
+	playlist, err := w.findPlaylist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fileIds, err := w.database.GetPlaylistEntries(id)
+	if err != nil {
+		return nil, errors.New("failed to get playlist entries")
+	}
+
+	return &jobmessages.PlaylistResult{
+		Playlist: toPlaylist(playlist),
+		FileIds:  fileIds,
+	}, nil
+}
+
+func (w *PlaylistsWf) create(name string) (*jobmessages.CreateResult, error) {
+	name, err := data.NormalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := w.database.InsertPlaylist(name)
+	if errors.Is(err, data.AlreadyExists) {
+		return nil, fmt.Errorf("playlist %q already exists", name)
+	} else if err != nil {
+		return nil, errors.New("failed to create playlist")
+	}
+
+	w.log.Infof("created playlist %v: %v", id, name)
+
+	return &jobmessages.CreateResult{Id: id}, nil
+}
+
+func (w *PlaylistsWf) rename(id int64, name string) error {
+	name, err := data.NormalizeName(name)
+	if err != nil {
+		return err
+	}
+
+	err = w.database.UpdatePlaylistName(id, name)
+	if errors.Is(err, data.NotFound) {
+		return errors.New("playlist is not found")
+	} else if errors.Is(err, data.AlreadyExists) {
+		return fmt.Errorf("playlist %q already exists", name)
+	} else if err != nil {
+		return errors.New("failed to rename playlist")
+	}
+
+	return nil
+}
+
+func (w *PlaylistsWf) delete(id int64) error {
+	err := w.database.DeletePlaylist(id)
+	if errors.Is(err, data.NotFound) {
+		return errors.New("playlist is not found")
+	} else if err != nil {
+		return errors.New("failed to delete playlist")
+	}
+
+	return nil
+}
+
+func (w *PlaylistsWf) addEntries(id int64, fileIds []int64, position *int) error {
+	fileIds, err := w.checkFiles(fileIds)
+	if err != nil {
+		return err
+	}
+
+	entries, err := w.getEntries(id)
+	if err != nil {
+		return err
+	}
+
+	fileIds = slices.DeleteFunc(fileIds, func(fileId int64) bool {
+		return slices.Contains(entries, fileId)
+	})
+
+	at := len(entries)
+	if position != nil {
+		if *position < 0 || *position > len(entries) {
+			return fmt.Errorf("position must be in range [0, %v]", len(entries))
+		}
+		at = *position
+	}
+
+	return w.setEntries(id, slices.Insert(entries, at, fileIds...))
+}
+
+func (w *PlaylistsWf) removeEntries(id int64, fileIds []int64) error {
+	entries, err := w.getEntries(id)
+	if err != nil {
+		return err
+	}
+
+	entries = slices.DeleteFunc(entries, func(fileId int64) bool {
+		return slices.Contains(fileIds, fileId)
+	})
+
+	return w.setEntries(id, entries)
+}
+
+func (w *PlaylistsWf) reorder(id int64, fileIds []int64) error {
+	entries, err := w.getEntries(id)
+	if err != nil {
+		return err
+	}
+
+	sortedEntries := slices.Sorted(slices.Values(entries))
+	sortedFileIds := slices.Sorted(slices.Values(fileIds))
+
+	if !slices.Equal(sortedEntries, sortedFileIds) {
+		return errors.New("new order must contain every playlist entry exactly once")
+	}
+
+	return w.setEntries(id, fileIds)
+}
+
+func (w *PlaylistsWf) findPlaylist(id int64) (*data.Playlist, error) {
+	playlist, err := w.database.GetPlaylist(id)
+	if errors.Is(err, data.NotFound) {
+		return nil, errors.New("playlist is not found")
+	} else if err != nil {
+		return nil, errors.New("failed to get playlist")
+	}
+
+	return playlist, nil
+}
+
+func (w *PlaylistsWf) getEntries(id int64) ([]int64, error) {
+	_, err := w.findPlaylist(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := w.database.GetPlaylistEntries(id)
+	if err != nil {
+		return nil, errors.New("failed to get playlist entries")
+	}
+
+	return entries, nil
+}
+
+func (w *PlaylistsWf) setEntries(id int64, fileIds []int64) error {
+	err := w.database.SetPlaylistEntries(id, fileIds)
+	if errors.Is(err, data.NotFound) {
+		return errors.New("playlist is not found")
+	} else if err != nil {
+		return errors.New("failed to update playlist entries")
+	}
+
+	return nil
+}
+
+// checkFiles makes sure all files exist and returns ids without duplicates
+func (w *PlaylistsWf) checkFiles(fileIds []int64) ([]int64, error) {
+	unique := make([]int64, 0, len(fileIds))
+
+	for _, fileId := range fileIds {
+		if slices.Contains(unique, fileId) {
+			continue
+		}
+
+		_, err := w.database.GetFile(fileId)
+		if errors.Is(err, data.NotFound) {
+			return nil, fmt.Errorf("file %v is not found", fileId)
+		} else if err != nil {
+			return nil, errors.New("failed to get file")
+		}
+
+		unique = append(unique, fileId)
+	}
+
+	return unique, nil
+}
@@ -0,0 +1,117 @@
+package playlists
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/playlists/job_messages"
+	"uv_server/internal/uv_server/config"
+)
+
+func newPlaylistsWf(jobIn chan interface{}, database data.Database) *PlaylistsWf {
+	wf := &PlaylistsWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.config = &config.Config{}
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+	wf.database = database
+
+	return wf
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+type testEntries_TableEntry struct {
+	name    string
+	request interface{}
+	entries []int64
+	// Entries stored by the workflow, nil when nothing has to be stored
+	expected []int64
+	failed   bool
+}
+
+func TestEntries(t *testing.T) {
+	id := ptr(int64(1))
+
+	testData := []testEntries_TableEntry{
+		{
+			name:     "add to the end",
+			request:  &jobmessages.AddEntriesRequest{Id: id, FileIds: []int64{3, 4}},
+			entries:  []int64{1, 2},
+			expected: []int64{1, 2, 3, 4},
+		},
+		{
+			name:     "add at position skipping existing entries",
+			request:  &jobmessages.AddEntriesRequest{Id: id, FileIds: []int64{2, 3, 3}, Position: ptr(0)},
+			entries:  []int64{1, 2},
+			expected: []int64{3, 1, 2},
+		},
+		{
+			name:    "add at invalid position",
+			request: &jobmessages.AddEntriesRequest{Id: id, FileIds: []int64{3}, Position: ptr(3)},
+			entries: []int64{1, 2},
+			failed:  true,
+		},
+		{
+			name:     "remove",
+			request:  &jobmessages.RemoveEntriesRequest{Id: id, FileIds: []int64{1, 5}},
+			entries:  []int64{1, 2, 3},
+			expected: []int64{2, 3},
+		},
+		{
+			name:     "reorder",
+			request:  &jobmessages.ReorderRequest{Id: id, FileIds: []int64{3, 1, 2}},
+			entries:  []int64{1, 2, 3},
+			expected: []int64{3, 1, 2},
+		},
+		{
+			name:    "reorder with missing entry",
+			request: &jobmessages.ReorderRequest{Id: id, FileIds: []int64{3, 1}},
+			entries: []int64{1, 2, 3},
+			failed:  true,
+		},
+		{
+			name:    "reorder with duplicated entry",
+			request: &jobmessages.ReorderRequest{Id: id, FileIds: []int64{3, 1, 1}},
+			entries: []int64{1, 2, 3},
+			failed:  true,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			jobIn := make(chan interface{}, 1)
+
+			database := dmocks.NewMockDatabase(t)
+			database.EXPECT().GetFile(mock.Anything).Return(&data.File{}, nil).Maybe()
+			database.EXPECT().GetPlaylist(*id).Return(&data.Playlist{Id: *id}, nil)
+			database.EXPECT().GetPlaylistEntries(*id).Return(tt.entries, nil)
+			if tt.expected != nil {
+				database.EXPECT().SetPlaylistEntries(*id, tt.expected).Return(nil)
+			}
+
+			wf := newPlaylistsWf(jobIn, database)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			wf.Run(&wg, tt.request)
+
+			msg := <-jobIn
+			if tt.failed {
+				assert.IsType(t, &cjmessages.Error{}, msg)
+			} else {
+				assert.IsType(t, &cjmessages.Done{}, msg)
+			}
+		})
+	}
+}
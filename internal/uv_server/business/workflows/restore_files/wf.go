@@ -0,0 +1,161 @@
+package restorefiles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/restore_files/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RestoreFilesWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+func NewRestoreFilesWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+) *RestoreFilesWf {
+	object := &RestoreFilesWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "RestoreFilesWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+
+	return object
+}
+
+func (w *RestoreFilesWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	failedFiles := []int64{}
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		w.log.Errorf("failed to get settings: %v", err)
+		w.jobIn <- &cjmessages.Error{Reason: "failed to get settings"}
+		return
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+
+	for _, id := range request.Ids {
+		switch w.jobCtx.Err() {
+		case context.DeadlineExceeded:
+			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+			return
+		case context.Canceled:
+			w.jobIn <- &cjmessages.Canceled{}
+			return
+		}
+
+		err := w.restoreFile(storageDir, id)
+		if err != nil {
+			failedFiles = append(failedFiles, id)
+		}
+	}
+
+	if len(failedFiles) != 0 {
+		w.jobIn <- &jobmessages.Error{FailedIds: failedFiles}
+		return
+	}
+
+	w.jobIn <- &cjmessages.Done{}
+}
+
+func (w *RestoreFilesWf) restoreFile(storageDir string, id int64) error {
+	log := w.log.WithField("id", id)
+
+	file, err := w.database.GetFile(id)
+	if err != nil {
+		log.Errorf("failed to get file, error is: %v", err)
+		return errors.New("failed to get file from database")
+	}
+
+	if !file.IsTrashed() {
+		err := errors.New("file is not in trash")
+		log.Error(err)
+		return err
+	}
+
+	// Files imported in place are not moved to the trash
+	if file.IsExternal() {
+		file.DeletedAt = sql.NullTime{}
+
+		err = w.database.UpdateFileDeletedAt(file)
+		if err != nil {
+			log.Errorf("failed to restore file, error is: %v", err)
+			return errors.New("failed to restore file in database")
+		}
+
+		return nil
+	}
+
+	src := data.LocateFile(storageDir, file)
+	file.DeletedAt = sql.NullTime{}
+	dst := data.LocateFile(storageDir, file)
+
+	exists, err := w.filesystem.Exists(dst)
+	if err != nil || exists {
+		log.Errorf("original location is not available: %v", dst)
+		return errors.New("original location is taken")
+	}
+
+	err = w.filesystem.MoveFile(src, dst)
+	if err != nil {
+		log.Errorf("failed to move file from trash, error is: %v", err)
+		return errors.New("failed to move file from trash")
+	}
+
+	err = w.database.UpdateFileDeletedAt(file)
+	if err != nil {
+		log.Errorf("failed to restore file, error is: %v", err)
+
+		err = w.filesystem.MoveFile(dst, src)
+		if err != nil {
+			log.Errorf("failed to move file back to trash: %v", err)
+		}
+
+		return errors.New("failed to restore file in database")
+	}
+
+	err = w.filesystem.DeleteDirectory(filepath.Dir(src))
+	if err != nil {
+		log.Warnf("failed to delete trash entry: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package jobmessages
+
+type Request struct {
+	// Every layer is changed when the layer is not set
+	Layer *string `json:"layer"`
+	Level *string `json:"level"`
+}
+
+type Result struct {
+	// Current levels by layer
+	Levels map[string]string `json:"levels"`
+}
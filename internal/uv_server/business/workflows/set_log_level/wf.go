@@ -0,0 +1,72 @@
+package setloglevel
+
+import (
+	"context"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/set_log_level/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type SetLogLevelWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	logLevels data.LogLevels
+}
+
+func NewSetLogLevelWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	logLevels data.LogLevels,
+) *SetLogLevelWf {
+	object := &SetLogLevelWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "SetLogLevelWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.logLevels = logLevels
+
+	return object
+}
+
+// Run changes the log level and reports levels of all layers
+func (w *SetLogLevelWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	layer := ""
+	if request.Layer != nil {
+		layer = *request.Layer
+	}
+
+	err := w.logLevels.SetLevel(layer, *request.Level)
+	if err != nil {
+		w.log.Errorf("failed to set log level: %v", err)
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	w.jobIn <- &jobmessages.Result{Levels: w.logLevels.GetLevels()}
+}
@@ -0,0 +1,92 @@
+package setloglevel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/set_log_level/job_messages"
+)
+
+func run(logLevels *dmocks.MockLogLevels, layer *string, level string) interface{} {
+	jobIn := make(chan interface{}, 1)
+
+	wf := &SetLogLevelWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+	wf.logLevels = logLevels
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	wf.Run(&wg, &jobmessages.Request{Layer: layer, Level: &level})
+
+	return <-jobIn
+}
+
+func TestRun_SingleLayer(t *testing.T) {
+	logLevels := dmocks.NewMockLogLevels(t)
+
+	layer := "data"
+	levels := map[string]string{
+		"application":  "info",
+		"business":     "info",
+		"presentation": "info",
+		"data":         "debug",
+	}
+
+	logLevels.EXPECT().SetLevel("data", "debug").Return(nil)
+	logLevels.EXPECT().GetLevels().Return(levels)
+
+	result := run(logLevels, &layer, "debug")
+
+	assert.Equal(t, &jobmessages.Result{Levels: levels}, result)
+}
+
+func TestRun_AllLayers(t *testing.T) {
+	logLevels := dmocks.NewMockLogLevels(t)
+
+	levels := map[string]string{
+		"application":  "trace",
+		"business":     "trace",
+		"presentation": "trace",
+		"data":         "trace",
+	}
+
+	// Every layer is changed when the layer is not set
+	logLevels.EXPECT().SetLevel("", "trace").Return(nil)
+	logLevels.EXPECT().GetLevels().Return(levels)
+
+	result := run(logLevels, nil, "trace")
+
+	assert.Equal(t, &jobmessages.Result{Levels: levels}, result)
+}
+
+func TestRun_InvalidLayer(t *testing.T) {
+	logLevels := dmocks.NewMockLogLevels(t)
+
+	layer := "storage"
+	logLevels.EXPECT().SetLevel("storage", "debug").
+		Return(errors.New(`unknown layer "storage"`))
+
+	result := run(logLevels, &layer, "debug")
+
+	assert.Equal(t, &cjmessages.Error{Reason: `unknown layer "storage"`}, result)
+}
+
+func TestRun_InvalidLevel(t *testing.T) {
+	logLevels := dmocks.NewMockLogLevels(t)
+
+	logLevels.EXPECT().SetLevel("", "verbose").
+		Return(errors.New(`not a valid logrus Level: "verbose"`))
+
+	result := run(logLevels, nil, "verbose")
+
+	assert.Equal(t, &cjmessages.Error{Reason: `not a valid logrus Level: "verbose"`}, result)
+}
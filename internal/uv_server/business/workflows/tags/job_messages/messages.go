@@ -0,0 +1,22 @@
+package jobmessages
+
+type GetTagsRequest struct{}
+
+type TagRequest struct {
+	FileIds []int64  `json:"fileIds"`
+	Tags    []string `json:"tags"`
+}
+
+type UntagRequest struct {
+	FileIds []int64  `json:"fileIds"`
+	Tags    []string `json:"tags"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+}
+
+type TagsResult struct {
+	Tags []Tag `json:"tags"`
+}
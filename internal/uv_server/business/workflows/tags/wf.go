@@ -0,0 +1,168 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/tags/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type TagsWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database data.Database
+}
+
+func NewTagsWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+) *TagsWf {
+	object := &TagsWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "TagsWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+
+	return object
+}
+
+func (w *TagsWf) Run(wg *sync.WaitGroup, request interface{}) {
+	defer wg.Done()
+
+	var result interface{}
+	var err error
+
+	switch tRequest := request.(type) {
+	case *jobmessages.GetTagsRequest:
+		result, err = w.getTags()
+	case *jobmessages.TagRequest:
+		err = w.tag(tRequest.FileIds, tRequest.Tags)
+	case *jobmessages.UntagRequest:
+		err = w.untag(tRequest.FileIds, tRequest.Tags)
+	default:
+		w.log.Fatalf("unknown request: %v", reflect.TypeOf(request))
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	if result == nil {
+		w.jobIn <- &cjmessages.Done{}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func (w *TagsWf) getTags() (*jobmessages.TagsResult, error) {
+	tags, err := w.database.GetTags()
+	if err != nil {
+		return nil, errors.New("failed to get tags")
+	}
+
+	result := &jobmessages.TagsResult{
+		Tags: make([]jobmessages.Tag, 0, len(tags)),
+	}
+
+	for _, tag := range tags {
+		result.Tags = append(result.Tags, jobmessages.Tag{
+			Name:  tag.Name,
+			Files: tag.Files,
+		})
+	}
+
+	return result, nil
+}
+
+func (w *TagsWf) tag(fileIds []int64, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	for _, fileId := range fileIds {
+		_, err := w.database.GetFile(fileId)
+		if errors.Is(err, data.NotFound) {
+			return fmt.Errorf("file %v is not found", fileId)
+		} else if err != nil {
+			return errors.New("failed to get file")
+		}
+	}
+
+	err = w.database.TagFiles(fileIds, tags)
+	if err != nil {
+		return errors.New("failed to tag files")
+	}
+
+	return nil
+}
+
+func (w *TagsWf) untag(fileIds []int64, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	err = w.database.UntagFiles(fileIds, tags)
+	if err != nil {
+		return errors.New("failed to untag files")
+	}
+
+	return nil
+}
+
+// normalizeTags validates tag names and removes duplicates,
+// tags are compared case insensitively
+func normalizeTags(tags []string) ([]string, error) {
+	result := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag, err := data.NormalizeName(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag: %w", err)
+		}
+
+		duplicate := slices.ContainsFunc(result, func(other string) bool {
+			return strings.EqualFold(tag, other)
+		})
+
+		if !duplicate {
+			result = append(result, tag)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package jobmessages
+
+import "uv_server/internal/uv_server/business/data"
+
+// Request describes a partial update, fields which are not set
+// keep their current values
+type Request struct {
+	StorageDir             *string `json:"storage_dir"`
+	MaxConcurrentDownloads *int    `json:"max_concurrent_downloads"`
+	DefaultFormat          *string `json:"default_format"`
+	FilenameTemplate       *string `json:"filename_template"`
+	BandwidthLimit         *int64  `json:"bandwidth_limit"`
+	DownloadBandwidthLimit *int64  `json:"download_bandwidth_limit"`
+	DownloadSchedule       *string `json:"download_schedule"`
+	TrashRetentionDays     *int    `json:"trash_retention_days"`
+
+	MoveFiles bool `json:"move_files"`
+}
+
+func (r *Request) IsEmpty() bool {
+	return r.StorageDir == nil &&
+		r.MaxConcurrentDownloads == nil &&
+		r.DefaultFormat == nil &&
+		r.FilenameTemplate == nil &&
+		r.BandwidthLimit == nil &&
+		r.DownloadBandwidthLimit == nil &&
+		r.DownloadSchedule == nil &&
+		r.TrashRetentionDays == nil
+}
+
+func (r *Request) Apply(settings *data.Settings) {
+	if r.StorageDir != nil {
+		settings.StorageDir = *r.StorageDir
+	}
+
+	if r.MaxConcurrentDownloads != nil {
+		settings.MaxConcurrentDownloads = *r.MaxConcurrentDownloads
+	}
+
+	if r.DefaultFormat != nil {
+		settings.DefaultFormat = *r.DefaultFormat
+	}
+
+	if r.FilenameTemplate != nil {
+		settings.FilenameTemplate = *r.FilenameTemplate
+	}
+
+	if r.BandwidthLimit != nil {
+		settings.BandwidthLimit = *r.BandwidthLimit
+	}
+
+	if r.DownloadBandwidthLimit != nil {
+		settings.DownloadBandwidthLimit = *r.DownloadBandwidthLimit
+	}
+
+	if r.DownloadSchedule != nil {
+		settings.DownloadSchedule = *r.DownloadSchedule
+	}
+
+	if r.TrashRetentionDays != nil {
+		settings.TrashRetentionDays = *r.TrashRetentionDays
+	}
+}
+
+type Progress struct {
+	Moved      int     `json:"moved"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
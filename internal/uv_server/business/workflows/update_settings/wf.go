@@ -2,9 +2,12 @@ package updatesettings
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
 	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/update_settings/job_messages"
 	"uv_server/internal/uv_server/common/loggers"
 	"uv_server/internal/uv_server/config"
 
@@ -20,7 +23,13 @@ type UpdateSettingsWf struct {
 	jobCtx context.Context
 	jobIn  chan<- interface{}
 
-	database data.Database
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+type movedFile struct {
+	src string
+	dst string
 }
 
 func NewUpdateSettingsWf(
@@ -30,6 +39,7 @@ func NewUpdateSettingsWf(
 	jobIn chan<- interface{},
 	job_out <-chan interface{},
 	database data.Database,
+	filesystem data.Filesystem,
 ) *UpdateSettingsWf {
 	object := &UpdateSettingsWf{}
 	object.uuid = uuid
@@ -44,30 +54,136 @@ func NewUpdateSettingsWf(
 	object.jobIn = jobIn
 	_ = job_out
 	object.database = database
+	object.filesystem = filesystem
 	return object
 }
 
-func (w *UpdateSettingsWf) Run(wg *sync.WaitGroup, request *data.Settings) {
+func (w *UpdateSettingsWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
 	defer wg.Done()
-	result, err := w.database.UpdateSettings(request)
 
-	select {
-	case <-w.jobCtx.Done():
-		w.log.Debugf("workflow cancelled: %v", w.jobCtx.Err().Error())
+	current, err := w.database.GetSettings()
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
 
-		switch w.jobCtx.Err() {
-		case context.DeadlineExceeded:
-			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
-		case context.Canceled:
+	settings := *current
+	request.Apply(&settings)
+
+	err = settings.Validate()
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	newStorageDir := w.config.ResolvePath(settings.StorageDir)
+
+	err = w.filesystem.ValidateDirectory(newStorageDir)
+	if err != nil {
+		w.log.Errorf("storage directory validation failed: %v", err)
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	oldStorageDir := w.config.ResolvePath(current.StorageDir)
+
+	var moved []movedFile
+
+	if request.MoveFiles && oldStorageDir != newStorageDir {
+		moved, err = w.moveFiles(oldStorageDir, newStorageDir)
+
+		if errors.Is(err, context.Canceled) {
 			w.jobIn <- &cjmessages.Canceled{}
+			return
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+			return
+		}
+
+		if err != nil {
+			w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+			return
 		}
-	default:
 	}
 
+	result, err := w.database.UpdateSettings(&settings)
 	if err != nil {
+		// Files are put back, since the storage directory is not changed
+		w.rollback(moved)
 		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
 		return
 	}
 
 	w.jobIn <- result
 }
+
+// moveFiles returns the moved files, they are moved back when it fails
+func (w *UpdateSettingsWf) moveFiles(
+	oldStorageDir string,
+	newStorageDir string,
+) ([]movedFile, error) {
+	w.log.Infof("moving files from %v to %v", oldStorageDir, newStorageDir)
+
+	files, err := w.database.GetFilesByStatus(data.FsFinished)
+	if err != nil {
+		w.log.Errorf("failed to get files: %v", err)
+		return nil, errors.New("failed to get files")
+	}
+
+	moved := make([]movedFile, 0, len(files))
+	total := len(files)
+
+	w.jobIn <- &jobmessages.Progress{Moved: 0, Total: total, Percentage: 0}
+
+	for i, file := range files {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			w.rollback(moved)
+			return nil, err
+		}
+
+		// Files imported in place live outside the storage
+		if !file.Path.Valid || file.IsExternal() {
+			continue
+		}
+
+		src := data.LocateFile(oldStorageDir, &file)
+		dst := data.LocateFile(newStorageDir, &file)
+
+		err := w.filesystem.MoveFile(src, dst)
+		if err != nil {
+			w.log.WithField("id", file.Id).Errorf("failed to move file: %v", err)
+			w.rollback(moved)
+			return nil, fmt.Errorf("failed to move file with id %v", file.Id)
+		}
+
+		moved = append(moved, movedFile{src: src, dst: dst})
+
+		w.jobIn <- &jobmessages.Progress{
+			Moved:      i + 1,
+			Total:      total,
+			Percentage: float64(i+1) / float64(total) * 100,
+		}
+	}
+
+	return moved, nil
+}
+
+func (w *UpdateSettingsWf) rollback(moved []movedFile) {
+	if len(moved) == 0 {
+		return
+	}
+
+	w.log.Infof("rolling back %v moved files", len(moved))
+
+	for i := len(moved) - 1; i >= 0; i-- {
+		err := w.filesystem.MoveFile(moved[i].dst, moved[i].src)
+		if err != nil {
+			w.log.Errorf(
+				"failed to move %v back to %v: %v",
+				moved[i].dst, moved[i].src, err)
+		}
+	}
+}
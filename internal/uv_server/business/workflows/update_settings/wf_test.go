@@ -0,0 +1,67 @@
+package updatesettings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	jobmessages "uv_server/internal/uv_server/business/workflows/update_settings/job_messages"
+	"uv_server/internal/uv_server/config"
+)
+
+func TestRun_SettingsWriteFailedRollsBack(t *testing.T) {
+	database := dmocks.NewMockDatabase(t)
+	filesystem := dmocks.NewMockFilesystem(t)
+
+	jobIn := make(chan interface{}, 10)
+
+	wf := &UpdateSettingsWf{}
+	wf.log = logrus.New().WithField("layer", "Business")
+	wf.config = &config.Config{HomeDir: "/home"}
+	wf.jobCtx = context.Background()
+	wf.jobIn = jobIn
+	wf.database = database
+	wf.filesystem = filesystem
+
+	database.EXPECT().GetSettings().Return(data.DefaultSettings(), nil)
+	filesystem.EXPECT().ValidateDirectory("/home/new").Return(nil)
+	database.EXPECT().GetFilesByStatus(data.FsFinished).Return([]data.File{
+		{Id: 1, Path: sql.NullString{String: "song.mp3", Valid: true}},
+		// Imported in place, it is not moved
+		{Id: 2, Path: sql.NullString{String: "/music/song.mp3", Valid: true}},
+	}, nil)
+	filesystem.EXPECT().MoveFile("/home/storage/song.mp3", "/home/new/song.mp3").Return(nil)
+	database.EXPECT().UpdateSettings(
+		&data.Settings{
+			StorageDir:             "new",
+			MaxConcurrentDownloads: 3,
+			DefaultFormat:          "mp3",
+			FilenameTemplate:       "{title}",
+			TrashRetentionDays:     30,
+		}).Return(nil, errors.New("failed to update settings"))
+	filesystem.EXPECT().MoveFile("/home/new/song.mp3", "/home/storage/song.mp3").Return(nil)
+
+	storageDir := "new"
+	request := &jobmessages.Request{StorageDir: &storageDir, MoveFiles: true}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	wf.Run(wg, request)
+	wg.Wait()
+	close(jobIn)
+
+	var last interface{}
+	for msg := range jobIn {
+		last = msg
+	}
+
+	assert.Equal(t, &cjmessages.Error{Reason: "failed to update settings"}, last)
+}
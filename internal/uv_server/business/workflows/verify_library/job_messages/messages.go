@@ -0,0 +1,18 @@
+package jobmessages
+
+type Request struct {
+	Repair bool `json:"repair"`
+}
+
+type Progress struct {
+	Checked    int     `json:"checked"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+type Result struct {
+	Missing  []int64  `json:"missing"`
+	Modified []int64  `json:"modified"`
+	Orphaned []string `json:"orphaned"`
+	Repaired bool     `json:"repaired"`
+}
@@ -0,0 +1,203 @@
+package verifylibrary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"sync"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/business/data"
+	jobmessages "uv_server/internal/uv_server/business/workflows/verify_library/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type VerifyLibraryWf struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	jobCtx context.Context
+	jobIn  chan<- interface{}
+
+	database   data.Database
+	filesystem data.Filesystem
+}
+
+func NewVerifyLibraryWf(
+	uuid string,
+	config *config.Config,
+	jobCtx context.Context,
+	jobIn chan<- interface{},
+	job_out <-chan interface{},
+	database data.Database,
+	filesystem data.Filesystem,
+) *VerifyLibraryWf {
+	object := &VerifyLibraryWf{}
+
+	object.uuid = uuid
+	object.log = loggers.BusinessLogger.WithFields(
+		logrus.Fields{
+			"component": "VerifyLibraryWf",
+			"uuid":      uuid},
+	)
+	object.config = config
+
+	object.jobCtx = jobCtx
+
+	object.jobIn = jobIn
+	_ = job_out
+
+	object.database = database
+	object.filesystem = filesystem
+
+	return object
+}
+
+func (w *VerifyLibraryWf) Run(wg *sync.WaitGroup, request *jobmessages.Request) {
+	defer wg.Done()
+
+	result, err := w.verify(request.Repair)
+
+	if errors.Is(err, context.Canceled) {
+		w.jobIn <- &cjmessages.Canceled{}
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		w.jobIn <- &cjmessages.Error{Reason: "Timeout exceeded"}
+		return
+	}
+
+	if err != nil {
+		w.jobIn <- &cjmessages.Error{Reason: err.Error()}
+		return
+	}
+
+	w.jobIn <- result
+}
+
+func (w *VerifyLibraryWf) verify(repair bool) (*jobmessages.Result, error) {
+	result := &jobmessages.Result{
+		Missing:  []int64{},
+		Modified: []int64{},
+		Orphaned: []string{},
+	}
+
+	settings, err := w.database.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	storageDir := w.config.ResolvePath(settings.StorageDir)
+
+	files, err := w.database.GetFilesByStatus(data.FsFinished)
+	if err != nil {
+		w.log.Errorf("failed to get files: %v", err)
+		return nil, errors.New("failed to get files")
+	}
+
+	known := make(map[string]bool, len(files))
+	total := len(files)
+
+	w.jobIn <- &jobmessages.Progress{Checked: 0, Total: total, Percentage: 0}
+
+	for i, file := range files {
+		if err := w.jobCtx.Err(); err != nil {
+			w.log.Debugf("workflow cancelled: %v", err)
+			return nil, err
+		}
+
+		// Trashed files are not a part of the library
+		if file.Path.Valid && !file.IsTrashed() {
+			known[file.Path.String] = true
+
+			err := w.verifyFile(storageDir, &file, repair, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		w.jobIn <- &jobmessages.Progress{
+			Checked:    i + 1,
+			Total:      total,
+			Percentage: float64(i+1) / float64(total) * 100,
+		}
+	}
+
+	stored, err := w.filesystem.ListFiles(storageDir)
+	if err != nil {
+		w.log.Errorf("failed to list storage directory: %v", err)
+		return nil, errors.New("failed to list storage directory")
+	}
+
+	for _, path := range stored {
+		if !known[path] {
+			result.Orphaned = append(result.Orphaned, path)
+		}
+	}
+
+	result.Repaired = repair
+
+	return result, nil
+}
+
+func (w *VerifyLibraryWf) verifyFile(
+	storageDir string,
+	file *data.File,
+	repair bool,
+	result *jobmessages.Result,
+) error {
+	log := w.log.WithField("id", file.Id)
+
+	digest, err := w.filesystem.HashFile(
+		data.ResolveFilePath(storageDir, file.Path.String))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("file is missing: %v", file.Path.String)
+		result.Missing = append(result.Missing, file.Id)
+
+		if repair {
+			err := w.database.DeleteFile(&data.File{Id: file.Id})
+			if err != nil {
+				log.Errorf("failed to delete file: %v", err)
+				return errors.New("failed to delete file from database")
+			}
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		log.Errorf("failed to hash file: %v", err)
+		return errors.New("failed to read file")
+	}
+
+	// Files downloaded before checksums were introduced have no digest
+	// to compare with, the digest is recorded during repair
+	modified := file.Checksum.Valid &&
+		(file.Checksum.String != digest.Checksum || file.Size.Int64 != digest.Size)
+
+	if modified {
+		log.Warnf("file is modified: %v", file.Path.String)
+		result.Modified = append(result.Modified, file.Id)
+	}
+
+	if repair && (modified || !file.Checksum.Valid) {
+		err := w.database.UpdateFileDigest(&data.File{
+			Id:       file.Id,
+			Size:     sql.NullInt64{Int64: digest.Size, Valid: true},
+			Checksum: sql.NullString{String: digest.Checksum, Valid: true},
+		})
+		if err != nil {
+			log.Errorf("failed to update file digest: %v", err)
+			return errors.New("failed to update file digest")
+		}
+	}
+
+	return nil
+}
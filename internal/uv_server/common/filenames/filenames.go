@@ -0,0 +1,167 @@
+package filenames
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	PhTitle    = "title"
+	PhUploader = "uploader"
+	PhId       = "id"
+	PhDate     = "date"
+	PhSource   = "source"
+)
+
+var placeholders = []string{PhTitle, PhUploader, PhId, PhDate, PhSource}
+
+const maxComponentLength = 200
+
+var placeholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+var forbiddenCharsRegex = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+var reservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+type Metadata struct {
+	Title    string
+	Uploader string
+	Id       string
+	// YYYYMMDD, as reported by the downloader
+	Date   string
+	Source string
+}
+
+func (m *Metadata) value(placeholder string) string {
+	switch placeholder {
+	case PhTitle:
+		return m.Title
+	case PhUploader:
+		return m.Uploader
+	case PhId:
+		return m.Id
+	case PhDate:
+		return m.Date
+	case PhSource:
+		return m.Source
+	default:
+		return ""
+	}
+}
+
+// ValidateTemplate checks that the template uses only known placeholders
+// and describes a relative path which does not escape the storage
+func ValidateTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+
+	if filepath.IsAbs(template) || strings.HasPrefix(template, "/") {
+		return fmt.Errorf("template must describe a relative path")
+	}
+
+	stripped := placeholderRegex.ReplaceAllString(template, "")
+	if strings.ContainsAny(stripped, "{}") {
+		return fmt.Errorf("template contains unbalanced braces")
+	}
+
+	for _, match := range placeholderRegex.FindAllStringSubmatch(template, -1) {
+		if !slices.Contains(placeholders, match[1]) {
+			return fmt.Errorf(
+				"unknown placeholder {%v}, allowed: %v",
+				match[1],
+				strings.Join(placeholders, ", "))
+		}
+	}
+
+	for _, component := range splitComponents(template) {
+		if component == ".." || component == "." {
+			return fmt.Errorf("template must not contain relative path components")
+		}
+	}
+
+	return nil
+}
+
+// Render builds a relative slash separated path from the template,
+// every path component is sanitized separately
+func Render(template string, metadata *Metadata) (string, error) {
+	err := ValidateTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	components := []string{}
+
+	for _, component := range splitComponents(template) {
+		rendered := placeholderRegex.ReplaceAllStringFunc(
+			component,
+			func(match string) string {
+				return metadata.value(match[1 : len(match)-1])
+			})
+
+		components = append(components, Sanitize(rendered))
+	}
+
+	return strings.Join(components, "/"), nil
+}
+
+// Sanitize makes a single path component safe for any supported filesystem
+func Sanitize(component string) string {
+	result := forbiddenCharsRegex.ReplaceAllString(component, "_")
+	result = strings.TrimSpace(result)
+	result = strings.TrimRight(result, ". ")
+
+	if len(result) > maxComponentLength {
+		result = result[:maxComponentLength]
+		for !utf8.ValidString(result) {
+			result = result[:len(result)-1]
+		}
+		result = strings.TrimRight(result, ". ")
+	}
+
+	base := strings.ToUpper(strings.Split(result, ".")[0])
+	if slices.Contains(reservedNames, base) {
+		result = "_" + result
+	}
+
+	if result == "" {
+		return "_"
+	}
+
+	return result
+}
+
+// Deduplicate appends " (N)" suffix to the file name until
+// exists reports that the path is free
+func Deduplicate(relPath string, exists func(relPath string) bool) string {
+	if !exists(relPath) {
+		return relPath
+	}
+
+	dir, file := path.Split(relPath)
+	ext := path.Ext(file)
+	name := strings.TrimSuffix(file, ext)
+
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%v%v (%v)%v", dir, name, i, ext)
+		if !exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+func splitComponents(template string) []string {
+	return strings.FieldsFunc(template, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+}
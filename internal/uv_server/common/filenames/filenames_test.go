@@ -0,0 +1,85 @@
+package filenames
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRender_TableEntry struct {
+	template string
+	result   string
+	err      bool
+}
+
+func TestRender(t *testing.T) {
+	metadata := &Metadata{
+		Title:    "Song: Part 1/2?",
+		Uploader: "Some Band",
+		Id:       "2AB3_l0iqSk",
+		Date:     "20240101",
+		Source:   "yt",
+	}
+
+	testData := []testRender_TableEntry{
+		{template: "{title}", result: "Song_ Part 1_2_"},
+		{template: "{uploader}/{title} [{id}]", result: "Some Band/Song_ Part 1_2_ [2AB3_l0iqSk]"},
+		{template: "{source}\\{date}-{id}", result: "yt/20240101-2AB3_l0iqSk"},
+		{template: "{unknown}", err: true},
+		{template: "{title", err: true},
+		{template: "../{title}", err: true},
+		{template: "/{title}", err: true},
+		{template: "", err: true},
+	}
+
+	for _, entry := range testData {
+		result, err := Render(entry.template, metadata)
+
+		if entry.err {
+			assert.NotNil(t, err, "template %q should be rejected", entry.template)
+			continue
+		}
+
+		assert.Nil(t, err)
+		assert.Equal(t, entry.result, result)
+	}
+}
+
+type testSanitize_TableEntry struct {
+	component string
+	result    string
+}
+
+func TestSanitize(t *testing.T) {
+	testData := []testSanitize_TableEntry{
+		{component: "plain name", result: "plain name"},
+		{component: "a<b>c:d\"e|f?g*h", result: "a_b_c_d_e_f_g_h"},
+		{component: "  trailing dots... ", result: "trailing dots"},
+		{component: "CON", result: "_CON"},
+		{component: "nul.txt", result: "_nul.txt"},
+		{component: "", result: "_"},
+		{component: "...", result: "_"},
+	}
+
+	for _, entry := range testData {
+		assert.Equal(t, entry.result, Sanitize(entry.component))
+	}
+
+	long := strings.Repeat("я", 150)
+	sanitized := Sanitize(long)
+	assert.LessOrEqual(t, len(sanitized), maxComponentLength)
+	assert.True(t, strings.HasPrefix(long, sanitized))
+}
+
+func TestDeduplicate(t *testing.T) {
+	taken := map[string]bool{
+		"band/song.mp3":     true,
+		"band/song (1).mp3": true,
+	}
+
+	exists := func(relPath string) bool { return taken[relPath] }
+
+	assert.Equal(t, "band/other.mp3", Deduplicate("band/other.mp3", exists))
+	assert.Equal(t, "band/song (2).mp3", Deduplicate("band/song.mp3", exists))
+}
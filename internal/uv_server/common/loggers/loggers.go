@@ -1,17 +1,54 @@
 package loggers
 
 import (
-	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+type Layer string
+
+const (
+	Application  Layer = "application"
+	Business     Layer = "business"
+	Presentation Layer = "presentation"
+	Data         Layer = "data"
+)
+
+var Layers = []Layer{Application, Business, Presentation, Data}
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+// Options describe where and how logs are written, zero rotation
+// limits keep the lumberjack defaults
+type Options struct {
+	Directory string
+	FileName  string
+	// FormatText or FormatJson
+	Format string
+	// Logs are duplicated to stdout
+	Console bool
+
+	Levels map[Layer]logrus.Level
+
+	MaxSizeMb  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+}
+
 var writer *ThreadsafeWriter
-var logFile *os.File
+var logFile *lumberjack.Logger
+
+var layerLoggers map[Layer]*logrus.Logger
 
 var applicationLogger *logrus.Logger
 var ApplicationLogger *logrus.Entry
@@ -30,7 +67,7 @@ type ThreadsafeWriter struct {
 	mutex   *sync.Mutex
 }
 
-func (w ThreadsafeWriter) Write(p []byte) (n int, err error) {
+func (w *ThreadsafeWriter) Write(p []byte) (n int, err error) {
 	w.mutex.Lock()
 	for _, writer := range w.writers {
 		n, err = writer.Write(p)
@@ -39,56 +76,105 @@ func (w ThreadsafeWriter) Write(p []byte) (n int, err error) {
 	return
 }
 
-func Init(logDirectory string, logFile string) {
+func Init(options *Options) {
 	log := logrus.New()
 
-	level := logrus.TraceLevel
-	log.SetLevel(level)
-
-	if _, err := os.Stat(logDirectory); errors.Is(err, os.ErrNotExist) {
-		err := os.Mkdir(logDirectory, os.ModePerm)
-		if err != nil {
-			log.Fatalf("Error creating directory: %v", err)
-		}
+	err := os.MkdirAll(options.Directory, os.ModePerm)
+	if err != nil {
+		log.Fatalf("Error creating directory: %v", err)
 	}
 
-	file, err := os.OpenFile(filepath.Join(logDirectory, logFile),
-		os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	// The file is rotated once it grows over the max size, old files
+	// are removed by age and count
+	logFile = &lumberjack.Logger{
+		Filename:   filepath.Join(options.Directory, options.FileName),
+		MaxSize:    options.MaxSizeMb,
+		MaxAge:     options.MaxAgeDays,
+		MaxBackups: options.MaxBackups,
+		Compress:   options.Compress,
+		LocalTime:  true,
+	}
 
-	if err != nil {
-		log.Fatalf("Error opening file: %v", err)
+	writers := []io.Writer{logFile}
+	if options.Console {
+		writers = append(writers, os.Stdout)
 	}
 
 	writer = &ThreadsafeWriter{
-		writers: []io.Writer{file, os.Stdout},
+		writers: writers,
 		mutex:   &sync.Mutex{},
 	}
 
-	applicationLogger = logrus.New()
-	applicationLogger.SetLevel(level)
-	applicationLogger.SetOutput(writer)
-	applicationLogger.SetNoLock()
+	var formatter logrus.Formatter = &logrus.TextFormatter{}
+	if options.Format == FormatJson {
+		formatter = &logrus.JSONFormatter{}
+	}
+
+	newLogger := func(layer Layer) *logrus.Logger {
+		logger := logrus.New()
+		logger.SetLevel(options.Levels[layer])
+		logger.SetFormatter(formatter)
+		logger.SetOutput(writer)
+		logger.SetNoLock()
+		return logger
+	}
+
+	applicationLogger = newLogger(Application)
 	ApplicationLogger = applicationLogger.WithField("layer", "Application")
 
-	businessLogger = logrus.New()
-	businessLogger.SetLevel(level)
-	businessLogger.SetOutput(writer)
-	businessLogger.SetNoLock()
+	businessLogger = newLogger(Business)
 	BusinessLogger = businessLogger.WithField("layer", "Business")
 
-	presentationLogger = logrus.New()
-	presentationLogger.SetLevel(level)
-	presentationLogger.SetOutput(writer)
-	presentationLogger.SetNoLock()
+	presentationLogger = newLogger(Presentation)
 	PresentationLogger = presentationLogger.WithField("layer", "Presentation")
 
-	dataLogger = logrus.New()
-	dataLogger.SetLevel(level)
-	dataLogger.SetOutput(writer)
-	dataLogger.SetNoLock()
+	dataLogger = newLogger(Data)
 	DataLogger = dataLogger.WithField("layer", "Data")
+
+	layerLoggers = map[Layer]*logrus.Logger{
+		Application:  applicationLogger,
+		Business:     businessLogger,
+		Presentation: presentationLogger,
+		Data:         dataLogger,
+	}
 }
 
+func ParseLayer(name string) (Layer, error) {
+	for _, layer := range Layers {
+		if string(layer) == name {
+			return layer, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown layer %q", name)
+}
+
+// SetLevel changes verbosity of the layer at runtime
+func SetLevel(layer Layer, level logrus.Level) {
+	layerLoggers[layer].SetLevel(level)
+}
+
+func GetLevels() map[Layer]logrus.Level {
+	levels := make(map[Layer]logrus.Level, len(layerLoggers))
+	for layer, logger := range layerLoggers {
+		levels[layer] = logger.GetLevel()
+	}
+
+	return levels
+}
+
+// DisableConsoleOutput keeps writing logs only into the log file, so they
+// do not mix with the output of command line tools
+func DisableConsoleOutput() {
+	writer.mutex.Lock()
+	writer.writers = writer.writers[:1]
+	writer.mutex.Unlock()
+}
+
+// CloseLogFile flushes the log file to disk and closes it
 func CloseLogFile() {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
 	logFile.Close()
 }
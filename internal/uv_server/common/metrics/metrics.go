@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "uv_server"
+
+// Job outcomes
+const (
+	JobDone     = "done"
+	JobError    = "error"
+	JobCanceled = "canceled"
+	// The request was refused before the workflow started
+	JobRejected = "rejected"
+)
+
+// Downloader failure reasons
+const (
+	// The downloader reported the failure itself
+	FailureReported = "reported"
+	// The downloader output could not be understood
+	FailureProtocol = "protocol"
+	// The downloaded file could not be moved into the storage
+	FailureFinalize = "finalize"
+)
+
+// Registry keeps the server metrics apart from the global registry,
+// so only the metrics below and the runtime ones are exposed
+var Registry = prometheus.NewRegistry()
+
+var (
+	SessionsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sessions_active",
+		Help:      "Number of connected clients.",
+	})
+
+	JobsRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "jobs_running",
+		Help:      "Number of running jobs by request type.",
+	}, []string{"type"})
+
+	JobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Number of finished jobs by request type and outcome.",
+	}, []string{"type", "state"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of jobs by request type.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"type"})
+
+	DownloadsQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "downloads_queued",
+		Help:      "Number of downloads waiting for a slot or the download schedule.",
+	})
+
+	DownloadedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "Size of successfully downloaded files.",
+	})
+
+	DownloadThroughput = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "download_throughput_bytes_per_second",
+		Help:      "Average speed of successful downloads.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 2, 12),
+	})
+
+	DownloaderFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloader_failures_total",
+		Help:      "Number of failed downloads by reason.",
+	}, []string{"reason"})
+
+	DbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database queries by Database method.",
+		Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+	}, []string{"query"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SessionsActive,
+		JobsRunning,
+		JobsTotal,
+		JobDuration,
+		DownloadsQueued,
+		DownloadedBytes,
+		DownloadThroughput,
+		DownloaderFailures,
+		DbQueryDuration,
+	)
+}
+
+// RegisterCleanQueue exposes the number of paths waiting for the file
+// cleaner, it is registered once the queue is created
+func RegisterCleanQueue(to_clean chan string) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "clean_queue_depth",
+		Help:      "Number of paths waiting to be cleaned up.",
+	}, func() float64 {
+		return float64(len(to_clean))
+	}))
+}
@@ -0,0 +1,219 @@
+// Package schedule parses windows of time during which downloads
+// are allowed to start, for example "mon-fri 18:00-08:00, sat-sun 00:00-24:00"
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Window starts on the listed days, a window ending before it starts
+// lasts until the next day
+type Window struct {
+	days [7]bool
+	// Minutes since midnight
+	start int
+	end   int
+}
+
+// Schedule is a set of windows, an empty schedule allows any time
+type Schedule []Window
+
+// Parse reads comma separated windows "[days ]HH:MM-HH:MM", days are
+// a day or a range of days like "mon-fri", every day when omitted
+func Parse(s string) (Schedule, error) {
+	schedule := Schedule{}
+
+	if strings.TrimSpace(s) == "" {
+		return schedule, nil
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		window, err := parseWindow(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q: %w", item, err)
+		}
+
+		schedule = append(schedule, window)
+	}
+
+	return schedule, nil
+}
+
+func parseWindow(s string) (Window, error) {
+	window := Window{}
+
+	fields := strings.Fields(s)
+
+	var times string
+	switch len(fields) {
+	case 1:
+		for i := range window.days {
+			window.days[i] = true
+		}
+		times = fields[0]
+	case 2:
+		err := window.parseDays(fields[0])
+		if err != nil {
+			return window, err
+		}
+		times = fields[1]
+	default:
+		return window, fmt.Errorf("expected \"[days ]HH:MM-HH:MM\"")
+	}
+
+	start, end, found := strings.Cut(times, "-")
+	if !found {
+		return window, fmt.Errorf("expected \"HH:MM-HH:MM\", got %q", times)
+	}
+
+	var err error
+
+	window.start, err = parseTime(start)
+	if err != nil {
+		return window, err
+	}
+
+	window.end, err = parseTime(end)
+	if err != nil {
+		return window, err
+	}
+
+	if window.start == window.end {
+		return window, fmt.Errorf("window is empty")
+	}
+
+	if window.start == minutesPerDay {
+		return window, fmt.Errorf("window can not start at 24:00")
+	}
+
+	return window, nil
+}
+
+func (w *Window) parseDays(s string) error {
+	first, last, isRange := strings.Cut(s, "-")
+
+	from, err := parseDay(first)
+	if err != nil {
+		return err
+	}
+
+	to := from
+	if isRange {
+		to, err = parseDay(last)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Ranges may wrap around the week, like "sat-mon"
+	for day := from; ; day = (day + 1) % 7 {
+		w.days[day] = true
+		if day == to {
+			break
+		}
+	}
+
+	return nil
+}
+
+func parseDay(s string) (int, error) {
+	for i, name := range dayNames {
+		if strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown day %q, expected one of %v", s, strings.Join(dayNames, ", "))
+}
+
+// parseTime returns minutes since midnight, 24:00 is the end of the day
+func parseTime(s string) (int, error) {
+	hours, minutes, found := strings.Cut(s, ":")
+	if !found || len(hours) != 2 || len(minutes) != 2 {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hours %q", hours)
+	}
+
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return 0, fmt.Errorf("invalid minutes %q", minutes)
+	}
+
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("time is out of range: %q", s)
+	}
+
+	return h*60 + m, nil
+}
+
+// contains checks the time of the day against the window which starts
+// on the weekday of t or on the day before
+func (w Window) contains(t time.Time) bool {
+	day := int(t.Weekday())
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+
+	previous := (day + 6) % 7
+	return (w.days[day] && minute >= w.start) || (w.days[previous] && minute < w.end)
+}
+
+func (s Schedule) Allows(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	for _, window := range s {
+		if window.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextStart returns the earliest time not before t the schedule allows
+func (s Schedule) NextStart(t time.Time) time.Time {
+	if s.Allows(t) {
+		return t
+	}
+
+	var next time.Time
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	// Every window starts within a week
+	for i := range 8 {
+		day := midnight.AddDate(0, 0, i)
+
+		for _, window := range s {
+			if !window.days[day.Weekday()] {
+				continue
+			}
+
+			// Not added as a duration, so the clock time is kept
+			// on days when daylight saving time changes
+			start := time.Date(
+				day.Year(), day.Month(), day.Day(),
+				window.start/60, window.start%60, 0, 0, t.Location())
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+
+	return next
+}
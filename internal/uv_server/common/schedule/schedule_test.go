@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2025-06-02 is a monday
+func at(day int, hour int, minute int) time.Time {
+	return time.Date(2025, 6, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	values := []string{
+		"18:00",
+		"18-20",
+		"8:00-10:00",
+		"10:00-10:00",
+		"24:00-08:00",
+		"10:60-11:00",
+		"25:00-26:00",
+		"weekdays 10:00-11:00",
+		"mon 10:00-11:00 extra",
+		"mon-fri 10:00-11:00,",
+	}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			_, err := Parse(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	schedule, err := Parse("mon-fri 18:00-08:00, sat-sun 00:00-24:00")
+	require.NoError(t, err)
+
+	tests := []struct {
+		time    time.Time
+		allowed bool
+	}{
+		{at(2, 7, 59), false}, // monday morning, sunday window ended at midnight
+		{at(2, 12, 0), false},
+		{at(2, 18, 0), true},
+		{at(3, 7, 59), true}, // window of monday lasts until tuesday
+		{at(3, 8, 0), false},
+		{at(7, 7, 0), true}, // saturday, the window of friday and the weekend one
+		{at(7, 12, 0), true},
+		{at(8, 23, 59), true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, schedule.Allows(test.time), "%v", test.time)
+	}
+}
+
+func TestAllows_Empty(t *testing.T) {
+	schedule, err := Parse(" ")
+	require.NoError(t, err)
+
+	assert.True(t, schedule.Allows(at(2, 12, 0)))
+	assert.Equal(t, at(2, 12, 0), schedule.NextStart(at(2, 12, 0)))
+}
+
+func TestAllows_WrappingDays(t *testing.T) {
+	schedule, err := Parse("Sat-Mon 10:00-12:00")
+	require.NoError(t, err)
+
+	assert.True(t, schedule.Allows(at(2, 11, 0)))
+	assert.False(t, schedule.Allows(at(3, 11, 0)))
+	assert.True(t, schedule.Allows(at(7, 11, 0)))
+	assert.True(t, schedule.Allows(at(8, 11, 0)))
+}
+
+func TestNextStart(t *testing.T) {
+	schedule, err := Parse("mon-fri 18:00-08:00, sun 09:30-10:00")
+	require.NoError(t, err)
+
+	assert.Equal(t, at(2, 18, 0), schedule.NextStart(at(2, 12, 0)))
+	// Friday night window ends on saturday, the next one is on sunday
+	assert.Equal(t, at(8, 9, 30), schedule.NextStart(at(7, 8, 0)))
+	assert.Equal(t, at(3, 7, 0), schedule.NextStart(at(3, 7, 0)))
+}
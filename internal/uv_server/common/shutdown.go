@@ -0,0 +1,7 @@
+package common
+
+import "errors"
+
+// ErrShutdown is the cause of job contexts canceled by a server shutdown,
+// workflows check it with context.Cause to keep their work resumable
+var ErrShutdown = errors.New("server is shutting down")
@@ -0,0 +1,5 @@
+package common
+
+// Version of the server, release builds set it with
+// -ldflags "-X uv_server/internal/uv_server/common.Version=<tag>"
+var Version = "dev"
@@ -1,31 +1,76 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/sirupsen/logrus"
 
+	"uv_server/configs"
 	"uv_server/internal/uv_server/common"
-	"uv_server/internal/uv_server/common/loggers"
 )
 
+// Overrides are command line values taking precedence over the config
+// file, empty values are not applied
+type Overrides struct {
+	HomeDir      string
+	ConfigPath   string
+	DbLocation   string
+	Port         uint16
+	LogsLocation string
+	LogLevel     string
+	AuthToken    string
+}
+
 type Config struct {
 	log *logrus.Entry
 
-	Port int16 `yaml:"port"`
+	Port uint16 `yaml:"port"`
+
+	HomeDir string
+	// Directory of the binary, tools shipped with the server are
+	// resolved against it, so an installation can be replaced
+	// without touching the home directory
+	InstallDir string
 
-	FfmpegLocation     string `yaml:"ffmpegLocation"`
-	ToolsLocation      string
-	ChangesetsLocation string `yaml:"changesetsLocation"`
+	FfmpegLocation string `yaml:"ffmpegLocation"`
+	ToolsLocation  string
+	DbLocation     string `yaml:"dbLocation"`
+	LogsLocation   string `yaml:"logsLocation"`
 
 	AllowClientReconnect bool `yaml:"allowClientReconnect"`
-}
 
-func (config *Config) parse(path string) {
+	// Clients have to present the token to connect, anyone
+	// can connect when it is empty
+	AuthToken string `yaml:"authToken"`
+
+	BackupsLocation     string `yaml:"backupsLocation"`
+	BackupIntervalHours int    `yaml:"backupIntervalHours"`
+	// Applies to scheduled backups only
+	BackupsToKeep int `yaml:"backupsToKeep"`
 
+	// Running jobs are given this time to finish on shutdown
+	// before they are canceled
+	ShutdownGracePeriodSeconds int `yaml:"shutdownGracePeriodSeconds"`
+
+	// Download progress is sent to clients at most once per interval,
+	// changes of the download phase are sent right away
+	ProgressIntervalMs int `yaml:"progressIntervalMs"`
+
+	Logging Logging `yaml:"logging"`
+}
+
+// parse reads the config file, the embedded default config is used
+// when the default config file is not installed
+func (config *Config) parse(path string, explicit bool) {
 	file, err := os.ReadFile(path)
 
-	if err != nil {
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		config.log.Infof("config file %v is not found, using defaults", path)
+		file = configs.Default
+	} else if err != nil {
 		config.log.Fatal(err)
 	}
 
@@ -78,20 +123,167 @@ func (config *Config) validateFfmpegLocation() {
 	}
 }
 
-func NewConfig(path string) *Config {
+func (config *Config) applyBackupDefaults() {
+	if config.BackupsLocation == "" {
+		config.BackupsLocation = "backups"
+	}
+
+	if config.BackupIntervalHours == 0 {
+		config.BackupIntervalHours = 24
+	}
+
+	if config.BackupsToKeep == 0 {
+		config.BackupsToKeep = 7
+	}
+
+	if config.BackupIntervalHours < 0 || config.BackupsToKeep < 0 {
+		config.log.Fatal("backup interval and number of backups must be positive")
+	}
+}
+
+func (config *Config) applyShutdownDefaults() {
+	if config.ShutdownGracePeriodSeconds == 0 {
+		config.ShutdownGracePeriodSeconds = 10
+	}
+
+	if config.ShutdownGracePeriodSeconds < 0 {
+		config.log.Fatal("shutdown grace period must be positive")
+	}
+}
+
+func (config *Config) applyProgressDefaults() {
+	if config.ProgressIntervalMs == 0 {
+		config.ProgressIntervalMs = 1000
+	}
+
+	if config.ProgressIntervalMs < 0 {
+		config.log.Fatal("progress interval must be positive")
+	}
+}
+
+// ResolvePath returns path as is when it is absolute, otherwise
+// it is resolved relative to the home directory
+func (config *Config) ResolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(config.HomeDir, path)
+}
+
+func (config *Config) resolveInstallPath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(config.InstallDir, path)
+}
+
+// defaultConfigPath prefers the config of the home directory
+// over the one installed with the server
+func (config *Config) defaultConfigPath() string {
+	name := filepath.Join("config", "config.yaml")
+
+	path := config.ResolvePath(name)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return config.resolveInstallPath(name)
+	}
+
+	return path
+}
+
+// ExecutableDir returns the directory of the running binary,
+// it is the default home directory
+func ExecutableDir() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(path), nil
+}
+
+func NewConfig(overrides *Overrides) *Config {
 	config := &Config{}
-	config.log = loggers.ApplicationLogger
+	// Loggers are initialized with the logs location from the config,
+	// so errors of the config itself go to stderr
+	config.log = logrus.New().WithField("layer", "Application")
+
+	installDir, err := ExecutableDir()
+	if err != nil {
+		config.log.Fatal(err)
+	}
+	config.InstallDir = installDir
 
-	config.parse(path)
+	config.HomeDir = overrides.HomeDir
+	if config.HomeDir == "" {
+		config.HomeDir = installDir
+	}
+
+	homeDir, err := filepath.Abs(config.HomeDir)
+	if err != nil {
+		config.log.Fatal(err)
+	}
+	config.HomeDir = homeDir
+
+	if overrides.ConfigPath != "" {
+		config.parse(config.ResolvePath(overrides.ConfigPath), true)
+	} else {
+		config.parse(config.defaultConfigPath(), false)
+	}
+
+	config.applyOverrides(overrides)
 
 	if config.Port == 0 {
 		config.log.Fatal("port is not specified")
 	}
 
+	config.FfmpegLocation = config.resolveInstallPath(config.FfmpegLocation)
 	config.validateFfmpegLocation()
 
-	config.ToolsLocation = "tools"
+	config.ToolsLocation = config.resolveInstallPath("tools")
 	config.validateSriptsLocation()
 
+	config.applyBackupDefaults()
+	config.applyShutdownDefaults()
+	config.applyProgressDefaults()
+	config.applyLoggingDefaults()
+
 	return config
 }
+
+func (config *Config) applyOverrides(overrides *Overrides) {
+	if overrides.DbLocation != "" {
+		config.DbLocation = overrides.DbLocation
+	}
+
+	if overrides.Port != 0 {
+		config.Port = overrides.Port
+	}
+
+	if overrides.LogsLocation != "" {
+		config.LogsLocation = overrides.LogsLocation
+	}
+
+	if overrides.AuthToken != "" {
+		config.AuthToken = overrides.AuthToken
+	}
+
+	if overrides.LogLevel != "" {
+		config.Logging.Level = overrides.LogLevel
+		config.Logging.Levels = nil
+	}
+
+	if config.DbLocation == "" {
+		config.DbLocation = "app.db"
+	}
+
+	if config.LogsLocation == "" {
+		config.LogsLocation = "logs"
+	}
+}
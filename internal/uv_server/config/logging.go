@@ -0,0 +1,102 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"uv_server/internal/uv_server/common/loggers"
+)
+
+type Logging struct {
+	// text or json
+	Format string `yaml:"format"`
+	// Logs are duplicated to stdout unless disabled
+	Console *bool `yaml:"console"`
+	// Level of every layer which is not listed in levels
+	Level string `yaml:"level"`
+	// Levels by layer: application, business, presentation or data
+	Levels map[string]string `yaml:"levels"`
+
+	MaxSizeMb  int  `yaml:"maxSizeMb"`
+	MaxAgeDays int  `yaml:"maxAgeDays"`
+	MaxBackups int  `yaml:"maxBackups"`
+	Compress   bool `yaml:"compress"`
+}
+
+func (config *Config) applyLoggingDefaults() {
+	logging := &config.Logging
+
+	if logging.Format == "" {
+		logging.Format = loggers.FormatText
+	}
+
+	if logging.Format != loggers.FormatText && logging.Format != loggers.FormatJson {
+		config.log.Fatalf("unknown log format: %v", logging.Format)
+	}
+
+	if logging.Console == nil {
+		console := true
+		logging.Console = &console
+	}
+
+	if logging.Level == "" {
+		logging.Level = "info"
+	}
+
+	if logging.MaxSizeMb == 0 {
+		logging.MaxSizeMb = 10
+	}
+
+	if logging.MaxAgeDays == 0 {
+		logging.MaxAgeDays = 30
+	}
+
+	if logging.MaxBackups == 0 {
+		logging.MaxBackups = 5
+	}
+
+	if logging.MaxSizeMb < 0 || logging.MaxAgeDays < 0 || logging.MaxBackups < 0 {
+		config.log.Fatal("log rotation limits must be positive")
+	}
+
+	// Levels are validated before the loggers are initialized
+	config.LoggerOptions("")
+}
+
+// LoggerOptions returns options of the loggers writing into the directory
+func (config *Config) LoggerOptions(directory string) *loggers.Options {
+	logging := &config.Logging
+
+	level, err := logrus.ParseLevel(logging.Level)
+	if err != nil {
+		config.log.Fatalf("invalid log level: %v", err)
+	}
+
+	levels := make(map[loggers.Layer]logrus.Level, len(loggers.Layers))
+	for _, layer := range loggers.Layers {
+		levels[layer] = level
+	}
+
+	for name, value := range logging.Levels {
+		layer, err := loggers.ParseLayer(name)
+		if err != nil {
+			config.log.Fatalf("invalid log levels: %v", err)
+		}
+
+		levels[layer], err = logrus.ParseLevel(value)
+		if err != nil {
+			config.log.Fatalf("invalid log level of %v layer: %v", layer, err)
+		}
+	}
+
+	return &loggers.Options{
+		Directory:  directory,
+		FileName:   "log.txt",
+		Format:     logging.Format,
+		Console:    *logging.Console,
+		Levels:     levels,
+		MaxSizeMb:  logging.MaxSizeMb,
+		MaxAgeDays: logging.MaxAgeDays,
+		MaxBackups: logging.MaxBackups,
+		Compress:   logging.Compress,
+	}
+}
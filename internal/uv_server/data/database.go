@@ -8,6 +8,7 @@ import (
 	"time"
 	"uv_server/internal/uv_server/business/data"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 
 	"github.com/sirupsen/logrus"
 
@@ -20,6 +21,43 @@ type Database struct {
 	db  *sql.DB
 }
 
+const fileColumns = `
+		id,
+		"path",
+		source_url,
+		"source",
+		status,
+		"size",
+		checksum,
+		title,
+		artist,
+		duration,
+		deleted_at,
+		added_at,
+		updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFile(row rowScanner, file *data.File) error {
+	return row.Scan(
+		&file.Id,
+		&file.Path,
+		&file.SourceUrl,
+		&file.Source,
+		&file.Status,
+		&file.Size,
+		&file.Checksum,
+		&file.Title,
+		&file.Artist,
+		&file.Duration,
+		&file.DeletedAt,
+		&file.AddedAt,
+		&file.UpdatedAt,
+	)
+}
+
 func NewDatabase(db *sql.DB) *Database {
 	object := &Database{}
 
@@ -30,18 +68,18 @@ func NewDatabase(db *sql.DB) *Database {
 	return object
 }
 
+// observe logs the statement latency and records it by the method name
+func (d *Database) observe(query string, startedAt time.Time) {
+	took := time.Since(startedAt)
+	d.log.Debugf("execution took %v us", took.Microseconds())
+	metrics.DbQueryDuration.WithLabelValues(query).Observe(took.Seconds())
+}
+
 func (d *Database) GetFile(id int64) (*data.File, error) {
 	var file data.File
 
 	statement := `
-	SELECT 
-		id,
-		"path",
-		source_url,
-		"source",
-		status,
-		added_at,
-		updated_at
+	SELECT ` + fileColumns + `
 	FROM files
 		WHERE id=?
 	`
@@ -49,17 +87,9 @@ func (d *Database) GetFile(id int64) (*data.File, error) {
 	d.log.Debugf("executing statement: %v", statement)
 	startedAt := time.Now()
 
-	err := d.db.QueryRow(statement, id).Scan(
-		&file.Id,
-		&file.Path,
-		&file.SourceUrl,
-		&file.Source,
-		&file.Status,
-		&file.AddedAt,
-		&file.UpdatedAt,
-	)
+	err := scanFile(d.db.QueryRow(statement, id), &file)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetFile", startedAt)
 
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, data.NotFound
@@ -73,18 +103,92 @@ func (d *Database) GetFile(id int64) (*data.File, error) {
 	return &file, nil
 }
 
+func (d *Database) GetFilesByStatus(status data.FileStatus) ([]data.File, error) {
+	statement := `
+	SELECT ` + fileColumns + `
+	FROM files
+		WHERE status=?
+	ORDER BY id
+	`
+
+	files, err := d.queryFiles(statement, status)
+	if err != nil {
+		d.log.Errorf("failed to get files by status: %v", err)
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (d *Database) GetLibraryFiles() ([]data.File, error) {
+	statement := `
+	SELECT ` + fileColumns + `
+	FROM files
+		WHERE deleted_at IS NULL
+	ORDER BY id
+	`
+
+	files, err := d.queryFiles(statement)
+	if err != nil {
+		d.log.Errorf("failed to get library files: %v", err)
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (d *Database) GetTrashedFiles(deletedBefore time.Time) ([]data.File, error) {
+	statement := `
+	SELECT ` + fileColumns + `
+	FROM files
+		WHERE deleted_at IS NOT NULL
+			AND deleted_at < ?
+	ORDER BY deleted_at
+	`
+
+	files, err := d.queryFiles(statement, deletedBefore.UTC())
+	if err != nil {
+		d.log.Errorf("failed to get trashed files: %v", err)
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (d *Database) queryFiles(statement string, args ...any) ([]data.File, error) {
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	rows, err := d.db.Query(statement, args...)
+
+	d.observe("queryFiles", startedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []data.File{}
+
+	for rows.Next() {
+		var file data.File
+
+		err = scanFile(rows, &file)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
 func (d *Database) GetFileByUrl(url string) (*data.File, error) {
 	var file data.File
 
 	statement := `
-	SELECT 
-		id,
-		"path",
-		source_url,
-		"source",
-		status,
-		added_at,
-		updated_at
+	SELECT ` + fileColumns + `
 	FROM files
 		WHERE source_url=?
 	`
@@ -92,17 +196,9 @@ func (d *Database) GetFileByUrl(url string) (*data.File, error) {
 	d.log.Debugf("executing statement: %v", statement)
 	startedAt := time.Now()
 
-	err := d.db.QueryRow(statement, url).Scan(
-		&file.Id,
-		&file.Path,
-		&file.SourceUrl,
-		&file.Source,
-		&file.Status,
-		&file.AddedAt,
-		&file.UpdatedAt,
-	)
+	err := scanFile(d.db.QueryRow(statement, url), &file)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetFileByUrl", startedAt)
 
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, data.NotFound
@@ -116,19 +212,55 @@ func (d *Database) GetFileByUrl(url string) (*data.File, error) {
 	return &file, nil
 }
 
+func (d *Database) IsPathTaken(path string) (bool, error) {
+	statement := `
+	SELECT EXISTS (
+		SELECT 1 FROM files
+			WHERE path=?
+	)
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	var taken bool
+	err := d.db.QueryRow(statement, path).Scan(&taken)
+
+	d.observe("IsPathTaken", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to check file path: %v", err)
+		return false, err
+	}
+
+	return taken, nil
+}
+
 func (d *Database) InsertFile(file *data.File) (int64, error) {
 	statement := `
 	INSERT INTO files (
 		path,
 		source_url,
 		source,
-		status
+		status,
+		"size",
+		checksum,
+		title,
+		artist,
+		duration,
+		added_at
 	)
 	VALUES (
 		?,
 		?,
 		?,
-		?
+		?,
+		?,
+		?,
+		?,
+		?,
+		?,
+		COALESCE(?, CURRENT_TIMESTAMP)
 	)
 	RETURNING id
 	`
@@ -141,9 +273,20 @@ func (d *Database) InsertFile(file *data.File) (int64, error) {
 		file.SourceUrl,
 		file.Source,
 		file.Status,
+		file.Size,
+		file.Checksum,
+		file.Title,
+		file.Artist,
+		file.Duration,
+		// Restored files keep the original time
+		sql.NullTime{Time: file.AddedAt, Valid: !file.AddedAt.IsZero()},
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("InsertFile", startedAt)
+
+	if isUniqueViolation(err) {
+		return 0, data.AlreadyExists
+	}
 
 	if err != nil {
 		d.log.Errorf("failed to insert a file: %v", err)
@@ -169,7 +312,7 @@ func (d *Database) UpdateFileStatus(file *data.File) error {
 		file.Id,
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("UpdateFileStatus", startedAt)
 
 	if err != nil {
 		d.log.Errorf("failed to update file status: %v", err)
@@ -179,6 +322,72 @@ func (d *Database) UpdateFileStatus(file *data.File) error {
 	return nil
 }
 
+func (d *Database) ResumeFile(fileId int64) error {
+	// The status is checked by the statement itself, so only one of
+	// concurrent resumes gets the file
+	statement := `
+	UPDATE files
+		SET status = ?
+	WHERE
+		id = ? AND status = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	result, err := d.db.Exec(statement,
+		data.FsDownloading,
+		fileId,
+		data.FsInterrupted,
+	)
+
+	d.observe("ResumeFile", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to resume file: %v", err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return data.NotFound
+	}
+
+	return nil
+}
+
+// InterruptDownloads marks downloads left by a server which was not
+// shut down gracefully as interrupted, so they can be resumed
+func (d *Database) InterruptDownloads() (int64, error) {
+	statement := `
+	UPDATE files
+		SET status = ?
+	WHERE
+		status = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	result, err := d.db.Exec(statement,
+		data.FsInterrupted,
+		data.FsDownloading,
+	)
+
+	d.observe("InterruptDownloads", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to interrupt downloads: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 func (d *Database) UpdateFilePath(file *data.File) error {
 	statement := `
 	UPDATE files
@@ -195,7 +404,11 @@ func (d *Database) UpdateFilePath(file *data.File) error {
 		file.Id,
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("UpdateFilePath", startedAt)
+
+	if isUniqueViolation(err) {
+		return data.AlreadyExists
+	}
 
 	if err != nil {
 		d.log.Errorf("failed to update file path: %v", err)
@@ -205,9 +418,11 @@ func (d *Database) UpdateFilePath(file *data.File) error {
 	return nil
 }
 
-func (d *Database) DeleteFile(file *data.File) error {
+func (d *Database) UpdateFileDigest(file *data.File) error {
 	statement := `
-	DELETE FROM files
+	UPDATE files
+		SET "size" = ?,
+			checksum = ?
 	WHERE
 		id = ?
 	`
@@ -216,44 +431,132 @@ func (d *Database) DeleteFile(file *data.File) error {
 	startedAt := time.Now()
 
 	_, err := d.db.Exec(statement,
+		file.Size,
+		file.Checksum,
 		file.Id,
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("UpdateFileDigest", startedAt)
 
 	if err != nil {
-		d.log.Errorf("failed to delete file: %v", err)
+		d.log.Errorf("failed to update file digest: %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func (d *Database) DeleteFiles(ids []int64) error {
-	placeholders := strings.TrimRight(strings.Repeat("?,", len(ids)), ",")
+func (d *Database) UpdateFileMetadata(file *data.File) error {
+	statement := `
+	UPDATE files
+		SET title = ?,
+			artist = ?,
+			duration = ?
+	WHERE
+		id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	_, err := d.db.Exec(statement,
+		file.Title,
+		file.Artist,
+		file.Duration,
+		file.Id,
+	)
+
+	d.observe("UpdateFileMetadata", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to update file metadata: %v", err)
+		return err
+	}
+
+	return nil
+}
 
-	statement := fmt.Sprintf(`
-	DELETE FROM files
+// UpdateFileDeletedAt moves a file into the trash or restores it
+// when DeletedAt is not valid
+func (d *Database) UpdateFileDeletedAt(file *data.File) error {
+	statement := `
+	UPDATE files
+		SET deleted_at = ?
 	WHERE
-		id IN (%s)
-	`, placeholders)
+		id = ?
+	`
+
+	var deletedAt any
+	if file.DeletedAt.Valid {
+		deletedAt = file.DeletedAt.Time.UTC()
+	}
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	_, err := d.db.Exec(statement, deletedAt, file.Id)
+
+	d.observe("UpdateFileDeletedAt", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to update file deleted_at: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteFile(file *data.File) error {
+	return d.DeleteFiles([]int64{file.Id})
+}
+
+// DeleteFiles removes the files together with their playlist entries
+// and tags
+func (d *Database) DeleteFiles(ids []int64) error {
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(ids)), ",")
 
 	args := make([]interface{}, len(ids))
 	for i, v := range ids {
 		args[i] = v
 	}
 
-	d.log.Debugf("executing statement: %v", statement)
-	startedAt := time.Now()
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
 
-	_, err := d.db.Exec(statement,
-		args...,
-	)
+	statements := []string{
+		`DELETE FROM playlist_entries WHERE file_id IN (%s)`,
+		`DELETE FROM file_tags WHERE file_id IN (%s)`,
+		`DELETE FROM files WHERE id IN (%s)`,
+	}
+
+	for _, statement := range statements {
+		statement = fmt.Sprintf(statement, placeholders)
+
+		d.log.Debugf("executing statement: %v", statement)
+		startedAt := time.Now()
+
+		_, err = tx.Exec(statement, args...)
+
+		d.observe("DeleteFiles", startedAt)
+
+		if err != nil {
+			d.log.Errorf("failed to delete file: %v", err)
+			return err
+		}
+	}
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	err = d.deleteUnusedTags(tx)
+	if err != nil {
+		return err
+	}
 
+	err = tx.Commit()
 	if err != nil {
-		d.log.Errorf("failed to delete file: %v", err)
+		d.log.Errorf("failed to commit file deletion: %v", err)
 		return err
 	}
 
@@ -263,21 +566,54 @@ func (d *Database) DeleteFiles(ids []int64) error {
 func (d *Database) GetFilesForGFW(request *gfsw.Request) (*gfsw.Result, error) {
 	result := &gfsw.Result{}
 
-	statement :=
+	joins := ""
+	conditions := []string{}
+	args := []interface{}{}
+	order := "f.added_at DESC"
+
+	if request.PlaylistId != nil {
+		joins = "JOIN playlist_entries AS e ON e.file_id = f.id AND e.playlist_id = ?"
+		args = append(args, *request.PlaylistId)
+		order = "e.position"
+	}
+
+	// Trashed files are listed only on demand
+	if request.Trashed != nil && *request.Trashed {
+		conditions = append(conditions, "f.deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "f.deleted_at IS NULL")
+	}
+
+	if request.Tag != nil {
+		conditions = append(conditions, `f.id IN (
+			SELECT ft.file_id
+			FROM file_tags AS ft
+			JOIN tags AS t ON t.id = ft.tag_id
+			WHERE t.name = ?
+		)`)
+		args = append(args, *request.Tag)
+	}
+
+	filter := joins + "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+
+	statement := fmt.Sprintf(
 		`
 		SELECT 
 			COUNT (*) 
-		FROM files
-		`
+		FROM files as f
+		%v
+		`,
+		filter,
+	)
 
 	d.log.Debugf("executing statement: %v", statement)
 	startedAt := time.Now()
 
-	err := d.db.QueryRow(statement).Scan(
+	err := d.db.QueryRow(statement, args...).Scan(
 		&result.Total,
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetFilesForGFW", startedAt)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		d.log.Errorf("failed to get file count: %v", err)
@@ -290,12 +626,16 @@ func (d *Database) GetFilesForGFW(request *gfsw.Request) (*gfsw.Result, error) {
 			f.id,
 			f.source,
 			f.status,
-			f.added_at
+			f.added_at,
+			f.deleted_at
 		FROM files as f
-		ORDER BY f.added_at DESC
+		%v
+		ORDER BY %v
 		LIMIT %v
 		OFFSET %v
 		`,
+		filter,
+		order,
 		*request.Limit,
 		*request.Offset,
 	)
@@ -303,9 +643,9 @@ func (d *Database) GetFilesForGFW(request *gfsw.Request) (*gfsw.Result, error) {
 	d.log.Debugf("executing statement: %v", statement)
 	startedAt = time.Now()
 
-	rows, err := d.db.Query(statement)
+	rows, err := d.db.Query(statement, args...)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetFilesForGFW", startedAt)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		d.log.Errorf("failed to get files: %v", err)
@@ -316,7 +656,13 @@ func (d *Database) GetFilesForGFW(request *gfsw.Request) (*gfsw.Result, error) {
 	for rows.Next() {
 		var file gfsw.File
 
-		err = rows.Scan(&file.Id, &file.Source, &file.Status, &file.AddedAt)
+		err = rows.Scan(
+			&file.Id,
+			&file.Source,
+			&file.Status,
+			&file.AddedAt,
+			&file.DeletedAt,
+		)
 		if err != nil {
 			d.log.Errorf("failed to scan files: %v", err)
 			return result, fmt.Errorf("failed to get files")
@@ -338,6 +684,10 @@ func (d *Database) GetFileForGFW(request *gfw.Request) (*gfw.Result, error) {
 			f.source_url,
 			f."source",
 			f.status,
+			f.title,
+			f.artist,
+			f.duration,
+			f.deleted_at,
 			f.added_at,
 			f.updated_at
 		FROM files as f
@@ -355,77 +705,116 @@ func (d *Database) GetFileForGFW(request *gfw.Request) (*gfw.Result, error) {
 		&result.SourceUrl,
 		&result.Source,
 		&result.Status,
+		&result.Title,
+		&result.Artist,
+		&result.Duration,
+		&result.DeletedAt,
 		&result.AddedAt,
 		&result.UpdatedAt,
 	)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetFileForGFW", startedAt)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		d.log.Errorf("failed to get file: %v", err)
 		return result, errors.New("no such file")
 	}
 
+	result.Tags, err = d.GetFileTags(*request.Id)
+	if err != nil {
+		return result, errors.New("failed to get file tags")
+	}
+
 	return result, nil
 }
 
 func (d *Database) GetSettings() (*data.Settings, error) {
-	var settings data.Settings
+	settings := data.DefaultSettings()
 
 	statement := `
 	SELECT
-		storage_dir
+		"key",
+		"value"
 	FROM settings
 	`
 
 	d.log.Debugf("executing statement: %v", statement)
 	startedAt := time.Now()
 
-	err := d.db.QueryRow(statement).Scan(
-		&settings.StorageDir,
-	)
+	rows, err := d.db.Query(statement)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	d.observe("GetSettings", startedAt)
 
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	if err != nil {
 		d.log.Errorf("failed to get settings: %v", err)
 		return nil, fmt.Errorf("failed to get settings")
 	}
+	defer rows.Close()
 
-	return &settings, nil
-}
+	for rows.Next() {
+		var key, value string
 
-func (d *Database) UpdateSettings(settings *data.Settings) (*data.Settings, error) {
-	deleteStmt := `DELETE FROM settings`
+		err = rows.Scan(&key, &value)
+		if err != nil {
+			d.log.Errorf("failed to scan settings: %v", err)
+			return nil, fmt.Errorf("failed to get settings")
+		}
 
-	d.log.Debugf("executing statement: %v", deleteStmt)
-	startedAt := time.Now()
+		known, err := applySettingValue(settings, key, value)
+		if err != nil {
+			d.log.Errorf("failed to parse settings: %v", err)
+			return nil, fmt.Errorf("failed to get settings")
+		}
 
-	_, err := d.db.Exec(deleteStmt)
+		if !known {
+			d.log.Warnf("unknown setting is ignored: %v", key)
+		}
+	}
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+	if err = rows.Err(); err != nil {
+		d.log.Errorf("failed to get settings: %v", err)
+		return nil, fmt.Errorf("failed to get settings")
+	}
 
+	return settings, nil
+}
+
+func (d *Database) UpdateSettings(settings *data.Settings) (*data.Settings, error) {
+	tx, err := d.db.Begin()
 	if err != nil {
-		d.log.Errorf("failed to delete existing settings: %v", err)
+		d.log.Errorf("failed to begin transaction: %v", err)
 		return nil, fmt.Errorf("failed to update settings")
 	}
+	defer tx.Rollback()
 
-	insertStmt := `
-    INSERT INTO settings (
-        storage_dir
-    ) VALUES (
-        ?
-    )`
+	statement := `
+	INSERT INTO settings (
+		"key",
+		"value"
+	) VALUES (
+		?,
+		?
+	)
+	ON CONFLICT("key") DO UPDATE SET "value" = excluded."value"
+	`
 
-	d.log.Debugf("executing statement: %v", insertStmt)
-	startedAt = time.Now()
+	for key, value := range settingsToValues(settings) {
+		d.log.Debugf("executing statement: %v", statement)
+		startedAt := time.Now()
 
-	_, err = d.db.Exec(insertStmt, settings.StorageDir)
+		_, err = tx.Exec(statement, key, value)
 
-	d.log.Debugf("execution took %v us", time.Since(startedAt).Microseconds())
+		d.observe("UpdateSettings", startedAt)
+
+		if err != nil {
+			d.log.Errorf("failed to update setting %v: %v", key, err)
+			return nil, fmt.Errorf("failed to update settings")
+		}
+	}
 
+	err = tx.Commit()
 	if err != nil {
-		d.log.Errorf("failed to insert new settings: %v", err)
+		d.log.Errorf("failed to commit settings: %v", err)
 		return nil, fmt.Errorf("failed to update settings")
 	}
 
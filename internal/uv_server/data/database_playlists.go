@@ -0,0 +1,333 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+	"uv_server/internal/uv_server/business/data"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+const playlistColumns = `
+		p.id,
+		p.name,
+		(SELECT COUNT(*) FROM playlist_entries AS e WHERE e.playlist_id = p.id),
+		p.added_at,
+		p.updated_at`
+
+func scanPlaylist(row rowScanner, playlist *data.Playlist) error {
+	return row.Scan(
+		&playlist.Id,
+		&playlist.Name,
+		&playlist.Entries,
+		&playlist.AddedAt,
+		&playlist.UpdatedAt,
+	)
+}
+
+func (d *Database) GetPlaylists() ([]data.Playlist, error) {
+	statement := `
+	SELECT ` + playlistColumns + `
+	FROM playlists AS p
+	ORDER BY p.name
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	rows, err := d.db.Query(statement)
+
+	d.observe("GetPlaylists", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to get playlists: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	playlists := []data.Playlist{}
+
+	for rows.Next() {
+		var playlist data.Playlist
+
+		err = scanPlaylist(rows, &playlist)
+		if err != nil {
+			d.log.Errorf("failed to scan playlists: %v", err)
+			return nil, err
+		}
+
+		playlists = append(playlists, playlist)
+	}
+
+	return playlists, rows.Err()
+}
+
+func (d *Database) GetPlaylist(id int64) (*data.Playlist, error) {
+	var playlist data.Playlist
+
+	statement := `
+	SELECT ` + playlistColumns + `
+	FROM playlists AS p
+		WHERE p.id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	err := scanPlaylist(d.db.QueryRow(statement, id), &playlist)
+
+	d.observe("GetPlaylist", startedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, data.NotFound
+	}
+
+	if err != nil {
+		d.log.Errorf("failed to get playlist: %v", err)
+		return nil, err
+	}
+
+	return &playlist, nil
+}
+
+func (d *Database) InsertPlaylist(name string) (int64, error) {
+	statement := `
+	INSERT INTO playlists (
+		name
+	)
+	VALUES (
+		?
+	)
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	result, err := d.db.Exec(statement, name)
+
+	d.observe("InsertPlaylist", startedAt)
+
+	if isUniqueViolation(err) {
+		return 0, data.AlreadyExists
+	}
+
+	if err != nil {
+		d.log.Errorf("failed to insert playlist: %v", err)
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (d *Database) UpdatePlaylistName(id int64, name string) error {
+	statement := `
+	UPDATE playlists
+		SET name = ?,
+			updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	result, err := d.db.Exec(statement, name, id)
+
+	d.observe("UpdatePlaylistName", startedAt)
+
+	if isUniqueViolation(err) {
+		return data.AlreadyExists
+	}
+
+	if err != nil {
+		d.log.Errorf("failed to rename playlist: %v", err)
+		return err
+	}
+
+	return requireAffected(result)
+}
+
+func (d *Database) DeletePlaylist(id int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := `
+	DELETE FROM playlist_entries
+	WHERE
+		playlist_id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	_, err = tx.Exec(statement, id)
+
+	d.observe("DeletePlaylist", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to delete playlist entries: %v", err)
+		return err
+	}
+
+	statement = `
+	DELETE FROM playlists
+	WHERE
+		id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt = time.Now()
+
+	result, err := tx.Exec(statement, id)
+
+	d.observe("DeletePlaylist", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to delete playlist: %v", err)
+		return err
+	}
+
+	err = requireAffected(result)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *Database) GetPlaylistEntries(id int64) ([]int64, error) {
+	statement := `
+	SELECT file_id
+	FROM playlist_entries
+		WHERE playlist_id = ?
+	ORDER BY position
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	rows, err := d.db.Query(statement, id)
+
+	d.observe("GetPlaylistEntries", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to get playlist entries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	fileIds := []int64{}
+
+	for rows.Next() {
+		var fileId int64
+
+		err = rows.Scan(&fileId)
+		if err != nil {
+			d.log.Errorf("failed to scan playlist entries: %v", err)
+			return nil, err
+		}
+
+		fileIds = append(fileIds, fileId)
+	}
+
+	return fileIds, rows.Err()
+}
+
+func (d *Database) SetPlaylistEntries(id int64, fileIds []int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := `
+	UPDATE playlists
+		SET updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	result, err := tx.Exec(statement, id)
+
+	d.observe("SetPlaylistEntries", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to update playlist: %v", err)
+		return err
+	}
+
+	err = requireAffected(result)
+	if err != nil {
+		return err
+	}
+
+	statement = `
+	DELETE FROM playlist_entries
+	WHERE
+		playlist_id = ?
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+
+	_, err = tx.Exec(statement, id)
+	if err != nil {
+		d.log.Errorf("failed to delete playlist entries: %v", err)
+		return err
+	}
+
+	statement = `
+	INSERT INTO playlist_entries (
+		playlist_id,
+		file_id,
+		position
+	)
+	VALUES (
+		?,
+		?,
+		?
+	)
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt = time.Now()
+
+	for position, fileId := range fileIds {
+		_, err = tx.Exec(statement, id, fileId, position)
+		if err != nil {
+			d.log.Errorf("failed to insert playlist entry: %v", err)
+			return err
+		}
+	}
+
+	d.observe("SetPlaylistEntries", startedAt)
+
+	return tx.Commit()
+}
+
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return data.NotFound
+	}
+
+	return nil
+}
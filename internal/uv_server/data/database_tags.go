@@ -0,0 +1,210 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"uv_server/internal/uv_server/business/data"
+)
+
+func (d *Database) GetTags() ([]data.Tag, error) {
+	statement := `
+	SELECT
+		t.name,
+		COUNT(ft.file_id)
+	FROM tags AS t
+	LEFT JOIN file_tags AS ft ON ft.tag_id = t.id
+	GROUP BY t.id
+	ORDER BY t.name
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	rows, err := d.db.Query(statement)
+
+	d.observe("GetTags", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to get tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []data.Tag{}
+
+	for rows.Next() {
+		var tag data.Tag
+
+		err = rows.Scan(&tag.Name, &tag.Files)
+		if err != nil {
+			d.log.Errorf("failed to scan tags: %v", err)
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (d *Database) GetFileTags(fileId int64) ([]string, error) {
+	statement := `
+	SELECT t.name
+	FROM file_tags AS ft
+	JOIN tags AS t ON t.id = ft.tag_id
+		WHERE ft.file_id = ?
+	ORDER BY t.name
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	rows, err := d.db.Query(statement, fileId)
+
+	d.observe("GetFileTags", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to get file tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+
+	for rows.Next() {
+		var tag string
+
+		err = rows.Scan(&tag)
+		if err != nil {
+			d.log.Errorf("failed to scan file tags: %v", err)
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (d *Database) TagFiles(fileIds []int64, tags []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	tagStatement := `
+	INSERT INTO tags (
+		name
+	)
+	VALUES (
+		?
+	)
+	ON CONFLICT(name) DO NOTHING
+	`
+
+	fileTagStatement := `
+	INSERT OR IGNORE INTO file_tags (
+		file_id,
+		tag_id
+	)
+	SELECT ?, id FROM tags WHERE name = ?
+	`
+
+	d.log.Debugf("executing statement: %v", tagStatement)
+	d.log.Debugf("executing statement: %v", fileTagStatement)
+	startedAt := time.Now()
+
+	for _, tag := range tags {
+		_, err = tx.Exec(tagStatement, tag)
+		if err != nil {
+			d.log.Errorf("failed to insert tag: %v", err)
+			return err
+		}
+
+		for _, fileId := range fileIds {
+			_, err = tx.Exec(fileTagStatement, fileId, tag)
+			if err != nil {
+				d.log.Errorf("failed to tag file: %v", err)
+				return err
+			}
+		}
+	}
+
+	d.observe("TagFiles", startedAt)
+
+	return tx.Commit()
+}
+
+func (d *Database) UntagFiles(fileIds []int64, tags []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := fmt.Sprintf(`
+	DELETE FROM file_tags
+	WHERE
+		file_id IN (%s)
+		AND tag_id IN (SELECT id FROM tags WHERE name IN (%s))
+	`,
+		strings.TrimRight(strings.Repeat("?,", len(fileIds)), ","),
+		strings.TrimRight(strings.Repeat("?,", len(tags)), ","),
+	)
+
+	args := make([]interface{}, 0, len(fileIds)+len(tags))
+	for _, fileId := range fileIds {
+		args = append(args, fileId)
+	}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	_, err = tx.Exec(statement, args...)
+
+	d.observe("UntagFiles", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to untag files: %v", err)
+		return err
+	}
+
+	err = d.deleteUnusedTags(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteUnusedTags removes tags which are not attached to any file,
+// tags exist only as long as they are used
+func (d *Database) deleteUnusedTags(tx *sql.Tx) error {
+	statement := `
+	DELETE FROM tags
+	WHERE
+		id NOT IN (SELECT tag_id FROM file_tags)
+	`
+
+	d.log.Debugf("executing statement: %v", statement)
+	startedAt := time.Now()
+
+	_, err := tx.Exec(statement)
+
+	d.observe("deleteUnusedTags", startedAt)
+
+	if err != nil {
+		d.log.Errorf("failed to delete unused tags: %v", err)
+		return err
+	}
+
+	return nil
+}
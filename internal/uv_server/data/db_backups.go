@@ -0,0 +1,242 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	backupPrefix     = "app-"
+	backupExtension  = ".db"
+	backupTimeLayout = "20060102-150405"
+
+	// Only scheduled backups are rotated, the others are taken
+	// on purpose and are deleted by hand
+	scheduledReason = "scheduled"
+)
+
+// DbBackups takes online backups of the database into the backups
+// directory and keeps only the configured number of the latest ones
+type DbBackups struct {
+	log    *logrus.Entry
+	config *config.Config
+	db     *sql.DB
+	dir    string
+}
+
+func NewDbBackups(config *config.Config, db *sql.DB) *DbBackups {
+	object := &DbBackups{}
+
+	object.log = loggers.DataLogger.
+		WithField("component", "DbBackups")
+	object.config = config
+	object.db = db
+	object.dir = config.ResolvePath(config.BackupsLocation)
+
+	return object
+}
+
+// Backup writes a backup into the backups directory and returns its
+// path, the reason becomes a part of the file name
+func (b *DbBackups) Backup(reason string) (string, error) {
+	name := backupPrefix + time.Now().Format(backupTimeLayout) + "-" + reason + backupExtension
+	path := filepath.Join(b.dir, name)
+
+	err := b.BackupTo(path)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// BackupTo writes a backup to the path, existing files are never replaced
+func (b *DbBackups) BackupTo(path string) error {
+	_, err := os.Stat(path)
+	if err == nil {
+		return fmt.Errorf("backup already exists: %v", path)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// The backup is written next to the destination and renamed,
+	// so a failed backup never leaves a partial file
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+
+	dst, err := sql.Open("sqlite3", tmp)
+	if err != nil {
+		return err
+	}
+
+	err = copyDatabase(dst, b.db)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to backup database: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+
+	b.log.Infof("database is backed up to %v", path)
+
+	return nil
+}
+
+// Restore replaces the database content with the backup
+func (b *DbBackups) Restore(path string) error {
+	_, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	src, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	err = copyDatabase(b.db, src)
+	if err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	b.log.Infof("database is restored from %v", path)
+
+	return nil
+}
+
+// List returns paths of backups from the oldest to the latest
+func (b *DbBackups) List() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() &&
+			strings.HasPrefix(name, backupPrefix) &&
+			strings.HasSuffix(name, backupExtension) {
+			paths = append(paths, filepath.Join(b.dir, name))
+		}
+	}
+
+	// Names start with the timestamp
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// Rotate deletes all scheduled backups except the configured number
+// of the latest
+func (b *DbBackups) Rotate() error {
+	all, err := b.List()
+	if err != nil {
+		return err
+	}
+
+	paths := []string{}
+	for _, path := range all {
+		if strings.HasSuffix(path, "-"+scheduledReason+backupExtension) {
+			paths = append(paths, path)
+		}
+	}
+
+	for len(paths) > b.config.BackupsToKeep {
+		err = os.Remove(paths[0])
+		if err != nil {
+			return err
+		}
+
+		b.log.Infof("deleted old backup %v", paths[0])
+		paths = paths[1:]
+	}
+
+	return nil
+}
+
+// BackupLoop takes scheduled backups until the context is canceled
+func (b *DbBackups) BackupLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(b.config.BackupIntervalHours) * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := b.Backup(scheduledReason)
+		if err != nil {
+			b.log.Errorf("scheduled backup has failed: %v", err)
+			continue
+		}
+
+		err = b.Rotate()
+		if err != nil {
+			b.log.Errorf("failed to rotate backups: %v", err)
+		}
+	}
+}
+
+// copyDatabase copies every page of the source database into
+// the destination with SQLite online backup API
+func copyDatabase(dst *sql.DB, src *sql.DB) error {
+	ctx := context.Background()
+
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			backup, err := dstDriverConn.(*sqlite3.SQLiteConn).Backup(
+				"main",
+				srcDriverConn.(*sqlite3.SQLiteConn),
+				"main")
+			if err != nil {
+				return err
+			}
+
+			_, err = backup.Step(-1)
+			if err != nil {
+				backup.Close()
+				return err
+			}
+
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"uv_server/internal/uv_server/config"
+)
+
+func newDbBackups(t *testing.T, keep int) (*DbBackups, *sql.DB) {
+	home := t.TempDir()
+
+	db, err := sql.Open("sqlite3", filepath.Join(home, "app.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	backups := &DbBackups{}
+	backups.log = logrus.New().WithField("layer", "Data")
+	backups.config = &config.Config{HomeDir: home, BackupsToKeep: keep}
+	backups.db = db
+	backups.dir = filepath.Join(home, "backups")
+
+	return backups, db
+}
+
+func countRows(t *testing.T, db *sql.DB) int {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count)
+	require.NoError(t, err)
+
+	return count
+}
+
+func TestDbBackups_BackupAndRestore(t *testing.T) {
+	backups, db := newDbBackups(t, 7)
+
+	_, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO items (id) VALUES (1)")
+	require.NoError(t, err)
+
+	path, err := backups.Backup("test")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO items (id) VALUES (2)")
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRows(t, db))
+
+	err = backups.Restore(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, countRows(t, db))
+
+	// Backups are never overwritten
+	err = backups.BackupTo(path)
+	assert.Error(t, err)
+}
+
+func TestDbBackups_Rotate(t *testing.T) {
+	backups, _ := newDbBackups(t, 2)
+
+	names := []string{
+		"app-1-scheduled.db",
+		"app-2-v3.db",
+		"app-3-scheduled.db",
+		"app-4-manual.db",
+		"app-5-scheduled.db",
+		"app-6-pre-restore.db",
+		"other.db",
+	}
+	for _, name := range names {
+		require.NoError(t, backups.BackupTo(filepath.Join(backups.dir, name)))
+	}
+
+	err := backups.Rotate()
+	require.NoError(t, err)
+
+	paths, err := backups.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(backups.dir, "app-2-v3.db"),
+		filepath.Join(backups.dir, "app-3-scheduled.db"),
+		filepath.Join(backups.dir, "app-4-manual.db"),
+		filepath.Join(backups.dir, "app-5-scheduled.db"),
+		filepath.Join(backups.dir, "app-6-pre-restore.db"),
+	}, paths)
+	assert.FileExists(t, filepath.Join(backups.dir, "other.db"))
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"uv_server/internal/uv_server/business/data"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+)
+
+const toolVersionTimeout = 5 * time.Second
+
+type Diagnostics struct {
+	log    *logrus.Entry
+	config *config.Config
+	db     *sql.DB
+}
+
+func NewDiagnostics(config *config.Config, db *sql.DB) *Diagnostics {
+	object := &Diagnostics{}
+
+	object.log = loggers.DataLogger.WithField("component", "Diagnostics")
+	object.config = config
+	object.db = db
+
+	return object
+}
+
+func (d *Diagnostics) GetDbVersion() (int, int, error) {
+	migrator := NewDbMigrator(d.config, d.db)
+
+	current, err := migrator.GetVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return current, migrator.RequiredVersion(), nil
+}
+
+func (d *Diagnostics) GetTools() []data.ToolInfo {
+	return []data.ToolInfo{
+		d.inspectTool(
+			"ffmpeg",
+			filepath.Join(d.config.ResolvePath(d.config.FfmpegLocation), "ffmpeg"),
+			"-version",
+		),
+		d.inspectTool(
+			"downloader",
+			filepath.Join(d.config.ResolvePath(d.config.ToolsLocation), "downloader"),
+			"--version",
+		),
+	}
+}
+
+// inspectTool runs the tool to get its version, the tool is not
+// available when it can not be executed
+func (d *Diagnostics) inspectTool(name string, path string, versionArg string) data.ToolInfo {
+	tool := data.ToolInfo{Name: name, Path: path}
+
+	ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, versionArg).Output()
+	if err != nil {
+		d.log.Warnf("failed to get %v version: %v", name, err)
+		tool.Error = err.Error()
+		return tool
+	}
+
+	tool.Available = true
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	if scanner.Scan() {
+		tool.Version = scanner.Text()
+	}
+
+	return tool
+}
+
+func (d *Diagnostics) GetFreeSpace(path string) (uint64, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return freeSpace(path)
+}
@@ -0,0 +1,165 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+	"uv_server/internal/uv_server/business/data"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
+	"uv_server/internal/uv_server/common/schedule"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Waiting downloads look at the settings again after this time,
+// so changes of the settings are picked up
+const queueRecheckInterval = 30 * time.Second
+
+type queueTicket struct{}
+
+type DownloadQueue struct {
+	log      *logrus.Entry
+	database *Database
+
+	mutex   sync.Mutex
+	running int
+	// Waiting downloads in the order they are queued
+	waiting []*queueTicket
+	// Closed and replaced whenever waiting downloads may proceed
+	changed chan struct{}
+}
+
+func NewDownloadQueue(db *sql.DB) *DownloadQueue {
+	object := &DownloadQueue{}
+
+	object.log = loggers.DataLogger.
+		WithField("component", "DownloadQueue")
+	object.database = NewDatabase(db)
+	object.changed = make(chan struct{})
+
+	return object
+}
+
+func (q *DownloadQueue) TryAcquire() (func(), bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.waiting) > 0 {
+		return nil, false
+	}
+
+	ok, _ := q.mayStart()
+	if !ok {
+		return nil, false
+	}
+
+	return q.take(), true
+}
+
+func (q *DownloadQueue) Acquire(ctx context.Context) (func(), error) {
+	ticket := &queueTicket{}
+
+	q.mutex.Lock()
+	q.waiting = append(q.waiting, ticket)
+	q.mutex.Unlock()
+
+	metrics.DownloadsQueued.Inc()
+	defer metrics.DownloadsQueued.Dec()
+
+	for {
+		q.mutex.Lock()
+
+		wait := queueRecheckInterval
+		if q.waiting[0] == ticket {
+			var ok bool
+			ok, wait = q.mayStart()
+			if ok {
+				q.dequeue(ticket)
+				release := q.take()
+				q.mutex.Unlock()
+				return release, nil
+			}
+		}
+
+		changed := q.changed
+		q.mutex.Unlock()
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			q.mutex.Lock()
+			q.dequeue(ticket)
+			q.mutex.Unlock()
+
+			return nil, ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// mayStart checks the settings, the returned duration tells how long
+// to wait before checking again. Called with the mutex locked
+func (q *DownloadQueue) mayStart() (bool, time.Duration) {
+	settings, err := q.database.GetSettings()
+	if err != nil {
+		// Downloads are not held back when settings are broken
+		q.log.Errorf("failed to get settings: %v", err)
+		settings = data.DefaultSettings()
+	}
+
+	if q.running >= settings.MaxConcurrentDownloads {
+		return false, queueRecheckInterval
+	}
+
+	downloadSchedule, err := schedule.Parse(settings.DownloadSchedule)
+	if err != nil {
+		q.log.Errorf("download schedule is ignored: %v", err)
+		return true, 0
+	}
+
+	now := time.Now()
+	if !downloadSchedule.Allows(now) {
+		return false, min(downloadSchedule.NextStart(now).Sub(now), queueRecheckInterval)
+	}
+
+	return true, 0
+}
+
+// take occupies a slot, called with the mutex locked
+func (q *DownloadQueue) take() func() {
+	q.running++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			q.mutex.Lock()
+			defer q.mutex.Unlock()
+
+			q.running--
+			q.notify()
+		})
+	}
+}
+
+// dequeue removes the ticket, so the next download becomes the first
+// one, called with the mutex locked
+func (q *DownloadQueue) dequeue(ticket *queueTicket) {
+	q.waiting = slices.DeleteFunc(q.waiting, func(t *queueTicket) bool {
+		return t == ticket
+	})
+	q.notify()
+}
+
+func (q *DownloadQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
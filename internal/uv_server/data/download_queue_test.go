@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"uv_server/internal/uv_server/business/data"
+)
+
+const settingsTable = `
+CREATE TABLE settings (
+	"key" TEXT PRIMARY KEY,
+	"value" TEXT NOT NULL
+);
+`
+
+func newTestQueue(t *testing.T, settings *data.Settings) *DownloadQueue {
+	db := newMemoryDb(t)
+
+	_, err := db.Exec(settingsTable)
+	require.NoError(t, err)
+
+	log := logrus.New().WithField("layer", "Data")
+
+	queue := &DownloadQueue{}
+	queue.log = log
+	queue.database = &Database{log: log, db: db}
+	queue.changed = make(chan struct{})
+
+	_, err = queue.database.UpdateSettings(settings)
+	require.NoError(t, err)
+
+	return queue
+}
+
+func TestDownloadQueue_ConcurrencyLimit(t *testing.T) {
+	settings := data.DefaultSettings()
+	settings.MaxConcurrentDownloads = 1
+	queue := newTestQueue(t, settings)
+
+	release, ok := queue.TryAcquire()
+	require.True(t, ok)
+
+	_, ok = queue.TryAcquire()
+	assert.False(t, ok)
+
+	acquired := make(chan func())
+	go func() {
+		next, err := queue.Acquire(context.Background())
+		assert.NoError(t, err)
+		acquired <- next
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("download started while the slot is taken")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Releasing twice frees a single slot
+	release()
+	release()
+
+	select {
+	case next := <-acquired:
+		_, ok = queue.TryAcquire()
+		assert.False(t, ok)
+		next()
+	case <-time.After(time.Second):
+		t.Fatal("queued download did not start")
+	}
+
+	release, ok = queue.TryAcquire()
+	assert.True(t, ok)
+	release()
+}
+
+func TestDownloadQueue_Cancelled(t *testing.T) {
+	settings := data.DefaultSettings()
+	settings.MaxConcurrentDownloads = 1
+	queue := newTestQueue(t, settings)
+
+	release, ok := queue.TryAcquire()
+	require.True(t, ok)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := queue.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, queue.waiting)
+}
+
+func TestDownloadQueue_OutsideOfSchedule(t *testing.T) {
+	// The window is as far from now as possible
+	now := time.Now().Add(12 * time.Hour)
+	settings := data.DefaultSettings()
+	settings.DownloadSchedule = fmt.Sprintf(
+		"%02d:%02d-%02d:%02d",
+		now.Hour(), now.Minute(), now.Add(time.Hour).Hour(), now.Minute())
+	queue := newTestQueue(t, settings)
+
+	_, ok := queue.TryAcquire()
+	assert.False(t, ok)
+
+	settings.DownloadSchedule = ""
+	_, err := queue.database.UpdateSettings(settings)
+	require.NoError(t, err)
+
+	release, ok := queue.TryAcquire()
+	assert.True(t, ok)
+	release()
+}
@@ -0,0 +1,87 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"uv_server/internal/uv_server/business/data"
+	"uv_server/internal/uv_server/common/loggers"
+
+	"github.com/sirupsen/logrus"
+)
+
+type downloadEntry struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type DownloadRegistry struct {
+	log *logrus.Entry
+
+	mutex     sync.Mutex
+	downloads map[int64]*downloadEntry
+}
+
+func NewDownloadRegistry() *DownloadRegistry {
+	object := &DownloadRegistry{}
+
+	object.log = loggers.DataLogger.
+		WithField("component", "DownloadRegistry")
+	object.downloads = make(map[int64]*downloadEntry)
+
+	return object
+}
+
+func (r *DownloadRegistry) Register(
+	claim func() (int64, error),
+	cancel context.CancelFunc,
+) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Cancel waits for the lock, so a claimed row is never seen
+	// without its download
+	fileId, err := claim()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, ok := r.downloads[fileId]; ok {
+		r.log.Errorf("download for file %v is already registered", fileId)
+		return 0, data.AlreadyDownloading
+	}
+
+	r.downloads[fileId] = &downloadEntry{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	return fileId, nil
+}
+
+func (r *DownloadRegistry) Unregister(fileId int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.downloads[fileId]
+	if !ok {
+		r.log.Fatalf("download for file %v is not registered", fileId)
+	}
+
+	delete(r.downloads, fileId)
+	close(entry.done)
+}
+
+func (r *DownloadRegistry) Cancel(fileId int64) (<-chan struct{}, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.downloads[fileId]
+	if !ok {
+		return nil, false
+	}
+
+	r.log.Debugf("cancelling download for file %v", fileId)
+	entry.cancel()
+
+	return entry.done, true
+}
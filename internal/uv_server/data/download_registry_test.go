@@ -0,0 +1,52 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"uv_server/internal/uv_server/business/data"
+)
+
+func newTestRegistry() *DownloadRegistry {
+	registry := &DownloadRegistry{}
+	registry.log = logrus.New().WithField("layer", "Data")
+	registry.downloads = make(map[int64]*downloadEntry)
+
+	return registry
+}
+
+func claimId(fileId int64) func() (int64, error) {
+	return func() (int64, error) { return fileId, nil }
+}
+
+func TestRegister_AlreadyRegistered(t *testing.T) {
+	registry := newTestRegistry()
+
+	cancelled := false
+	fileId, err := registry.Register(claimId(1), func() { cancelled = true })
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), fileId)
+
+	_, err = registry.Register(claimId(1), func() {})
+	assert.ErrorIs(t, err, data.AlreadyDownloading)
+
+	// The first download stays registered
+	_, ok := registry.Cancel(1)
+	assert.True(t, ok)
+	assert.True(t, cancelled)
+}
+
+func TestRegister_ClaimFails(t *testing.T) {
+	registry := newTestRegistry()
+
+	claimErr := errors.New("failed")
+	_, err := registry.Register(func() (int64, error) { return 0, claimErr }, func() {})
+	assert.ErrorIs(t, err, claimErr)
+
+	_, ok := registry.Cancel(0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,165 @@
+package downloaders
+
+import (
+	"encoding/json"
+	"fmt"
+
+	businessData "uv_server/internal/uv_server/business/workflows/downloading/data"
+	"uv_server/internal/uv_server/common"
+)
+
+// The downloader script writes one JSON object per line to stdout,
+// the "type" field tells which of the messages below it is. The first
+// message is always hello, the last one is either done or failed.
+// Unknown types and unknown fields are rejected, so the script and
+// the server have to agree on the version of the protocol. Anything
+// the script writes to stderr is kept and added to the failure reason
+// when the script breaks the protocol or exits without a result.
+//
+// tools/downloaders/youtube/downloader.py implements the other side.
+const ProtocolVersion = 1
+
+type MessageType string
+
+const (
+	MessageHello    MessageType = "hello"
+	MessageMetadata MessageType = "metadata"
+	MessageProgress MessageType = "progress"
+	MessageLog      MessageType = "log"
+	MessageWarning  MessageType = "warning"
+	MessageDone     MessageType = "done"
+	MessageFailed   MessageType = "failed"
+)
+
+type messageHeader struct {
+	Type MessageType `json:"type"`
+}
+
+type HelloMessage struct {
+	messageHeader
+	Version     int    `json:"version"`
+	ToolVersion string `json:"tool_version"`
+}
+
+// MetadataMessage is sent once the media is resolved, values which
+// are not known are empty
+type MetadataMessage struct {
+	messageHeader
+	Title      string  `json:"title"`
+	Uploader   string  `json:"uploader"`
+	Id         string  `json:"id"`
+	UploadDate string  `json:"upload_date"`
+	Duration   float64 `json:"duration"`
+}
+
+// ProgressMessage values which are not known are omitted
+type ProgressMessage struct {
+	messageHeader
+	Phase           businessData.Phase `json:"phase"`
+	Percentage      float64            `json:"percentage"`
+	DownloadedBytes int64              `json:"downloaded_bytes"`
+	TotalBytes      int64              `json:"total_bytes"`
+	Speed           float64            `json:"speed"`
+	Eta             float64            `json:"eta"`
+}
+
+type LogLevel string
+
+const (
+	LogDebug LogLevel = "debug"
+	LogInfo  LogLevel = "info"
+)
+
+type LogMessage struct {
+	messageHeader
+	Level LogLevel `json:"level"`
+	Msg   string   `json:"msg"`
+}
+
+type WarningMessage struct {
+	messageHeader
+	Msg string `json:"msg"`
+}
+
+type DoneMessage struct {
+	messageHeader
+	// Path of the downloaded file in the temp directory
+	Filename string `json:"filename"`
+}
+
+type FailedMessage struct {
+	messageHeader
+	Msg string `json:"msg"`
+}
+
+// ParseChildMessage decodes a line of the script output into one of
+// the message types and checks its required fields
+func ParseChildMessage(line []byte) (interface{}, error) {
+	header := &messageHeader{}
+	err := json.Unmarshal(line, header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	var message interface{}
+
+	switch header.Type {
+	case MessageHello:
+		message = &HelloMessage{}
+	case MessageMetadata:
+		message = &MetadataMessage{}
+	case MessageProgress:
+		message = &ProgressMessage{}
+	case MessageLog:
+		message = &LogMessage{}
+	case MessageWarning:
+		message = &WarningMessage{}
+	case MessageDone:
+		message = &DoneMessage{}
+	case MessageFailed:
+		message = &FailedMessage{}
+	case "":
+		return nil, fmt.Errorf("message does not contain a \"type\" field")
+	default:
+		return nil, fmt.Errorf("unknown message type %q", header.Type)
+	}
+
+	err = common.UnmarshalStrict(line, message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v message: %w", header.Type, err)
+	}
+
+	err = validateChildMessage(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v message: %w", header.Type, err)
+	}
+
+	return message, nil
+}
+
+func validateChildMessage(message interface{}) error {
+	switch m := message.(type) {
+	case *ProgressMessage:
+		switch m.Phase {
+		case businessData.PhaseFetchingMetadata,
+			businessData.PhaseDownloading,
+			businessData.PhasePostProcessing:
+		default:
+			return fmt.Errorf("unknown phase %q", m.Phase)
+		}
+	case *LogMessage:
+		if m.Level != LogDebug && m.Level != LogInfo {
+			return fmt.Errorf("unknown level %q", m.Level)
+		}
+	case *DoneMessage:
+		if m.Filename == "" {
+			return fmt.Errorf("\"filename\" is empty")
+		}
+	case *FailedMessage:
+		if m.Msg == "" {
+			return fmt.Errorf("\"msg\" is empty")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package downloaders
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup makes the child a leader of a new process group,
+// so processes it spawns can be killed together with it
+func startInProcessGroup(process *exec.Cmd) {
+	process.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessTree kills the child and every process of its group
+func killProcessTree(process *exec.Cmd) error {
+	return syscall.Kill(-process.Process.Pid, syscall.SIGKILL)
+}
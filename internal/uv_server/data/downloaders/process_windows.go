@@ -0,0 +1,29 @@
+//go:build windows
+
+package downloaders
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// startInProcessGroup keeps console signals of the server away from
+// the child, the server decides when the child is stopped
+func startInProcessGroup(process *exec.Cmd) {
+	process.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// killProcessTree kills the child and every process it has spawned
+func killProcessTree(process *exec.Cmd) error {
+	err := exec.Command(
+		"taskkill", "/T", "/F", "/PID", strconv.Itoa(process.Process.Pid),
+	).Run()
+	if err != nil {
+		return process.Process.Kill()
+	}
+
+	return nil
+}
@@ -2,8 +2,8 @@ package downloaders
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,23 +11,22 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	bdata "uv_server/internal/uv_server/business/data"
 	businessData "uv_server/internal/uv_server/business/workflows/downloading/data"
+	"uv_server/internal/uv_server/common/filenames"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 	"uv_server/internal/uv_server/config"
-)
-
-type mtype int
-
-const (
-	DownloadingProgress mtype = 1
-	DownloadingDone     mtype = 2
-	DownloadingFailed   mtype = 3
+	"uv_server/internal/uv_server/data"
 )
 
 type YtDownloader struct {
@@ -43,6 +42,8 @@ type YtDownloader struct {
 	child_out chan interface{}
 
 	to_clean chan<- string
+
+	database bdata.Database
 }
 
 func NewYtDownloader(
@@ -51,6 +52,7 @@ func NewYtDownloader(
 	jobCtx context.Context,
 	wf_out chan<- interface{},
 	to_clean chan<- string,
+	database bdata.Database,
 ) *YtDownloader {
 	object := &YtDownloader{}
 	object.log = loggers.DataLogger.WithFields(
@@ -66,121 +68,155 @@ func NewYtDownloader(
 	object.wf_out = wf_out
 	object.child_out = make(chan interface{}, 1)
 	object.to_clean = to_clean
+	object.database = database
 
 	return object
 }
 
-func (d *YtDownloader) handleProgressMessage(
-	message map[string]interface{},
-) error {
-	d.log.Tracef("Handling progress message: %v", message)
+// handleMetadataMessage keeps the metadata until the download is done
+func (d *YtDownloader) handleMetadataMessage(
+	message *MetadataMessage,
+	done *businessData.Done,
+) {
+	d.log.Tracef("Handling metadata message: %+v", message)
 
-	percentage, ok := message["percentage"]
+	done.Title = message.Title
+	done.Uploader = message.Uploader
+	done.VideoId = message.Id
+	done.UploadDate = message.UploadDate
+	done.Duration = message.Duration
+}
 
-	if !ok {
-		return errors.New("progress message does not contain " +
-			"a \"percentage\" field")
-	}
+func (d *YtDownloader) handleProgressMessage(message *ProgressMessage) {
+	d.log.Tracef("Handling progress message: %+v", message)
 
-	businessMessage := &businessData.Progress{
-		Percentage: percentage.(float64),
+	d.child_out <- &businessData.Progress{
+		Phase:           message.Phase,
+		Percentage:      message.Percentage,
+		DownloadedBytes: message.DownloadedBytes,
+		TotalBytes:      message.TotalBytes,
+		Speed:           message.Speed,
+		Eta:             message.Eta,
 	}
-
-	d.child_out <- businessMessage
-	return nil
 }
 
-func (d *YtDownloader) handleDoneMessage(
-	message map[string]interface{},
-) error {
-	d.log.Tracef("Handling done message: %v", message)
-
-	filename, ok := message["filename"]
-
-	if !ok {
-		return errors.New("done message does not contain " +
-			"a \"filename\" field")
+func (d *YtDownloader) handleLogMessage(message *LogMessage) {
+	switch message.Level {
+	case LogDebug:
+		d.log.Debugf("downloader: %v", message.Msg)
+	case LogInfo:
+		d.log.Infof("downloader: %v", message.Msg)
 	}
+}
 
-	businessMessage := &businessData.Done{
-		Filename: filename.(string),
-	}
+func (d *YtDownloader) handleDoneMessage(
+	message *DoneMessage,
+	done *businessData.Done,
+) {
+	d.log.Tracef("Handling done message: %+v", message)
 
-	d.child_out <- businessMessage
-	return nil
+	done.Filename = message.Filename
+	d.child_out <- done
 }
 
-func (d *YtDownloader) handleFailedMessage(
-	message map[string]interface{},
-) error {
-	d.log.Tracef("Handling failed message: %v", message)
-
-	msg, ok := message["msg"]
+func (d *YtDownloader) handleFailedMessage(message *FailedMessage) {
+	d.log.Tracef("Handling failed message: %+v", message)
 
-	if !ok {
-		return errors.New("failed message does not contain " +
-			"a \"msg\" field")
-	}
+	d.child_out <- &businessData.Error{Reason: message.Msg}
+}
 
-	businessMessage := &businessData.Error{
-		Reason: msg.(string),
+// buildStoragePath renders the filename template for the downloaded file,
+// the result is a slash separated path relative to the storage directory
+func (d *YtDownloader) buildStoragePath(
+	storageDir string,
+	downloadedFile string,
+	done *businessData.Done,
+	options *businessData.Options,
+) (string, error) {
+	ext := filepath.Ext(downloadedFile)
+
+	title := done.Title
+	if title == "" {
+		title = strings.TrimSuffix(downloadedFile, ext)
 	}
 
-	d.child_out <- businessMessage
-	return nil
-}
-
-func copyFile(src, dst string) error {
-	sourceFileStat, err := os.Stat(src)
+	name, err := filenames.Render(options.FilenameTemplate, &filenames.Metadata{
+		Title:    title,
+		Uploader: done.Uploader,
+		Id:       done.VideoId,
+		Date:     done.UploadDate,
+		Source:   options.Source,
+	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if !sourceFileStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file", src)
-	}
+	// Trashed files keep their paths to be restored to
+	var takenErr error
+	relPath := filenames.Deduplicate(name+ext, func(relPath string) bool {
+		_, err := os.Stat(filepath.Join(storageDir, filepath.FromSlash(relPath)))
+		if !errors.Is(err, os.ErrNotExist) {
+			return true
+		}
 
-	source, err := os.Open(src)
-	if err != nil {
-		return err
+		taken, err := d.database.IsPathTaken(relPath)
+		if err != nil {
+			takenErr = err
+		}
+		return taken
+	})
+	if takenErr != nil {
+		return "", takenErr
 	}
-	defer source.Close()
 
-	destinationStat, err := os.Stat(dst)
-	if err != nil && errors.Is(err, os.ErrNotExist) {
-		destination, err := os.Create(dst)
+	return relPath, nil
+}
+
+// finalize moves the downloaded file from the temp directory into
+// the storage, the file is verified before it is reported as done
+func (d *YtDownloader) finalize(
+	storageDir string,
+	tempDir string,
+	downloadedFile string,
+	done *businessData.Done,
+	options *businessData.Options,
+) (string, *bdata.FileDigest, error) {
+	const attempts = 3
+
+	for range attempts {
+		relPath, err := d.buildStoragePath(
+			storageDir, downloadedFile, done, options)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 
-		defer destination.Close()
-		_, err = io.Copy(destination, source)
-		return err
-	}
+		d.log.Debugf("storage path is: %v", relPath)
 
-	if err != nil {
-		return err
-	}
+		digest, err := data.FinalizeFile(
+			path.Join(tempDir, downloadedFile),
+			filepath.Join(storageDir, filepath.FromSlash(relPath)),
+		)
 
-	if !destinationStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file", src)
-	}
+		if errors.Is(err, data.ErrDestinationExists) {
+			d.log.Warnf("storage path is taken, retrying: %v", relPath)
+			continue
+		}
 
-	destination, err := os.Create(dst)
-	if err != nil {
-		return err
+		if err != nil {
+			return "", nil, err
+		}
+
+		return relPath, digest, nil
 	}
 
-	defer destination.Close()
-	_, err = io.Copy(destination, source)
-	return err
+	return "", nil, fmt.Errorf("failed to find free storage path")
 }
 
 func (d *YtDownloader) ensureDirectoryExists(dir string) {
 	stat, err := os.Stat(dir)
 
 	if err != nil && errors.Is(err, os.ErrNotExist) {
-		if err := os.Mkdir(dir, os.ModePerm); err != nil {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 			log.Fatal(err)
 		}
 
@@ -196,20 +232,21 @@ func (d *YtDownloader) ensureDirectoryExists(dir string) {
 	}
 }
 
-func (d *YtDownloader) Download(wg *sync.WaitGroup, url string, storageDir string) {
+func (d *YtDownloader) Download(
+	wg *sync.WaitGroup,
+	url string,
+	options *businessData.Options,
+) {
 	d.log.Debugf("downloading file from url: %v", url)
 
 	defer wg.Done()
 
-	wd, err := os.Getwd()
-	if err != nil {
-		d.log.Fatal(err)
-	}
+	storageDir := d.config.ResolvePath(options.StorageDir)
 
-	tempDir := path.Join(wd, "tmp", d.uuid)
+	tempDir := d.config.ResolvePath(path.Join("tmp", d.uuid))
 	d.ensureDirectoryExists(tempDir)
 
-	process, stdout, err := d.startProcess(wd, url, tempDir)
+	process, stdout, stderr, err := d.startProcess(url, tempDir, options)
 	if err != nil {
 		d.log.Fatal(err)
 	}
@@ -228,18 +265,25 @@ func (d *YtDownloader) Download(wg *sync.WaitGroup, url string, storageDir strin
 			if typedMsg, ok := msg.(*businessData.Progress); ok {
 				d.wf_out <- typedMsg
 			} else if typedMsg, ok := msg.(*businessData.Done); ok {
-				sfn := strings.Split(typedMsg.Filename, string(os.PathSeparator))
-				typedMsg.Filename = sfn[len(sfn)-1]
+				d.wf_out <- &businessData.Progress{Phase: businessData.PhaseFinalizing}
 
-				err := copyFile(
-					path.Join(tempDir, typedMsg.Filename),
-					path.Join(storageDir, typedMsg.Filename),
-				)
+				sfn := strings.Split(typedMsg.Filename, string(os.PathSeparator))
+				downloadedFile := sfn[len(sfn)-1]
 
+				relPath, digest, err := d.finalize(
+					storageDir, tempDir, downloadedFile, typedMsg, options)
 				if err != nil {
-					d.log.Fatalf("Failed to copy file: %v", err)
+					d.log.Errorf("failed to finalize file: %v", err)
+					d.cleanUp(process, &childWg, true, tempDir)
+					metrics.DownloaderFailures.WithLabelValues(metrics.FailureFinalize).Inc()
+					d.wf_out <- &businessData.Error{Reason: "failed to store downloaded file"}
+					return
 				}
 
+				typedMsg.Filename = relPath
+				typedMsg.Size = digest.Size
+				typedMsg.Checksum = digest.Checksum
+
 				d.cleanUp(process, &childWg, true, tempDir)
 				d.wf_out <- typedMsg
 				return
@@ -247,6 +291,12 @@ func (d *YtDownloader) Download(wg *sync.WaitGroup, url string, storageDir strin
 				d.wf_out <- typedMsg
 				d.cleanUp(process, &childWg, false, tempDir)
 				return
+			} else if typedMsg, ok := msg.(*childFailure); ok {
+				// stderr is complete once the script is stopped
+				d.cleanUp(process, &childWg, typedMsg.exited, tempDir)
+				d.log.Errorf("downloader stderr: %v", stderr.String())
+				d.wf_out <- &businessData.Error{Reason: failureReason(typedMsg.reason, stderr)}
+				return
 			} else {
 				d.log.Fatalf("Unknown message type: %v", reflect.TypeOf(msg))
 			}
@@ -265,7 +315,7 @@ func (d *YtDownloader) cleanUp(
 	childWg.Wait()
 
 	if !gracefulExit {
-		err := process.Process.Kill()
+		err := killProcessTree(process)
 		if err != nil {
 			d.log.Error(err)
 		}
@@ -281,14 +331,32 @@ func (d *YtDownloader) cleanUp(
 	d.log.Trace("Done cleaning up")
 }
 
-func (d *YtDownloader) startProcess(wd string, url string, dir string) (*exec.Cmd, io.ReadCloser, error) {
-	executable := path.Join(wd, d.config.ToolsLocation, "downloader")
+func (d *YtDownloader) startProcess(
+	url string,
+	dir string,
+	options *businessData.Options,
+) (*exec.Cmd, io.ReadCloser, *stderrTail, error) {
+	executable := path.Join(d.config.ResolvePath(d.config.ToolsLocation), "downloader")
 
-	process := exec.Command(
-		executable,
+	args := []string{
 		"--url", url,
 		"--dir", dir,
-		"--ffmpeg_location", d.config.FfmpegLocation)
+		"--format", options.Format,
+		"--ffmpeg_location", d.config.FfmpegLocation,
+	}
+
+	if options.RateLimit > 0 {
+		args = append(args, "--limit_rate", strconv.FormatInt(options.RateLimit, 10))
+	}
+
+	process := exec.Command(executable, args...)
+	startInProcessGroup(process)
+
+	stderr := &stderrTail{}
+	process.Stderr = stderr
+	// Processes left behind by the script may keep stderr open,
+	// waiting for the script does not hang on them
+	process.WaitDelay = stderrWaitDelay
 
 	stdout, err := process.StdoutPipe()
 	if err != nil {
@@ -300,7 +368,7 @@ func (d *YtDownloader) startProcess(wd string, url string, dir string) (*exec.Cm
 		d.log.Fatal(err)
 	}
 
-	return process, stdout, nil
+	return process, stdout, stderr, nil
 }
 
 func (d *YtDownloader) listenToChild(wg *sync.WaitGroup, stdout io.ReadCloser) {
@@ -308,81 +376,137 @@ func (d *YtDownloader) listenToChild(wg *sync.WaitGroup, stdout io.ReadCloser) {
 
 	reader := bufio.NewReader(stdout)
 
+	helloReceived := false
+	done := &businessData.Done{}
+
 	for {
-		message, err := reader.ReadBytes('\n')
-		d.log.Tracef("Handling script message: %v", string(message))
+		line, err := reader.ReadBytes('\n')
+		d.log.Tracef("Handling script message: %v", string(line))
 
-		if err != nil && !errors.Is(err, os.ErrClosed) {
-			d.log.Errorf("failed to read message from script: %v", err)
-			d.child_out <- &businessData.Error{Reason: "downloading failed"}
+		if errors.Is(err, os.ErrClosed) {
+			d.log.Tracef("Child output closed")
 			return
 		}
 
-		if err != nil && errors.Is(err, os.ErrClosed) {
-			d.log.Tracef("Child output closed")
+		if errors.Is(err, io.EOF) && len(bytes.TrimSpace(line)) == 0 {
+			d.protocolFailure("downloader exited without a result", true)
 			return
 		}
 
-		parsedMessage, t, err := parseChildMessage(message)
+		// The last line may come without a line break
+		if err != nil && !errors.Is(err, io.EOF) {
+			d.log.Errorf("failed to read message from script: %v", err)
+			d.protocolFailure("failed to read downloader output", false)
+			return
+		}
 
+		message, err := ParseChildMessage(line)
 		if err != nil {
-			d.log.Error(err)
-			d.child_out <- &businessData.Error{Reason: "downloading failed"}
+			d.log.Errorf("failed to parse message from script: %v", err)
+			d.protocolFailure(err.Error(), false)
 			return
 		}
 
-		switch mtype(t.(float64)) {
-		case DownloadingProgress:
-			err := d.handleProgressMessage(parsedMessage)
-			if err != nil {
-				d.log.Errorf("failed to handle progress message: %v, reason: %v",
-					parsedMessage, err)
-
-				d.child_out <- &businessData.Error{Reason: "downloading failed"}
+		if !helloReceived {
+			hello, ok := message.(*HelloMessage)
+			if !ok {
+				d.protocolFailure("expected a hello message first", false)
 				return
 			}
-		case DownloadingDone:
-			err := d.handleDoneMessage(parsedMessage)
-
-			if err != nil {
-				d.log.Errorf("failed to handle done message: %v, reason: %v",
-					parsedMessage, err)
 
-				d.child_out <- &businessData.Error{Reason: "downloading failed"}
+			if hello.Version != ProtocolVersion {
+				d.protocolFailure(fmt.Sprintf(
+					"unsupported downloader protocol version %v, expected %v",
+					hello.Version, ProtocolVersion), false)
 				return
 			}
 
-			return
-		case DownloadingFailed:
-			err := d.handleFailedMessage(parsedMessage)
-
-			if err != nil {
-				d.log.Errorf("failed to handle error message: %v, reason: %v",
-					parsedMessage, err)
+			d.log.Debugf("downloader version is %v", hello.ToolVersion)
+			helloReceived = true
+			continue
+		}
 
-				d.child_out <- &businessData.Error{Reason: "downloading failed"}
-				return
-			}
+		switch m := message.(type) {
+		case *HelloMessage:
+			d.protocolFailure("unexpected hello message", false)
+			return
+		case *MetadataMessage:
+			d.handleMetadataMessage(m, done)
+		case *ProgressMessage:
+			d.handleProgressMessage(m)
+		case *LogMessage:
+			d.handleLogMessage(m)
+		case *WarningMessage:
+			d.log.Warnf("downloader: %v", m.Msg)
+		case *DoneMessage:
+			d.handleDoneMessage(m, done)
 			return
-		default:
-			d.log.Errorf("no message handler for type: %v", err)
-			d.child_out <- &businessData.Error{Reason: "downloading failed"}
+		case *FailedMessage:
+			d.handleFailedMessage(m)
+			metrics.DownloaderFailures.WithLabelValues(metrics.FailureReported).Inc()
 			return
 		}
 	}
 }
 
-func parseChildMessage(message []byte) (map[string]interface{}, interface{}, error) {
-	var parsedMessage map[string]interface{}
-	err := json.Unmarshal(message, &parsedMessage)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal message from script: %v", err)
+// childFailure is sent when the script output is broken, the failure
+// reason is completed with stderr once the script is stopped
+type childFailure struct {
+	reason string
+	// The script is gone and does not need to be killed
+	exited bool
+}
+
+// protocolFailure fails the download when the script output is broken
+func (d *YtDownloader) protocolFailure(reason string, exited bool) {
+	metrics.DownloaderFailures.WithLabelValues(metrics.FailureProtocol).Inc()
+	d.child_out <- &childFailure{reason: reason, exited: exited}
+}
+
+// failureReason adds the last line the script wrote to stderr,
+// it is usually the exception which broke the script
+func failureReason(reason string, stderr *stderrTail) string {
+	line := stderr.LastLine()
+	if line == "" {
+		return fmt.Sprintf("downloading failed: %v", reason)
 	}
 
-	t, ok := parsedMessage["type"]
-	if !ok {
-		return nil, nil, fmt.Errorf("message from does not contain \"type\" field")
+	return fmt.Sprintf("downloading failed: %v: %v", reason, line)
+}
+
+const (
+	// Only the end of stderr is kept, it holds the reason of a crash
+	stderrTailSize  = 4096
+	stderrWaitDelay = 5 * time.Second
+)
+
+// stderrTail keeps the last bytes written to it
+type stderrTail struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (s *stderrTail) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data = append(s.data, p...)
+	if len(s.data) > stderrTailSize {
+		s.data = s.data[len(s.data)-stderrTailSize:]
 	}
 
-	return parsedMessage, t, nil
+	return len(p), nil
+}
+
+func (s *stderrTail) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return string(s.data)
+}
+
+// LastLine returns the last line which is not blank
+func (s *stderrTail) LastLine() string {
+	lines := strings.Split(strings.TrimSpace(s.String()), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
 }
@@ -0,0 +1,321 @@
+//go:build !windows
+
+package downloaders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	dmocks "uv_server/internal/uv_server/business/data/mocks"
+	businessData "uv_server/internal/uv_server/business/workflows/downloading/data"
+	"uv_server/internal/uv_server/config"
+)
+
+// The fake downloader takes the arguments of the real one, the body
+// of the script writes messages and can use the temp directory as $dir
+const fakeDownloaderPreamble = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+		--dir) dir="$2"; shift 2 ;;
+		*) shift ;;
+	esac
+done
+`
+
+const helloLine = `echo '{"type": "hello", "version": 1, "tool_version": "fake"}'`
+
+func newFakeDownloader(t *testing.T, script string) (*YtDownloader, chan interface{}) {
+	home := t.TempDir()
+
+	tools := filepath.Join(home, "tools")
+	require.NoError(t, os.MkdirAll(tools, 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tools, "downloader"),
+		[]byte(fakeDownloaderPreamble+script),
+		0755))
+
+	wfOut := make(chan interface{}, 16)
+	toClean := make(chan string, 1)
+
+	d := &YtDownloader{}
+	d.uuid = "uuid"
+	d.log = logrus.New().WithField("layer", "Data")
+	d.config = &config.Config{HomeDir: home, ToolsLocation: tools}
+	d.jobCtx = context.Background()
+	d.wf_out = wfOut
+	d.child_out = make(chan interface{}, 1)
+	d.to_clean = toClean
+
+	database := dmocks.NewMockDatabase(t)
+	database.EXPECT().IsPathTaken(mock.Anything).Return(false, nil).Maybe()
+	d.database = database
+
+	return d, wfOut
+}
+
+// download runs the fake downloader and returns every message it
+// produced, the last one is either Done or Error
+func download(t *testing.T, d *YtDownloader, wfOut chan interface{}) []interface{} {
+	options := &businessData.Options{
+		StorageDir:       filepath.Join(d.config.HomeDir, "storage"),
+		Format:           "mp3",
+		FilenameTemplate: "{uploader} - {title}",
+		Source:           "youtube",
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go d.Download(&wg, "https://www.youtube.com/watch?v=2AB3_l0iqSk", options)
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		t.Fatal("downloader did not finish")
+	}
+
+	close(wfOut)
+
+	messages := []interface{}{}
+	for msg := range wfOut {
+		messages = append(messages, msg)
+	}
+
+	require.NotEmpty(t, messages)
+
+	return messages
+}
+
+func TestDownload_Done(t *testing.T) {
+	d, wfOut := newFakeDownloader(t, helloLine+`
+echo '{"type": "progress", "phase": "fetching_metadata"}'
+echo '{"type": "log", "level": "info", "msg": "[youtube] extracting"}'
+echo '{"type": "log", "level": "debug", "msg": "[debug] details"}'
+echo '{"type": "warning", "msg": "format is not available"}'
+echo '{"type": "progress", "phase": "downloading", "percentage": 50, "downloaded_bytes": 5, "total_bytes": 10, "speed": 2.5, "eta": 2}'
+printf 'audio' > "$dir/song.mp3"
+echo '{"type": "progress", "phase": "post_processing"}'
+echo '{"type": "metadata", "title": "Song", "uploader": "Band", "id": "2AB3_l0iqSk", "upload_date": "20240101", "duration": 61.5}'
+printf '{"type": "done", "filename": "%s/song.mp3"}' "$dir"
+`)
+
+	messages := download(t, d, wfOut)
+
+	assert.Equal(t, []interface{}{
+		&businessData.Progress{Phase: businessData.PhaseFetchingMetadata},
+		&businessData.Progress{
+			Phase:           businessData.PhaseDownloading,
+			Percentage:      50,
+			DownloadedBytes: 5,
+			TotalBytes:      10,
+			Speed:           2.5,
+			Eta:             2,
+		},
+		&businessData.Progress{Phase: businessData.PhasePostProcessing},
+		&businessData.Progress{Phase: businessData.PhaseFinalizing},
+	}, messages[:len(messages)-1])
+
+	done, ok := messages[len(messages)-1].(*businessData.Done)
+	require.True(t, ok, "expected Done, got %#v", messages[len(messages)-1])
+	assert.Equal(t, "Band - Song.mp3", done.Filename)
+	assert.Equal(t, int64(len("audio")), done.Size)
+	assert.NotEmpty(t, done.Checksum)
+	assert.Equal(t, "Song", done.Title)
+	assert.Equal(t, "Band", done.Uploader)
+	assert.Equal(t, "2AB3_l0iqSk", done.VideoId)
+	assert.Equal(t, "20240101", done.UploadDate)
+	assert.Equal(t, 61.5, done.Duration)
+
+	assert.FileExists(t, filepath.Join(d.config.HomeDir, "storage", "Band - Song.mp3"))
+}
+
+func TestDownload_PathOfTrashedFileIsSkipped(t *testing.T) {
+	d, wfOut := newFakeDownloader(t, helloLine+`
+echo '{"type": "metadata", "title": "Song", "uploader": "Band", "id": "2AB3_l0iqSk", "upload_date": "20240101", "duration": 61.5}'
+printf 'audio' > "$dir/song.mp3"
+printf '{"type": "done", "filename": "%s/song.mp3"}' "$dir"
+`)
+
+	// The trashed file is not on disk, but its row keeps the path
+	database := dmocks.NewMockDatabase(t)
+	database.EXPECT().IsPathTaken("Band - Song.mp3").Return(true, nil)
+	database.EXPECT().IsPathTaken("Band - Song (1).mp3").Return(false, nil)
+	d.database = database
+
+	messages := download(t, d, wfOut)
+
+	done, ok := messages[len(messages)-1].(*businessData.Done)
+	require.True(t, ok, "expected Done, got %#v", messages[len(messages)-1])
+	assert.Equal(t, "Band - Song (1).mp3", done.Filename)
+}
+
+func TestDownload_Failures(t *testing.T) {
+	testData := []struct {
+		name   string
+		script string
+		reason string
+	}{
+		{
+			name: "reported",
+			script: helloLine + `
+echo '{"type": "failed", "msg": "Video unavailable"}'
+`,
+			reason: "Video unavailable",
+		},
+		{
+			name: "crash",
+			script: helloLine + `
+echo 'Traceback (most recent call last):' >&2
+echo 'ValueError: boom' >&2
+exit 1
+`,
+			reason: "downloading failed: downloader exited without a result: ValueError: boom",
+		},
+		{
+			name: "no hello",
+			script: `
+echo '{"type": "progress", "phase": "downloading", "percentage": 1}'
+`,
+			reason: "downloading failed: expected a hello message first",
+		},
+		{
+			name: "repeated hello",
+			script: helloLine + `
+` + helloLine + `
+`,
+			reason: "downloading failed: unexpected hello message",
+		},
+		{
+			name: "unsupported version",
+			script: `
+echo '{"type": "hello", "version": 2, "tool_version": "fake"}'
+`,
+			reason: "downloading failed: unsupported downloader protocol version 2, expected 1",
+		},
+		{
+			name: "unknown type",
+			script: helloLine + `
+echo '{"type": "unknown"}'
+`,
+			reason: `downloading failed: unknown message type "unknown"`,
+		},
+		{
+			name: "unknown field",
+			script: helloLine + `
+echo '{"type": "done", "filename": "song.mp3", "size": 1}'
+`,
+			reason: `downloading failed: invalid done message: json: unknown field "size"`,
+		},
+		{
+			name: "wrong field type",
+			script: helloLine + `
+echo '{"type": "progress", "phase": "downloading", "percentage": "50"}'
+`,
+			reason: "downloading failed: invalid progress message: json: " +
+				"cannot unmarshal string into Go struct field ProgressMessage.percentage of type float64",
+		},
+		{
+			name: "unknown phase",
+			script: helloLine + `
+echo '{"type": "progress", "phase": "uploading"}'
+`,
+			reason: `downloading failed: invalid progress message: unknown phase "uploading"`,
+		},
+		{
+			name: "unknown log level",
+			script: helloLine + `
+echo '{"type": "log", "level": "trace", "msg": "message"}'
+`,
+			reason: `downloading failed: invalid log message: unknown level "trace"`,
+		},
+		{
+			name: "missing filename",
+			script: helloLine + `
+echo '{"type": "done"}'
+`,
+			reason: `downloading failed: invalid done message: "filename" is empty`,
+		},
+		{
+			name: "missing reason",
+			script: helloLine + `
+echo '{"type": "failed"}'
+`,
+			reason: `downloading failed: invalid failed message: "msg" is empty`,
+		},
+		{
+			name: "no type",
+			script: `
+echo '{"version": 1}'
+`,
+			reason: `downloading failed: message does not contain a "type" field`,
+		},
+		{
+			name: "not json",
+			script: `
+echo 'No module named yt_dlp' >&2
+echo 'hello'
+`,
+			reason: "downloading failed: invalid message: invalid character 'h' " +
+				"looking for beginning of value: No module named yt_dlp",
+		},
+		{
+			name: "missing file",
+			script: helloLine + `
+echo '{"type": "done", "filename": "missing.mp3"}'
+`,
+			reason: "failed to store downloaded file",
+		},
+	}
+
+	for _, entry := range testData {
+		t.Run(entry.name, func(t *testing.T) {
+			d, wfOut := newFakeDownloader(t, entry.script)
+
+			messages := download(t, d, wfOut)
+
+			last := messages[len(messages)-1]
+			failure, ok := last.(*businessData.Error)
+			require.True(t, ok, "expected Error, got %#v", last)
+			assert.Equal(t, entry.reason, failure.Reason)
+		})
+	}
+}
+
+func TestDownload_Cancelled(t *testing.T) {
+	d, wfOut := newFakeDownloader(t, helloLine+`
+echo '{"type": "progress", "phase": "fetching_metadata"}'
+sleep 30
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.jobCtx = ctx
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go d.Download(&wg, "url", &businessData.Options{StorageDir: "storage", Format: "mp3"})
+
+	msg := <-wfOut
+	assert.Equal(t, &businessData.Progress{Phase: businessData.PhaseFetchingMetadata}, msg)
+
+	startedAt := time.Now()
+	cancel()
+	wg.Wait()
+
+	// The script is killed instead of being waited for
+	assert.Less(t, time.Since(startedAt), 10*time.Second)
+	assert.Empty(t, wfOut)
+}
@@ -24,9 +24,7 @@ func NewFileCleaner(to_clean chan string) *FileCleaner {
 	return object
 }
 
-func (f *FileCleaner) InitializeAndCleanDirectories() {
-	storagePath := "storage"
-	tmpPath := "tmp"
+func (f *FileCleaner) InitializeAndCleanDirectories(storagePath string, tmpPath string) {
 	f.log.Info("initializing directories")
 	if err := f.createDirIfNotExists(storagePath); err != nil {
 		f.log.Fatalf("failed to create storage directory: %v", err)
@@ -43,7 +41,7 @@ func (f *FileCleaner) InitializeAndCleanDirectories() {
 func (f *FileCleaner) createDirIfNotExists(path string) error {
 	info, err := os.Stat(path)
 	if err != nil && errors.Is(err, fs.ErrNotExist) {
-		return os.Mkdir(path, 0755)
+		return os.MkdirAll(path, 0755)
 	} else if err != nil {
 		f.log.Fatalf("failed to stat file %v", err)
 	}
@@ -0,0 +1,204 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"uv_server/internal/uv_server/business/data"
+)
+
+var ErrDestinationExists = errors.New("destination already exists")
+
+// HashFile returns size and hex encoded SHA-256 of the file
+func HashFile(path string) (*data.FileDigest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.FileDigest{
+		Size:     size,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// FinalizeFile moves a file into its final location and verifies that
+// the content has not changed on the way, existing files are never replaced.
+// The source is kept when the verification fails
+func FinalizeFile(src string, dst string) (*data.FileDigest, error) {
+	expected, err := HashFile(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash source file: %w", err)
+	}
+
+	var actual *data.FileDigest
+
+	err = moveFile(src, dst, func(path string) error {
+		actual, err = HashFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash destination file: %w", err)
+		}
+
+		if *actual != *expected {
+			return fmt.Errorf(
+				"expected %v bytes with checksum %v, got %v bytes with checksum %v",
+				expected.Size, expected.Checksum, actual.Size, actual.Checksum)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return actual, nil
+}
+
+// moveFile renames the file when possible, otherwise the file is copied
+// next to the destination, synced to disk and renamed into place.
+// When verify is given, the placed file is checked while the source
+// still exists, the source is removed only after the check passes
+func moveFile(src string, dst string, verify func(path string) error) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	if verify == nil {
+		err = placeFile(src, dst)
+		if err == nil || errors.Is(err, ErrDestinationExists) {
+			return err
+		}
+	} else {
+		err = linkVerified(src, dst, verify)
+		if err == nil {
+			return os.Remove(src)
+		}
+
+		if errors.Is(err, ErrDestinationExists) || errors.Is(err, errVerification) {
+			return err
+		}
+	}
+
+	tmp, err := copyNextTo(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if verify != nil {
+		err = verify(tmp)
+		if err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("%w: %w", errVerification, err)
+		}
+	}
+
+	err = placeFile(tmp, dst)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+var errVerification = errors.New("verification failed")
+
+// linkVerified hard links src at dst and verifies the link, the link
+// is removed when the verification fails
+func linkVerified(src string, dst string, verify func(path string) error) error {
+	err := os.Link(src, dst)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %v", ErrDestinationExists, dst)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = verify(dst)
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("%w: %w", errVerification, err)
+	}
+
+	return nil
+}
+
+// placeFile atomically puts src at dst on the same volume without
+// replacing an existing dst, hard links are used for that purpose
+// with a fallback to rename on filesystems which do not support them
+func placeFile(src string, dst string) error {
+	err := os.Link(src, dst)
+	if err == nil {
+		return os.Remove(src)
+	}
+
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %v", ErrDestinationExists, dst)
+	}
+
+	_, statErr := os.Lstat(dst)
+	if statErr == nil {
+		return fmt.Errorf("%w: %v", ErrDestinationExists, dst)
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return statErr
+	}
+
+	return os.Rename(src, dst)
+}
+
+func copyNextTo(src string, dst string) (string, error) {
+	source, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	destination, err := os.CreateTemp(
+		filepath.Dir(dst), "."+filepath.Base(dst)+".*.part")
+	if err != nil {
+		return "", err
+	}
+
+	tmp := destination.Name()
+
+	_, err = io.Copy(destination, source)
+	if err == nil {
+		err = destination.Sync()
+	}
+
+	closeErr := destination.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return tmp, nil
+}
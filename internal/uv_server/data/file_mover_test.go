@@ -0,0 +1,50 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinalizeFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "tmp", "song.mp3")
+	dst := filepath.Join(dir, "storage", "song.mp3")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(src), 0755))
+	require.NoError(t, os.WriteFile(src, []byte("audio"), 0644))
+
+	digest, err := FinalizeFile(src, dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(len("audio")), digest.Size)
+	assert.NoFileExists(t, src)
+	assert.FileExists(t, dst)
+}
+
+func TestMoveFile_VerificationFailedKeepsSource(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "song.mp3")
+	dst := filepath.Join(dir, "storage", "song.mp3")
+
+	require.NoError(t, os.WriteFile(src, []byte("audio"), 0644))
+
+	verified := ""
+	err := moveFile(src, dst, func(path string) error {
+		verified = path
+		return errors.New("checksum mismatch")
+	})
+
+	assert.ErrorIs(t, err, errVerification)
+	assert.NotEmpty(t, verified)
+	assert.NoFileExists(t, verified)
+
+	content, err := os.ReadFile(src)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(content))
+	assert.NoFileExists(t, dst)
+}
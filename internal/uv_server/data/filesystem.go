@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"uv_server/internal/uv_server/business/data"
 	"uv_server/internal/uv_server/common/loggers"
 
 	"github.com/sirupsen/logrus"
@@ -43,3 +46,151 @@ func (f *Filesystem) DeleteLocalFile(path string) error {
 
 	return os.Remove(path)
 }
+
+func (f *Filesystem) DeleteDirectory(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (f *Filesystem) EnsureDirectory(path string) error {
+	info, err := os.Stat(path)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return os.MkdirAll(path, 0755)
+	} else if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("path exists and it's not a directory: %v", path)
+	}
+
+	return nil
+}
+
+// ValidateDirectory checks that the directory exists and
+// it is possible to create files inside of it
+func (f *Filesystem) ValidateDirectory(path string) error {
+	info, err := os.Stat(path)
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("directory does not exist: %v", path)
+	} else if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("path is not a directory: %v", path)
+	}
+
+	probe, err := os.CreateTemp(path, ".uv_probe_*")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %v", path)
+	}
+
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// MoveFile moves a file creating missing parent directories of the
+// destination, existing files are never replaced
+func (f *Filesystem) MoveFile(src string, dst string) error {
+	return moveFile(src, dst, nil)
+}
+
+// CopyFile copies a file creating missing parent directories of the
+// destination, existing files are never replaced
+func (f *Filesystem) CopyFile(src string, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := copyNextTo(src, dst)
+	if err != nil {
+		return err
+	}
+
+	err = placeFile(tmp, dst)
+	if err != nil {
+		os.Remove(tmp)
+	}
+
+	return err
+}
+
+func (f *Filesystem) Exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func (f *Filesystem) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (f *Filesystem) WriteFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+func (f *Filesystem) HashFile(path string) (*data.FileDigest, error) {
+	return HashFile(path)
+}
+
+func (f *Filesystem) ListFiles(root string) ([]string, error) {
+	files := []string{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+
+	return files, err
+}
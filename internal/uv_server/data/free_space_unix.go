@@ -0,0 +1,17 @@
+//go:build !windows
+
+package data
+
+import "syscall"
+
+// freeSpace reports the space available to unprivileged users
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
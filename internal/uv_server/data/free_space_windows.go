@@ -0,0 +1,23 @@
+//go:build windows
+
+package data
+
+import "golang.org/x/sys/windows"
+
+// freeSpace reports the space available to the user of the server,
+// disk quotas are taken into account
+func freeSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var available, total, free uint64
+
+	err = windows.GetDiskFreeSpaceEx(pathPtr, &available, &total, &free)
+	if err != nil {
+		return 0, err
+	}
+
+	return available, nil
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"uv_server/internal/uv_server/common/loggers"
+)
+
+type LogLevels struct {
+	log *logrus.Entry
+}
+
+func NewLogLevels() *LogLevels {
+	object := &LogLevels{}
+	object.log = loggers.DataLogger.WithField("component", "LogLevels")
+	return object
+}
+
+func (l *LogLevels) SetLevel(layer string, level string) error {
+	parsedLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	layers := loggers.Layers
+	if layer != "" {
+		parsedLayer, err := loggers.ParseLayer(layer)
+		if err != nil {
+			return err
+		}
+		layers = []loggers.Layer{parsedLayer}
+	}
+
+	for _, layer := range layers {
+		loggers.SetLevel(layer, parsedLevel)
+		l.log.Infof("log level of %v layer is %v", layer, parsedLevel)
+	}
+
+	return nil
+}
+
+func (l *LogLevels) GetLevels() map[string]string {
+	levels := map[string]string{}
+	for layer, level := range loggers.GetLevels() {
+		levels[string(layer)] = level.String()
+	}
+
+	return levels
+}
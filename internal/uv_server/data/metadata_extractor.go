@@ -0,0 +1,101 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"uv_server/internal/uv_server/business/data"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataExtractor reads tags of media files with ffprobe shipped
+// alongside ffmpeg
+type MetadataExtractor struct {
+	log    *logrus.Entry
+	config *config.Config
+}
+
+func NewMetadataExtractor(config *config.Config) *MetadataExtractor {
+	object := &MetadataExtractor{}
+
+	object.log = loggers.DataLogger.
+		WithField("component", "MetadataExtractor")
+	object.config = config
+
+	return object
+}
+
+type probeOutput struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// Extract never fails because of missing tags, the file name is used
+// as a title when the file does not have one
+func (e *MetadataExtractor) Extract(path string) (*data.MediaMetadata, error) {
+	metadata := &data.MediaMetadata{
+		Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+	}
+
+	output, err := exec.Command(
+		e.probeExecutable(),
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		path,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe file: %w", err)
+	}
+
+	probe := &probeOutput{}
+	err = json.Unmarshal(output, probe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse probe output: %w", err)
+	}
+
+	// Tag names differ in case between containers
+	tags := make(map[string]string, len(probe.Format.Tags))
+	for key, value := range probe.Format.Tags {
+		tags[strings.ToLower(key)] = strings.TrimSpace(value)
+	}
+
+	if tags["title"] != "" {
+		metadata.Title = tags["title"]
+	}
+
+	if tags["artist"] != "" {
+		metadata.Artist = tags["artist"]
+	} else {
+		metadata.Artist = tags["album_artist"]
+	}
+
+	if probe.Format.Duration != "" {
+		duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+		if err != nil {
+			e.log.Warnf("unexpected duration %v: %v", probe.Format.Duration, err)
+		} else {
+			metadata.Duration = duration
+		}
+	}
+
+	return metadata, nil
+}
+
+func (e *MetadataExtractor) probeExecutable() string {
+	name := "ffprobe"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return filepath.Join(e.config.ResolvePath(e.config.FfmpegLocation), name)
+}
@@ -1,31 +1,69 @@
 package data
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
-	"path"
-	"path/filepath"
+	"io/fs"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
+	uvdb "uv_server/db"
 	"uv_server/internal/uv_server/common/loggers"
 	"uv_server/internal/uv_server/config"
 )
 
-var db_version int = 3
+var migrationFilename = regexp.MustCompile(`^(\d+)_(.+?)(\.down)?\.sql$`)
 
+type migration struct {
+	version int
+	name    string
+	up      string
+	// Empty when the migration can not be reverted
+	down     string
+	checksum string
+}
+
+// MigrationStep is a single migration planned to be applied or reverted
+type MigrationStep struct {
+	Version    int
+	Name       string
+	Down       bool
+	Statements []string
+}
+
+type appliedMigration struct {
+	version  int
+	checksum string
+}
+
+// DbMigrator applies migrations embedded into the binary, every
+// migration runs in its own transaction together with the version
+// bump and is recorded with a checksum in schema_migrations table
 type DbMigrator struct {
-	log        *logrus.Entry
-	config     *config.Config
-	db         *sql.DB
-	changesets map[int]func() error
+	log     *logrus.Entry
+	config  *config.Config
+	db      *sql.DB
+	backups *DbBackups
+	// Migration of version N is at index N-1
+	migrations []migration
 }
 
 func NewDbMigrator(config *config.Config, db *sql.DB) *DbMigrator {
+	migrations, err := fs.Sub(uvdb.Migrations, "migrations")
+	if err != nil {
+		loggers.DataLogger.Fatal(err)
+	}
+
+	return newDbMigrator(config, db, migrations)
+}
+
+func newDbMigrator(config *config.Config, db *sql.DB, migrations fs.FS) *DbMigrator {
 	object := &DbMigrator{}
 
 	object.log = loggers.DataLogger.
@@ -33,13 +71,95 @@ func NewDbMigrator(config *config.Config, db *sql.DB) *DbMigrator {
 	object.config = config
 
 	object.db = db
-	object.changesets = make(map[int]func() error)
-	object.registerChangesets(config.ChangesetsLocation)
+	object.backups = NewDbBackups(config, db)
+
+	var err error
+	object.migrations, err = loadMigrations(migrations)
+	if err != nil {
+		object.log.Fatal(err)
+	}
 
 	return object
 }
 
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+
+	for _, e := range entries {
+		match := migrationFilename.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration filename: %v", e.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %v", e.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		// Line endings depend on the checkout
+		script := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+
+		if match[3] != "" {
+			m.down = script
+			continue
+		}
+
+		if m.up != "" {
+			return nil, fmt.Errorf("duplicate migration version: %v", e.Name())
+		}
+
+		sum := sha256.Sum256([]byte(script))
+
+		m.name = match[2]
+		m.up = script
+		m.checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]migration, len(byVersion))
+
+	for i := range migrations {
+		m, ok := byVersion[i+1]
+		if !ok || m.up == "" {
+			return nil, fmt.Errorf("migration %v is missing", i+1)
+		}
+
+		migrations[i] = *m
+	}
+
+	return migrations, nil
+}
+
+// RequiredVersion is the version of the latest embedded migration
+func (m *DbMigrator) RequiredVersion() int {
+	return len(m.migrations)
+}
+
 func (m *DbMigrator) GetVersion() (int, error) {
+	applied, _, err := m.getApplied()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(applied), nil
+}
+
+func (m *DbMigrator) tableExists(name string) (bool, error) {
 	statement := `
 	SELECT name FROM sqlite_master
 	WHERE type='table'
@@ -49,154 +169,382 @@ func (m *DbMigrator) GetVersion() (int, error) {
 	m.log.Debugf("executing statement: %v", statement)
 
 	var dummy interface{}
-	err := m.db.QueryRow(statement, "app").Scan(&dummy)
+	err := m.db.QueryRow(statement, name).Scan(&dummy)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, nil
-		}
+		return false,
+			fmt.Errorf("failed to check for %v table existance: %v", name, err)
+	}
+
+	return true, nil
+}
 
-		return 0,
-			fmt.Errorf("failed to check for %v table existance: %v",
-				"app",
-				err)
+// getApplied returns applied migrations ordered by version, databases
+// created before schema_migrations table are described by the version
+// in app table and reported as legacy
+func (m *DbMigrator) getApplied() ([]appliedMigration, bool, error) {
+	exists, err := m.tableExists("schema_migrations")
+	if err != nil {
+		return nil, false, err
+	}
+
+	if exists {
+		applied, err := m.querySchemaMigrations()
+		return applied, false, err
 	}
 
-	statement = `
-	SELECT db_version FROM app; 
+	exists, err = m.tableExists("app")
+	if err != nil || !exists {
+		return []appliedMigration{}, false, err
+	}
+
+	statement := `
+	SELECT db_version FROM app;
 	`
 
-	m.log.Debugf("Executing statement: %v", statement)
+	m.log.Debugf("executing statement: %v", statement)
 
 	var version int
 	err = m.db.QueryRow(statement).Scan(&version)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return []appliedMigration{}, false, nil
+	}
+
 	if err != nil {
-		return 0, fmt.Errorf("failed to get app version: %v", err)
+		return nil, false, fmt.Errorf("failed to get app version: %v", err)
+	}
+
+	if version > len(m.migrations) {
+		return nil, false, fmt.Errorf(
+			"database version %v is newer than supported %v", version, len(m.migrations))
+	}
+
+	// Legacy migrations are trusted to match embedded ones
+	applied := make([]appliedMigration, 0, version)
+	for _, migration := range m.migrations[:version] {
+		applied = append(applied, appliedMigration{
+			version:  migration.version,
+			checksum: migration.checksum,
+		})
 	}
 
-	return version, nil
+	return applied, true, nil
 }
 
-func (m *DbMigrator) setDbVersion(version int) error {
+func (m *DbMigrator) querySchemaMigrations() ([]appliedMigration, error) {
 	statement := `
-	INSERT OR REPLACE INTO app (id, db_version)
-	VALUES (1, ?);
+	SELECT version, checksum FROM schema_migrations
+	ORDER BY version;
 	`
 
-	_, err := m.db.Exec(statement, version)
+	m.log.Debugf("executing statement: %v", statement)
 
+	rows, err := m.db.Query(statement)
 	if err != nil {
-		return fmt.Errorf("failed to set db version: %v", err)
+		return nil, fmt.Errorf("failed to get applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := []appliedMigration{}
+
+	for rows.Next() {
+		var migration appliedMigration
+
+		err = rows.Scan(&migration.version, &migration.checksum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan applied migrations: %v", err)
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return applied, rows.Err()
+}
+
+// verify makes sure applied migrations are the embedded ones
+func (m *DbMigrator) verify(applied []appliedMigration) error {
+	for i, migration := range applied {
+		if migration.version != i+1 {
+			return fmt.Errorf("migration %v is not applied", i+1)
+		}
+
+		if migration.version > len(m.migrations) {
+			return fmt.Errorf(
+				"database version %v is newer than supported %v",
+				applied[len(applied)-1].version,
+				len(m.migrations))
+		}
+
+		if migration.checksum != m.migrations[i].checksum {
+			return fmt.Errorf(
+				"migration %v_%v was modified after being applied",
+				migration.version,
+				m.migrations[i].name)
+		}
 	}
 
 	return nil
 }
 
-func (m *DbMigrator) executeFile(path string) error {
-	content, err := os.ReadFile(path)
+// Plan returns migrations to be applied or reverted to get to
+// the target version
+func (m *DbMigrator) Plan(target int) ([]MigrationStep, error) {
+	applied, _, err := m.getApplied()
 	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
+		return nil, err
 	}
 
-	queries := strings.Split(string(content), ";")
-
-	tx, err := m.db.Begin()
+	err = m.verify(applied)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, query := range queries {
-		query = strings.TrimSpace(query)
-		if query == "" {
-			continue
-		}
+	return m.plan(len(applied), target)
+}
 
-		_, err := m.db.Exec(query)
-		if err != nil {
-			return fmt.Errorf("error executing query %q: %w", query, err)
-		}
+func (m *DbMigrator) plan(current int, target int) ([]MigrationStep, error) {
+	if target < 0 || target > len(m.migrations) {
+		return nil, fmt.Errorf(
+			"target version %v is out of range [0, %v]", target, len(m.migrations))
 	}
 
-	return tx.Commit()
-}
+	steps := []MigrationStep{}
+
+	for v := current + 1; v <= target; v++ {
+		migration := m.migrations[v-1]
 
-func (m *DbMigrator) parseFileVersion(filename string) (int, error) {
-	base := filepath.Base(filename)
+		steps = append(steps, MigrationStep{
+			Version:    v,
+			Name:       migration.name,
+			Statements: splitStatements(migration.up),
+		})
+	}
 
-	re := regexp.MustCompile(`^(\d+)`)
-	match := re.FindStringSubmatch(base)
+	for v := current; v > target; v-- {
+		migration := m.migrations[v-1]
 
-	if len(match) < 2 {
-		return 0, fmt.Errorf("invalid filename format: %s", filename)
+		if migration.down == "" {
+			return nil, fmt.Errorf(
+				"migration %v_%v can not be reverted", v, migration.name)
+		}
+
+		steps = append(steps, MigrationStep{
+			Version:    v,
+			Name:       migration.name,
+			Down:       true,
+			Statements: splitStatements(migration.down),
+		})
 	}
 
-	version, err := strconv.Atoi(match[1])
+	return steps, nil
+}
+
+// Migrate applies or reverts migrations to get to the target version,
+// the database is restored from a backup when a migration fails
+func (m *DbMigrator) Migrate(target int) error {
+	applied, legacy, err := m.getApplied()
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse version number: %w", err)
+		return err
 	}
 
-	return version, nil
-}
+	err = m.verify(applied)
+	if err != nil {
+		return err
+	}
+
+	current := len(applied)
 
-func (m *DbMigrator) registerChangesets(dir string) {
-	entries, err := os.ReadDir(dir)
+	m.log.Infof("current db version: %v", current)
+	m.log.Infof("target db version: %v", target)
 
+	steps, err := m.plan(current, target)
 	if err != nil {
-		m.log.Fatal(err)
+		return err
 	}
 
-	for _, e := range entries {
-		m.log.Tracef("registering changeset: %v", e.Name())
+	err = m.ensureSchemaMigrations(applied, legacy)
+	if err != nil {
+		return err
+	}
 
-		ver, err := m.parseFileVersion(e.Name())
+	if len(steps) == 0 {
+		m.log.Info("migration is not needed")
+		return nil
+	}
+
+	m.log.Infof("migrating from %v to %v", current, target)
 
+	// A new database has nothing to lose
+	backup := ""
+	if current != 0 {
+		backup, err = m.backups.Backup(fmt.Sprintf("v%v", current))
 		if err != nil {
-			m.log.Fatal(err)
+			return fmt.Errorf("failed to backup database before migration: %v", err)
 		}
+	}
 
-		m.changesets[ver] = func() error {
-			return m.executeFile(path.Join(dir, e.Name()))
+	for _, step := range steps {
+		err = m.apply(&step)
+		if err != nil {
+			m.restore(backup)
+			return err
 		}
 	}
+
+	return nil
 }
 
 func (m *DbMigrator) MigrateIfNeeded() {
 	m.log.Trace("checking for migration")
 
-	ver, err := m.GetVersion()
+	err := m.Migrate(m.RequiredVersion())
+	if err != nil {
+		m.log.Fatal(err)
+	}
+}
+
+// ensureSchemaMigrations creates schema_migrations table and records
+// migrations applied before it existed
+func (m *DbMigrator) ensureSchemaMigrations(
+	applied []appliedMigration,
+	legacy bool,
+) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statement := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`
 
-	m.log.Infof("current db version: %v", ver)
-	m.log.Infof("required db version: %v", db_version)
+	m.log.Debugf("executing statement: %v", statement)
 
+	_, err = tx.Exec(statement)
 	if err != nil {
-		m.log.Fatal(err)
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
 	}
 
-	if db_version == ver {
-		m.log.Info("migration is not needed")
-		return
+	if legacy {
+		for _, migration := range applied {
+			err = m.recordMigration(tx, migration.version)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	if db_version < ver {
-		m.log.Fatalf("migration from %v to %v is impossible", ver, db_version)
+	return tx.Commit()
+}
+
+func (m *DbMigrator) apply(step *MigrationStep) error {
+	direction := "applying"
+	if step.Down {
+		direction = "reverting"
 	}
 
-	m.log.Infof("Migrating from %v to %v", ver, db_version)
+	m.log.Infof("%v migration %v_%v", direction, step.Version, step.Name)
 
-	for v := ver; v < db_version; v++ {
-		nextVer := v + 1
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-		m.log.Infof("migrating to %v", nextVer)
+	for _, statement := range step.Statements {
+		m.log.Debugf("executing statement: %v", statement)
 
-		err := m.changesets[nextVer]()
+		_, err = tx.Exec(statement)
 		if err != nil {
-			m.log.Fatalf("migration to %v has failed: %v", nextVer, err)
+			return fmt.Errorf(
+				"%v migration %v has failed on %q: %w", direction, step.Version, statement, err)
 		}
+	}
 
-		err = m.setDbVersion(nextVer)
-		if err != nil {
-			m.log.Fatalf("migration to %v has failed: %v", nextVer, err)
-		}
+	if step.Down {
+		err = m.forgetMigration(tx, step.Version)
+	} else {
+		err = m.recordMigration(tx, step.Version)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *DbMigrator) recordMigration(tx *sql.Tx, version int) error {
+	statement := `
+	INSERT INTO schema_migrations (version, name, checksum)
+	VALUES (?, ?, ?);
+	`
+
+	migration := m.migrations[version-1]
+
+	_, err := tx.Exec(statement, version, migration.name, migration.checksum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %v: %v", version, err)
+	}
+
+	return m.setDbVersion(tx, version)
+}
+
+func (m *DbMigrator) forgetMigration(tx *sql.Tx, version int) error {
+	statement := `
+	DELETE FROM schema_migrations
+	WHERE version = ?;
+	`
+
+	_, err := tx.Exec(statement, version)
+	if err != nil {
+		return fmt.Errorf("failed to forget migration %v: %v", version, err)
+	}
+
+	// The first migration creates app table
+	if version == 1 {
+		return nil
+	}
+
+	return m.setDbVersion(tx, version-1)
+}
+
+// setDbVersion keeps app table in sync for older versions of the server
+func (m *DbMigrator) setDbVersion(tx *sql.Tx, version int) error {
+	statement := `
+	INSERT OR REPLACE INTO app (id, db_version)
+	VALUES (1, ?);
+	`
+
+	_, err := tx.Exec(statement, version)
+
+	if err != nil {
+		return fmt.Errorf("failed to set db version: %v", err)
+	}
+
+	return nil
+}
+
+// restore brings back the database state from before the migration,
+// so the previous version of the application keeps working
+func (m *DbMigrator) restore(backup string) {
+	if backup == "" {
+		return
+	}
+
+	err := m.backups.Restore(backup)
+	if err != nil {
+		m.log.Errorf("failed to restore database from %v: %v", backup, err)
 	}
 }
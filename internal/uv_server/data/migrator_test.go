@@ -0,0 +1,207 @@
+package data
+
+import (
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	uvdb "uv_server/db"
+	"uv_server/internal/uv_server/config"
+)
+
+const appTable = `
+CREATE TABLE app (
+	id INTEGER PRIMARY KEY,
+	db_version INTEGER NOT NULL
+);
+`
+
+func newTestMigrator(t *testing.T, db *sql.DB, migrations fs.FS) *DbMigrator {
+	home := t.TempDir()
+	log := logrus.New().WithField("layer", "Data")
+
+	m := &DbMigrator{}
+	m.log = log
+	m.config = &config.Config{HomeDir: home, BackupsLocation: "backups"}
+	m.db = db
+
+	m.backups = &DbBackups{}
+	m.backups.log = log
+	m.backups.config = m.config
+	m.backups.db = db
+	m.backups.dir = filepath.Join(home, "backups")
+
+	var err error
+	m.migrations, err = loadMigrations(migrations)
+	require.NoError(t, err)
+
+	return m
+}
+
+func newMemoryDb(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// Every connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", name,
+	).Scan(&count)
+	require.NoError(t, err)
+
+	return count == 1
+}
+
+func TestMigrate_EmbeddedMigrations(t *testing.T) {
+	db := newMemoryDb(t)
+
+	migrations, err := fs.Sub(uvdb.Migrations, "migrations")
+	require.NoError(t, err)
+
+	m := newTestMigrator(t, db, migrations)
+
+	require.NoError(t, m.Migrate(m.RequiredVersion()))
+	assert.True(t, tableExists(t, db, "playlists"))
+
+	require.NoError(t, m.Migrate(4))
+	assert.False(t, tableExists(t, db, "playlists"))
+
+	version, err := m.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 4, version)
+
+	require.NoError(t, m.Migrate(m.RequiredVersion()))
+
+	version, err = m.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, m.RequiredVersion(), version)
+
+	// Migrations without down scripts can not be reverted
+	assert.Error(t, m.Migrate(0))
+}
+
+func TestMigrate_FailedMigrationIsRolledBack(t *testing.T) {
+	db := newMemoryDb(t)
+
+	m := newTestMigrator(t, db, fstest.MapFS{
+		"001_app.sql":    {Data: []byte(appTable)},
+		"002_items.sql":  {Data: []byte("CREATE TABLE items (id INTEGER);")},
+		"003_broken.sql": {Data: []byte("CREATE TABLE other (id INTEGER); INSERT INTO missing VALUES (1);")},
+	})
+
+	require.NoError(t, m.Migrate(1))
+	assert.Error(t, m.Migrate(3))
+
+	version, err := m.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.False(t, tableExists(t, db, "items"))
+	assert.False(t, tableExists(t, db, "other"))
+}
+
+func TestMigrate_ModifiedMigration(t *testing.T) {
+	db := newMemoryDb(t)
+
+	m := newTestMigrator(t, db, fstest.MapFS{
+		"001_app.sql": {Data: []byte(appTable)},
+	})
+	require.NoError(t, m.Migrate(1))
+
+	m = newTestMigrator(t, db, fstest.MapFS{
+		"001_app.sql":   {Data: []byte(appTable + "-- changed\n")},
+		"002_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER);")},
+	})
+	assert.ErrorContains(t, m.Migrate(2), "was modified")
+}
+
+func TestMigrate_LegacyDatabase(t *testing.T) {
+	db := newMemoryDb(t)
+
+	_, err := db.Exec(appTable + "; INSERT INTO app (id, db_version) VALUES (1, 1);")
+	require.NoError(t, err)
+
+	m := newTestMigrator(t, db, fstest.MapFS{
+		"001_app.sql":        {Data: []byte(appTable)},
+		"002_items.sql":      {Data: []byte("CREATE TABLE items (id INTEGER);")},
+		"002_items.down.sql": {Data: []byte("DROP TABLE items;")},
+	})
+
+	steps, err := m.Plan(2)
+	require.NoError(t, err)
+	assert.Len(t, steps, 1)
+
+	require.NoError(t, m.Migrate(2))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	var version int
+	require.NoError(t, db.QueryRow("SELECT db_version FROM app").Scan(&version))
+	assert.Equal(t, 2, version)
+}
+
+func TestLoadMigrations_MissingVersion(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{
+		"001_app.sql":   {Data: []byte(appTable)},
+		"003_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER);")},
+	})
+	assert.ErrorContains(t, err, "migration 2 is missing")
+}
+
+type testSplitStatements_TableEntry struct {
+	name     string
+	script   string
+	expected []string
+}
+
+func TestSplitStatements(t *testing.T) {
+	testData := []testSplitStatements_TableEntry{
+		{
+			name:     "plain statements",
+			script:   "CREATE TABLE a (x);\n\nINSERT INTO a VALUES (1);\n",
+			expected: []string{"CREATE TABLE a (x)", "INSERT INTO a VALUES (1)"},
+		},
+		{
+			name:     "semicolons in literals",
+			script:   `INSERT INTO a VALUES ('x;''y'); INSERT INTO "b;c" VALUES (1)`,
+			expected: []string{`INSERT INTO a VALUES ('x;''y')`, `INSERT INTO "b;c" VALUES (1)`},
+		},
+		{
+			name:     "comments",
+			script:   "-- first; comment\nDROP TABLE a; /* block; */ DROP TABLE b;",
+			expected: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		{
+			name: "trigger",
+			script: `CREATE TRIGGER t AFTER DELETE ON files BEGIN
+	DELETE FROM tags WHERE id = CASE WHEN old.id > 0 THEN old.id END;
+	DELETE FROM file_tags WHERE file_id = old.id;
+END;
+DROP TABLE a;`,
+			expected: []string{`CREATE TRIGGER t AFTER DELETE ON files BEGIN
+	DELETE FROM tags WHERE id = CASE WHEN old.id > 0 THEN old.id END;
+	DELETE FROM file_tags WHERE file_id = old.id;
+END`, "DROP TABLE a"},
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitStatements(tt.script))
+		})
+	}
+}
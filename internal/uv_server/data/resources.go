@@ -3,6 +3,8 @@ package data
 import "database/sql"
 
 type Resources struct {
-	Db       *sql.DB
-	To_clean chan<- string
+	Db        *sql.DB
+	To_clean  chan<- string
+	Downloads *DownloadRegistry
+	Queue     *DownloadQueue
 }
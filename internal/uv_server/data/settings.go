@@ -0,0 +1,56 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"uv_server/internal/uv_server/business/data"
+)
+
+func settingsToValues(settings *data.Settings) map[string]string {
+	return map[string]string{
+		data.SettingStorageDir: settings.StorageDir,
+		data.SettingMaxConcurrentDownloads: strconv.Itoa(
+			settings.MaxConcurrentDownloads),
+		data.SettingDefaultFormat:    settings.DefaultFormat,
+		data.SettingFilenameTemplate: settings.FilenameTemplate,
+		data.SettingBandwidthLimit: strconv.FormatInt(
+			settings.BandwidthLimit, 10),
+		data.SettingDownloadBandwidthLimit: strconv.FormatInt(
+			settings.DownloadBandwidthLimit, 10),
+		data.SettingDownloadSchedule: settings.DownloadSchedule,
+		data.SettingTrashRetentionDays: strconv.Itoa(
+			settings.TrashRetentionDays),
+	}
+}
+
+// applySettingValue returns false for unknown keys
+func applySettingValue(settings *data.Settings, key string, value string) (bool, error) {
+	var err error
+
+	switch key {
+	case data.SettingStorageDir:
+		settings.StorageDir = value
+	case data.SettingMaxConcurrentDownloads:
+		settings.MaxConcurrentDownloads, err = strconv.Atoi(value)
+	case data.SettingDefaultFormat:
+		settings.DefaultFormat = value
+	case data.SettingFilenameTemplate:
+		settings.FilenameTemplate = value
+	case data.SettingBandwidthLimit:
+		settings.BandwidthLimit, err = strconv.ParseInt(value, 10, 64)
+	case data.SettingDownloadBandwidthLimit:
+		settings.DownloadBandwidthLimit, err = strconv.ParseInt(value, 10, 64)
+	case data.SettingDownloadSchedule:
+		settings.DownloadSchedule = value
+	case data.SettingTrashRetentionDays:
+		settings.TrashRetentionDays, err = strconv.Atoi(value)
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return true, fmt.Errorf("invalid value %q for setting %v: %w", value, key, err)
+	}
+
+	return true, nil
+}
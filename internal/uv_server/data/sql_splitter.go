@@ -0,0 +1,125 @@
+package data
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitStatements splits an SQL script into statements. Semicolons in
+// string literals, quoted identifiers, comments and trigger bodies do
+// not end a statement, comments are dropped
+func splitStatements(script string) []string {
+	statements := []string{}
+
+	var current strings.Builder
+	var words []string
+	depth := 0
+
+	flush := func() {
+		statement := strings.TrimSpace(current.String())
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+
+		current.Reset()
+		words = words[:0]
+		depth = 0
+	}
+
+	runes := []rune(script)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			current.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i++
+			current.WriteRune(' ')
+		case r == '\'' || r == '"' || r == '`' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+
+			current.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				current.WriteRune(runes[i])
+				if runes[i] != closing {
+					continue
+				}
+
+				// A doubled quote is an escaped quote
+				if closing != ']' && i+1 < len(runes) && runes[i+1] == closing {
+					i++
+					current.WriteRune(runes[i])
+					continue
+				}
+
+				break
+			}
+		case isWordRune(r):
+			start := i
+			for i+1 < len(runes) && isWordRune(runes[i+1]) {
+				i++
+			}
+
+			word := string(runes[start : i+1])
+			current.WriteString(word)
+
+			words = append(words, strings.ToUpper(word))
+			depth += triggerDepthChange(words)
+		case r == ';' && depth == 0:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return statements
+}
+
+// triggerDepthChange tracks BEGIN ... END blocks of trigger bodies
+// and CASE ... END expressions inside of them
+func triggerDepthChange(words []string) int {
+	if !isTrigger(words) {
+		return 0
+	}
+
+	switch words[len(words)-1] {
+	case "BEGIN", "CASE":
+		return 1
+	case "END":
+		return -1
+	}
+
+	return 0
+}
+
+// isTrigger reports whether the statement starts with
+// CREATE [TEMP | TEMPORARY] TRIGGER
+func isTrigger(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+
+	if words[1] == "TEMP" || words[1] == "TEMPORARY" {
+		return len(words) > 2 && words[2] == "TRIGGER"
+	}
+
+	return words[1] == "TRIGGER"
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"uv_server/internal/uv_server/business/data"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const trashPurgeInterval = time.Hour
+
+// TrashPurger permanently deletes files which stay in the trash longer
+// than the retention period from the settings
+type TrashPurger struct {
+	log        *logrus.Entry
+	config     *config.Config
+	database   *Database
+	filesystem *Filesystem
+}
+
+func NewTrashPurger(config *config.Config, db *sql.DB) *TrashPurger {
+	object := &TrashPurger{}
+
+	object.log = loggers.DataLogger.
+		WithField("component", "TrashPurger")
+	object.config = config
+	object.database = NewDatabase(db)
+	object.filesystem = NewFilesystem()
+
+	return object
+}
+
+// PurgeLoop purges the trash every hour until the context is canceled
+func (p *TrashPurger) PurgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge() {
+	settings, err := p.database.GetSettings()
+	if err != nil {
+		p.log.Errorf("failed to get settings: %v", err)
+		return
+	}
+
+	if settings.TrashRetentionDays == 0 {
+		return
+	}
+
+	storageDir := p.config.ResolvePath(settings.StorageDir)
+	retention := time.Duration(settings.TrashRetentionDays) * 24 * time.Hour
+
+	files, err := p.database.GetTrashedFiles(time.Now().Add(-retention))
+	if err != nil {
+		p.log.Errorf("failed to get trashed files: %v", err)
+		return
+	}
+
+	for _, file := range files {
+		log := p.log.WithField("id", file.Id)
+
+		err := data.PurgeFile(p.database, p.filesystem, storageDir, &file)
+		if err != nil {
+			log.Errorf("failed to purge file: %v", err)
+			continue
+		}
+
+		log.Infof("purged trashed file: %v", file.Path.String)
+	}
+}
@@ -0,0 +1,119 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"uv_server/internal/uv_protocol"
+	canceldownload "uv_server/internal/uv_server/business/workflows/cancel_download"
+	jobmessages "uv_server/internal/uv_server/business/workflows/cancel_download/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type CancelDownloadWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *canceldownload.CancelDownloadWf
+
+	resources *data.Resources
+}
+
+func NewCancelDownloadWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *CancelDownloadWfAdapter {
+	object := &CancelDownloadWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "CancelDownloadWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+func (wa *CancelDownloadWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = canceldownload.NewCancelDownloadWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		wa.resources.Downloads,
+	)
+}
+
+func (wa *CancelDownloadWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.CancelDownloadByFileIdRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of CancelDownloadByFileIdRequest", msg.Header.Type)
+	}
+
+	request := &jobmessages.Request{}
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *CancelDownloadWfAdapter) validateRequest(request *jobmessages.Request) error {
+	if request.Id == nil {
+		return fmt.Errorf("missing \"id\" field")
+	}
+
+	return nil
+}
+
+func (wa *CancelDownloadWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+// The workflow reports only common messages handled by the job
+func (wa *CancelDownloadWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	return Done, nil
+}
@@ -65,6 +65,7 @@ func (wa *DeleteFilesWfAdapter) CreateWf(
 		wf_in,
 		data.NewDatabase(wa.resources.Db),
 		data.NewFilesystem(),
+		wa.resources.Downloads,
 	)
 }
 
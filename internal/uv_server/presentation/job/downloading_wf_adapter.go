@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 	"uv_server/internal/uv_protocol"
 	"uv_server/internal/uv_server/business/workflows/downloading"
 	jobmessages "uv_server/internal/uv_server/business/workflows/downloading/job_messages"
@@ -53,6 +54,12 @@ func NewDownloadingWfAdapter(
 	return object
 }
 
+// Downloads may wait in the queue for as long as it takes,
+// the workflow starts the deadline once the download starts
+func (wa *DownloadingWfAdapter) Timeout() time.Duration {
+	return 0
+}
+
 func (wa *DownloadingWfAdapter) CreateWf(
 	uuid string,
 	config *config.Config,
@@ -62,12 +69,18 @@ func (wa *DownloadingWfAdapter) CreateWf(
 ) {
 	wa.downloaderOut = make(chan interface{}, 1)
 
+	// Downloads can be cancelled by other jobs through the registry
+	ctx, cancel := context.WithCancel(ctx)
+
+	database := data.NewDatabase(wa.resources.Db)
+
 	downloader := downloaders.NewYtDownloader(
 		uuid,
 		config,
 		ctx,
 		wa.downloaderOut,
 		wa.resources.To_clean,
+		database,
 	)
 
 	wa.wf = downloading.NewDownloadingWf(
@@ -78,7 +91,12 @@ func (wa *DownloadingWfAdapter) CreateWf(
 		wf_in,
 		downloader,
 		wa.downloaderOut,
-		data.NewDatabase(wa.resources.Db),
+		database,
+		data.NewFilesystem(),
+		wa.resources.Downloads,
+		wa.resources.Queue,
+		cancel,
+		defaultTimeout,
 	)
 }
 
@@ -117,7 +135,25 @@ func (wa *DownloadingWfAdapter) HandleWfMessage(
 ) (State, error) {
 	wa.log.Tracef("handling wf message")
 
-	if tMsg, ok := msg.(*jobmessages.Progress); ok {
+	if tMsg, ok := msg.(*jobmessages.Queued); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.DownloadingQueued,
+				},
+				Payload: payload,
+			},
+			Done: false,
+		}
+
+		wa.session_in <- msg
+	} else if tMsg, ok := msg.(*jobmessages.Progress); ok {
 		payload, err := json.Marshal(tMsg)
 		if err != nil {
 			wa.log.Fatalf("failed to serialize message: %v", err)
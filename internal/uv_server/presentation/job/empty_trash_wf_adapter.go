@@ -0,0 +1,128 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"uv_server/internal/uv_protocol"
+	emptytrash "uv_server/internal/uv_server/business/workflows/empty_trash"
+	jobmessages "uv_server/internal/uv_server/business/workflows/empty_trash/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EmptyTrashWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *emptytrash.EmptyTrashWf
+
+	resources *data.Resources
+}
+
+func NewEmptyTrashWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *EmptyTrashWfAdapter {
+	object := &EmptyTrashWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "EmptyTrashWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+func (wa *EmptyTrashWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = emptytrash.NewEmptyTrashWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+		data.NewFilesystem(),
+	)
+}
+
+func (wa *EmptyTrashWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.EmptyTrashRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of EmptyTrashRequest", msg.Header.Type)
+	}
+
+	request := &jobmessages.Request{}
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *EmptyTrashWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *EmptyTrashWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.Result); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.EmptyTrashResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Done, nil
+}
@@ -0,0 +1,181 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"uv_server/internal/uv_protocol"
+	exportlibrary "uv_server/internal/uv_server/business/workflows/export_library"
+	jobmessages "uv_server/internal/uv_server/business/workflows/export_library/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ExportLibraryWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *exportlibrary.ExportLibraryWf
+
+	resources *data.Resources
+}
+
+func NewExportLibraryWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *ExportLibraryWfAdapter {
+	object := &ExportLibraryWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "ExportLibraryWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+// Large libraries are read file by file
+func (wa *ExportLibraryWfAdapter) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (wa *ExportLibraryWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = exportlibrary.NewExportLibraryWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+		data.NewFilesystem(),
+	)
+}
+
+func (wa *ExportLibraryWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.ExportRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of ExportRequest", msg.Header.Type)
+	}
+
+	request := &jobmessages.Request{}
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *ExportLibraryWfAdapter) validateRequest(request *jobmessages.Request) error {
+	switch request.Format {
+	case jobmessages.FormatM3u8, jobmessages.FormatJson, jobmessages.FormatCsv:
+	case "":
+		return fmt.Errorf("\"format\" field is empty")
+	default:
+		return fmt.Errorf("unknown format %q", request.Format)
+	}
+
+	if request.PlaylistId != nil && request.Format != jobmessages.FormatM3u8 {
+		return fmt.Errorf("\"playlistId\" is supported only by %v format", jobmessages.FormatM3u8)
+	}
+
+	if request.Path != nil && *request.Path == "" {
+		return fmt.Errorf("\"path\" field is empty")
+	}
+
+	return nil
+}
+
+func (wa *ExportLibraryWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *ExportLibraryWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.Chunk); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.ExportChunk,
+				},
+				Payload: payload,
+			},
+			Done: false,
+		}
+
+		wa.session_in <- msg
+	} else if tMsg, ok := msg.(*jobmessages.Result); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.ExportResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+
+		return Done, nil
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Active, nil
+}
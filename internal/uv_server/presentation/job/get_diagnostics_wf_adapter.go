@@ -0,0 +1,124 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"uv_server/internal/uv_protocol"
+	businessData "uv_server/internal/uv_server/business/data"
+	getdiagnostics "uv_server/internal/uv_server/business/workflows/get_diagnostics"
+	jobmessages "uv_server/internal/uv_server/business/workflows/get_diagnostics/job_messages"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GetDiagnosticsWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *getdiagnostics.GetDiagnosticsWf
+
+	resources  *data.Resources
+	activeJobs businessData.ActiveJobs
+}
+
+func NewGetDiagnosticsWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+	activeJobs businessData.ActiveJobs,
+) *GetDiagnosticsWfAdapter {
+	object := &GetDiagnosticsWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "GetDiagnosticsWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+	object.activeJobs = activeJobs
+
+	return object
+}
+
+func (wa *GetDiagnosticsWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = getdiagnostics.NewGetDiagnosticsWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+		data.NewDiagnostics(config, wa.resources.Db),
+		wa.activeJobs,
+	)
+}
+
+func (wa *GetDiagnosticsWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.GetDiagnosticsRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of GetDiagnosticsRequest", msg.Header.Type)
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg)
+
+	return nil
+}
+
+func (wa *GetDiagnosticsWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *GetDiagnosticsWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.Result); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.GetDiagnosticsResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Done, nil
+}
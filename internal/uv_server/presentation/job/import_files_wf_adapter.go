@@ -0,0 +1,179 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"uv_server/internal/uv_protocol"
+	importfiles "uv_server/internal/uv_server/business/workflows/import_files"
+	jobmessages "uv_server/internal/uv_server/business/workflows/import_files/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ImportFilesWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *importfiles.ImportFilesWf
+
+	resources *data.Resources
+}
+
+func NewImportFilesWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *ImportFilesWfAdapter {
+	object := &ImportFilesWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "ImportFilesWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+// Hashing and copying a large collection may take a while
+func (wa *ImportFilesWfAdapter) Timeout() time.Duration {
+	return time.Hour
+}
+
+func (wa *ImportFilesWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = importfiles.NewImportFilesWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+		data.NewFilesystem(),
+		data.NewMetadataExtractor(config),
+	)
+}
+
+func (wa *ImportFilesWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.ImportFilesRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of ImportFilesRequest", msg.Header.Type)
+	}
+
+	request := &jobmessages.Request{}
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *ImportFilesWfAdapter) validateRequest(request *jobmessages.Request) error {
+	if request.Dir == "" {
+		return fmt.Errorf("\"dir\" field is empty")
+	}
+
+	if request.Mode == "" {
+		request.Mode = jobmessages.ModeNone
+	}
+
+	switch request.Mode {
+	case jobmessages.ModeNone, jobmessages.ModeCopy, jobmessages.ModeMove:
+		return nil
+	default:
+		return fmt.Errorf("unknown mode %q", request.Mode)
+	}
+}
+
+func (wa *ImportFilesWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *ImportFilesWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.Progress); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.ImportFilesProgress,
+				},
+				Payload: payload,
+			},
+			Done: false,
+		}
+
+		wa.session_in <- msg
+	} else if tMsg, ok := msg.(*jobmessages.Result); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.ImportFilesResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+
+		return Done, nil
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Active, nil
+}
@@ -0,0 +1,155 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"uv_server/internal/uv_protocol"
+	importlibrary "uv_server/internal/uv_server/business/workflows/import_library"
+	jobmessages "uv_server/internal/uv_server/business/workflows/import_library/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ImportLibraryWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *importlibrary.ImportLibraryWf
+
+	resources *data.Resources
+}
+
+func NewImportLibraryWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *ImportLibraryWfAdapter {
+	object := &ImportLibraryWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "ImportLibraryWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+// Large libraries are restored file by file
+func (wa *ImportLibraryWfAdapter) Timeout() time.Duration {
+	return 10 * time.Minute
+}
+
+func (wa *ImportLibraryWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = importlibrary.NewImportLibraryWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+		data.NewFilesystem(),
+	)
+}
+
+func (wa *ImportLibraryWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	if msg.Header.Type != uv_protocol.ImportLibraryRequest {
+		wa.log.Fatalf("unexpected message type, got %v instead of ImportLibraryRequest", msg.Header.Type)
+	}
+
+	request := &jobmessages.Request{}
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *ImportLibraryWfAdapter) validateRequest(request *jobmessages.Request) error {
+	if (request.Path == nil) == (request.Library == nil) {
+		return fmt.Errorf("exactly one of \"path\" and \"library\" fields is required")
+	}
+
+	if request.Path != nil && *request.Path == "" {
+		return fmt.Errorf("\"path\" field is empty")
+	}
+
+	return nil
+}
+
+func (wa *ImportLibraryWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *ImportLibraryWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.Result); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.ImportLibraryResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+
+		return Done, nil
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Active, nil
+}
@@ -10,11 +10,14 @@ import (
 	"uv_server/internal/uv_protocol"
 	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 	"uv_server/internal/uv_server/config"
 
 	"github.com/sirupsen/logrus"
 )
 
+const defaultTimeout = 60 * time.Second
+
 type State int
 
 const (
@@ -57,11 +60,18 @@ type Job struct {
 	wf_out chan interface{}
 
 	wf_adatapter WorkflowAdapter
+
+	// Canceled with common.ErrShutdown when the server shuts down
+	serverCtx context.Context
+
+	// Reported to metrics once the job is finished
+	outcome string
 }
 
 func NewJob(
 	uuid string,
 	config *config.Config,
+	serverCtx context.Context,
 	session_in chan<- *Message,
 	wf_adatapter WorkflowAdapter,
 ) *Job {
@@ -82,6 +92,9 @@ func NewJob(
 
 	object.wf_adatapter = wf_adatapter
 
+	object.serverCtx = serverCtx
+	object.outcome = metrics.JobDone
+
 	return object
 }
 
@@ -93,9 +106,30 @@ func (j *Job) Notify(m *uv_protocol.Message) {
 func (j *Job) Run(m *uv_protocol.Message) {
 	j.log.Tracef("Run: handling message %v", m)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	timeout := defaultTimeout
+	if provider, ok := j.wf_adatapter.(TimeoutProvider); ok {
+		timeout = provider.Timeout()
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(j.serverCtx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(j.serverCtx)
+	}
 	defer cancel()
 
+	jobType := m.Header.Type.String()
+	startedAt := time.Now()
+
+	metrics.JobsRunning.WithLabelValues(jobType).Inc()
+	defer func() {
+		metrics.JobsRunning.WithLabelValues(jobType).Dec()
+		metrics.JobsTotal.WithLabelValues(jobType, j.outcome).Inc()
+		metrics.JobDuration.WithLabelValues(jobType).Observe(time.Since(startedAt).Seconds())
+	}()
+
 	j.wf_adatapter.CreateWf(
 		j.uuid,
 		j.config,
@@ -130,6 +164,8 @@ func (j *Job) Run(m *uv_protocol.Message) {
 }
 
 func (j *Job) buildErrorMessage(reason string) *Message {
+	j.outcome = metrics.JobError
+
 	payload, err := json.Marshal(cjmessages.Error{Reason: reason})
 	if err != nil {
 		j.log.Fatalf("failed to serialize message: %v", err)
@@ -150,6 +186,8 @@ func (j *Job) buildErrorMessage(reason string) *Message {
 }
 
 func (j *Job) buildCanceledMessage() *Message {
+	j.outcome = metrics.JobCanceled
+
 	msg := &Message{
 		Msg: &uv_protocol.Message{
 			Header: &uv_protocol.Header{
@@ -208,6 +246,10 @@ func (j *Job) active(
 			} else if _, ok := msg.(*cjmessages.Done); ok {
 				j.session_in <- j.buildDoneMessage()
 				return None
+			} else if _, ok := msg.(*cjmessages.Canceled); ok {
+				// Workflows may be cancelled by other jobs
+				j.session_in <- j.buildCanceledMessage()
+				return None
 			} else {
 				state, err := j.wf_adatapter.HandleWfMessage(msg)
 
@@ -0,0 +1,209 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"uv_server/internal/uv_protocol"
+	"uv_server/internal/uv_server/business/workflows/playlists"
+	jobmessages "uv_server/internal/uv_server/business/workflows/playlists/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PlaylistsWfAdapter serves every playlist request type
+type PlaylistsWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *playlists.PlaylistsWf
+
+	resources *data.Resources
+}
+
+func NewPlaylistsWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *PlaylistsWfAdapter {
+	object := &PlaylistsWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "PlaylistsWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+func (wa *PlaylistsWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = playlists.NewPlaylistsWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+	)
+}
+
+func (wa *PlaylistsWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	var request interface{}
+
+	switch msg.Header.Type {
+	case uv_protocol.GetPlaylistsRequest:
+		request = &jobmessages.GetPlaylistsRequest{}
+	case uv_protocol.GetPlaylistRequest:
+		request = &jobmessages.GetPlaylistRequest{}
+	case uv_protocol.CreatePlaylistRequest:
+		request = &jobmessages.CreateRequest{}
+	case uv_protocol.RenamePlaylistRequest:
+		request = &jobmessages.RenameRequest{}
+	case uv_protocol.DeletePlaylistRequest:
+		request = &jobmessages.DeleteRequest{}
+	case uv_protocol.AddPlaylistEntriesRequest:
+		request = &jobmessages.AddEntriesRequest{}
+	case uv_protocol.RemovePlaylistEntriesRequest:
+		request = &jobmessages.RemoveEntriesRequest{}
+	case uv_protocol.ReorderPlaylistRequest:
+		request = &jobmessages.ReorderRequest{}
+	default:
+		wa.log.Fatalf("unexpected message type, got %v instead of playlist request", msg.Header.Type)
+	}
+
+	// Listing requests do not carry any fields
+	if len(msg.Payload) != 0 {
+		err := common.UnmarshalStrict(msg.Payload, request)
+		if err != nil {
+			newErr := fmt.Errorf("failed to parse payload: %w", err)
+			wa.log.Error(newErr)
+			return newErr
+		}
+	}
+
+	err := wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *PlaylistsWfAdapter) validateRequest(request interface{}) error {
+	switch tRequest := request.(type) {
+	case *jobmessages.GetPlaylistRequest:
+		return requireId(tRequest.Id)
+	case *jobmessages.CreateRequest:
+		return requireName(tRequest.Name)
+	case *jobmessages.RenameRequest:
+		return errors.Join(requireId(tRequest.Id), requireName(tRequest.Name))
+	case *jobmessages.DeleteRequest:
+		return requireId(tRequest.Id)
+	case *jobmessages.AddEntriesRequest:
+		return errors.Join(requireId(tRequest.Id), requireFileIds(tRequest.FileIds))
+	case *jobmessages.RemoveEntriesRequest:
+		return errors.Join(requireId(tRequest.Id), requireFileIds(tRequest.FileIds))
+	case *jobmessages.ReorderRequest:
+		return requireId(tRequest.Id)
+	}
+
+	return nil
+}
+
+func requireId(id *int64) error {
+	if id == nil {
+		return fmt.Errorf("missing \"id\" field")
+	}
+
+	return nil
+}
+
+func requireName(name *string) error {
+	if name == nil {
+		return fmt.Errorf("missing \"name\" field")
+	}
+
+	return nil
+}
+
+func requireFileIds(fileIds []int64) error {
+	if len(fileIds) == 0 {
+		return fmt.Errorf("\"fileIds\" array is empty")
+	}
+
+	return nil
+}
+
+func (wa *PlaylistsWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *PlaylistsWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	var type_ uv_protocol.Type
+
+	switch msg.(type) {
+	case *jobmessages.PlaylistsResult:
+		type_ = uv_protocol.GetPlaylistsResponse
+	case *jobmessages.PlaylistResult:
+		type_ = uv_protocol.GetPlaylistResponse
+	case *jobmessages.CreateResult:
+		type_ = uv_protocol.CreatePlaylistResponse
+	default:
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		wa.log.Fatalf("failed to serialize message: %v", err)
+	}
+
+	wa.session_in <- &Message{
+		Msg: &uv_protocol.Message{
+			Header: &uv_protocol.Header{
+				Uuid: &wa.uuid,
+				Type: type_,
+			},
+			Payload: payload,
+		},
+		Done: true,
+	}
+
+	return Done, nil
+}
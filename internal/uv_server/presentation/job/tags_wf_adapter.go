@@ -0,0 +1,165 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"uv_server/internal/uv_protocol"
+	"uv_server/internal/uv_server/business/workflows/tags"
+	jobmessages "uv_server/internal/uv_server/business/workflows/tags/job_messages"
+	"uv_server/internal/uv_server/common"
+	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/config"
+	"uv_server/internal/uv_server/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TagsWfAdapter serves every tag request type
+type TagsWfAdapter struct {
+	uuid string
+
+	log    *logrus.Entry
+	config *config.Config
+
+	session_in chan<- *Message
+	wf         *tags.TagsWf
+
+	resources *data.Resources
+}
+
+func NewTagsWfAdapter(
+	uuid string,
+	config *config.Config,
+	session_in chan<- *Message,
+	resources *data.Resources,
+) *TagsWfAdapter {
+	object := &TagsWfAdapter{}
+
+	object.uuid = uuid
+	object.log = loggers.PresentationLogger.WithFields(
+		logrus.Fields{
+			"component": "TagsWfAdapter",
+			"uuid":      uuid})
+	object.config = config
+	object.session_in = session_in
+
+	object.resources = resources
+
+	return object
+}
+
+func (wa *TagsWfAdapter) CreateWf(
+	uuid string,
+	config *config.Config,
+	ctx context.Context,
+	wf_in chan interface{},
+	wf_out chan interface{},
+) {
+	wa.wf = tags.NewTagsWf(
+		uuid,
+		config,
+		ctx,
+		wf_out,
+		wf_in,
+		data.NewDatabase(wa.resources.Db),
+	)
+}
+
+func (wa *TagsWfAdapter) RunWf(
+	wg *sync.WaitGroup,
+	msg *uv_protocol.Message,
+) error {
+	var request interface{}
+
+	switch msg.Header.Type {
+	case uv_protocol.GetTagsRequest:
+		wg.Add(1)
+		go wa.wf.Run(wg, &jobmessages.GetTagsRequest{})
+		return nil
+	case uv_protocol.TagFilesRequest:
+		request = &jobmessages.TagRequest{}
+	case uv_protocol.UntagFilesRequest:
+		request = &jobmessages.UntagRequest{}
+	default:
+		wa.log.Fatalf("unexpected message type, got %v instead of tag request", msg.Header.Type)
+	}
+
+	err := common.UnmarshalStrict(msg.Payload, request)
+	if err != nil {
+		newErr := fmt.Errorf("failed to parse payload: %w", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
+	wg.Add(1)
+	go wa.wf.Run(wg, request)
+
+	return nil
+}
+
+func (wa *TagsWfAdapter) validateRequest(request interface{}) error {
+	switch tRequest := request.(type) {
+	case *jobmessages.TagRequest:
+		return errors.Join(requireFileIds(tRequest.FileIds), requireTags(tRequest.Tags))
+	case *jobmessages.UntagRequest:
+		return errors.Join(requireFileIds(tRequest.FileIds), requireTags(tRequest.Tags))
+	}
+
+	return nil
+}
+
+func requireTags(tags []string) error {
+	if len(tags) == 0 {
+		return fmt.Errorf("\"tags\" array is empty")
+	}
+
+	return nil
+}
+
+func (wa *TagsWfAdapter) HandleSessionMessage(
+	msg *uv_protocol.Message,
+) error {
+	wa.log.Tracef("handling session message: %v", msg.Header.Type)
+	return fmt.Errorf("unexpected message %v", msg.Header.Type)
+}
+
+func (wa *TagsWfAdapter) HandleWfMessage(
+	msg interface{},
+) (State, error) {
+	wa.log.Tracef("handling wf message")
+
+	if tMsg, ok := msg.(*jobmessages.TagsResult); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.GetTagsResponse,
+				},
+				Payload: payload,
+			},
+			Done: true,
+		}
+
+		wa.session_in <- msg
+	} else {
+		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
+	}
+
+	return Done, nil
+}
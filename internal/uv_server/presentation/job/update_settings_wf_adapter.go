@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 	"uv_server/internal/uv_protocol"
-	jobmessages "uv_server/internal/uv_server/business/data"
+	businessData "uv_server/internal/uv_server/business/data"
 	updatesettings "uv_server/internal/uv_server/business/workflows/update_settings"
+	jobmessages "uv_server/internal/uv_server/business/workflows/update_settings/job_messages"
 	"uv_server/internal/uv_server/common"
 	"uv_server/internal/uv_server/common/loggers"
 	"uv_server/internal/uv_server/config"
@@ -50,6 +52,11 @@ func NewSetSettingsWfAdapter(
 	return object
 }
 
+// Moving files into a new storage directory may take a while
+func (wa *UpdateSettingsWfAdapter) Timeout() time.Duration {
+	return time.Hour
+}
+
 func (wa *UpdateSettingsWfAdapter) CreateWf(
 	uuid string,
 	config *config.Config,
@@ -64,6 +71,7 @@ func (wa *UpdateSettingsWfAdapter) CreateWf(
 		wf_out,
 		wf_in,
 		data.NewDatabase(wa.resources.Db),
+		data.NewFilesystem(),
 	)
 }
 
@@ -75,7 +83,7 @@ func (wa *UpdateSettingsWfAdapter) RunWf(
 		wa.log.Fatalf("unexpected message type, got %v instead of UpdateSettingsRequest", msg.Header.Type)
 	}
 
-	request := &jobmessages.Settings{}
+	request := &jobmessages.Request{}
 	err := common.UnmarshalStrict(msg.Payload, request)
 	if err != nil {
 		newErr := fmt.Errorf("failed to parse payload: %w", err)
@@ -83,12 +91,31 @@ func (wa *UpdateSettingsWfAdapter) RunWf(
 		return newErr
 	}
 
+	err = wa.validateRequest(request)
+	if err != nil {
+		newErr := fmt.Errorf("request validation failed: %v", err)
+		wa.log.Error(newErr)
+		return newErr
+	}
+
 	wg.Add(1)
 	go wa.wf.Run(wg, request)
 
 	return nil
 }
 
+func (wa *UpdateSettingsWfAdapter) validateRequest(request *jobmessages.Request) error {
+	if request.IsEmpty() {
+		return fmt.Errorf("request does not contain any setting")
+	}
+
+	if request.MoveFiles && request.StorageDir == nil {
+		return fmt.Errorf("\"move_files\" requires \"storage_dir\" field")
+	}
+
+	return nil
+}
+
 func (wa *UpdateSettingsWfAdapter) HandleSessionMessage(
 	msg *uv_protocol.Message,
 ) error {
@@ -101,7 +128,25 @@ func (wa *UpdateSettingsWfAdapter) HandleWfMessage(
 ) (State, error) {
 	wa.log.Tracef("handling wf message")
 
-	if tMsg, ok := msg.(*jobmessages.Settings); ok {
+	if tMsg, ok := msg.(*jobmessages.Progress); ok {
+		payload, err := json.Marshal(tMsg)
+		if err != nil {
+			wa.log.Fatalf("failed to serialize message: %v", err)
+		}
+
+		msg := &Message{
+			Msg: &uv_protocol.Message{
+				Header: &uv_protocol.Header{
+					Uuid: &wa.uuid,
+					Type: uv_protocol.UpdateSettingsProgress,
+				},
+				Payload: payload,
+			},
+			Done: false,
+		}
+
+		wa.session_in <- msg
+	} else if tMsg, ok := msg.(*businessData.Settings); ok {
 		payload, err := json.Marshal(tMsg)
 		if err != nil {
 			wa.log.Fatalf("failed to serialize message: %v", err)
@@ -119,9 +164,11 @@ func (wa *UpdateSettingsWfAdapter) HandleWfMessage(
 		}
 
 		wa.session_in <- msg
+
+		return Done, nil
 	} else {
 		wa.log.Fatalf("Unknown message: %v", reflect.TypeOf(msg))
 	}
 
-	return Done, nil
+	return Active, nil
 }
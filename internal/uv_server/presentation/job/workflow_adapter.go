@@ -3,6 +3,7 @@ package job
 import (
 	"context"
 	"sync"
+	"time"
 	"uv_server/internal/uv_protocol"
 	"uv_server/internal/uv_server/config"
 )
@@ -20,3 +21,10 @@ type WorkflowAdapter interface {
 	HandleSessionMessage(message *uv_protocol.Message) error
 	HandleWfMessage(message interface{}) (State, error)
 }
+
+// TimeoutProvider may be implemented by adapters of workflows
+// which are not expected to finish within the default job timeout,
+// zero means the workflow limits its time itself
+type TimeoutProvider interface {
+	Timeout() time.Duration
+}
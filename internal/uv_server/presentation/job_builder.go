@@ -1,6 +1,7 @@
 package presentation
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -17,14 +18,24 @@ type JobBuilder struct {
 	config *config.Config
 
 	resources *data.Resources
+	tracker   *JobTracker
+
+	jobsCtx context.Context
 }
 
-func NewJobBuilder(config *config.Config, resources *data.Resources) *JobBuilder {
+func NewJobBuilder(
+	config *config.Config,
+	jobsCtx context.Context,
+	resources *data.Resources,
+	tracker *JobTracker,
+) *JobBuilder {
 	object := &JobBuilder{}
 	object.log = loggers.PresentationLogger
 	object.config = config
+	object.jobsCtx = jobsCtx
 
 	object.resources = resources
+	object.tracker = tracker
 
 	return object
 }
@@ -86,6 +97,93 @@ func (b *JobBuilder) CreateJob(
 			session_in,
 			b.resources,
 		)
+	case uv_protocol.VerifyLibraryRequest:
+		wa = job.NewVerifyLibraryWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.ImportFilesRequest:
+		wa = job.NewImportFilesWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.RestoreFilesRequest:
+		wa = job.NewRestoreFilesWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.EmptyTrashRequest:
+		wa = job.NewEmptyTrashWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.CancelDownloadByFileIdRequest:
+		wa = job.NewCancelDownloadWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.GetPlaylistsRequest,
+		uv_protocol.GetPlaylistRequest,
+		uv_protocol.CreatePlaylistRequest,
+		uv_protocol.RenamePlaylistRequest,
+		uv_protocol.DeletePlaylistRequest,
+		uv_protocol.AddPlaylistEntriesRequest,
+		uv_protocol.RemovePlaylistEntriesRequest,
+		uv_protocol.ReorderPlaylistRequest:
+		wa = job.NewPlaylistsWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.GetTagsRequest,
+		uv_protocol.TagFilesRequest,
+		uv_protocol.UntagFilesRequest:
+		wa = job.NewTagsWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.ExportRequest:
+		wa = job.NewExportLibraryWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.ImportLibraryRequest:
+		wa = job.NewImportLibraryWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.SetLogLevelRequest:
+		wa = job.NewSetLogLevelWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+		)
+	case uv_protocol.GetDiagnosticsRequest:
+		wa = job.NewGetDiagnosticsWfAdapter(
+			uuid,
+			b.config,
+			session_in,
+			b.resources,
+			b.tracker,
+		)
 	default:
 		return j, fmt.Errorf("unable to create job for message type %v", type_)
 	}
@@ -93,6 +191,7 @@ func (b *JobBuilder) CreateJob(
 	job := job.NewJob(
 		uuid,
 		b.config,
+		b.jobsCtx,
 		session_in,
 		wa,
 	)
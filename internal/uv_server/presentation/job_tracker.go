@@ -0,0 +1,89 @@
+package presentation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"uv_server/internal/uv_server/business/data"
+)
+
+// JobTracker counts running jobs of all sessions, once it is closed
+// new jobs are refused so the running ones can be drained
+type JobTracker struct {
+	mx     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+	jobs   map[string]data.JobInfo
+}
+
+func NewJobTracker() *JobTracker {
+	object := &JobTracker{}
+	object.jobs = make(map[string]data.JobInfo)
+	return object
+}
+
+// Add registers a new job, false is returned when the tracker is closed
+func (t *JobTracker) Add(uuid string, type_ string) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.closed {
+		return false
+	}
+
+	t.wg.Add(1)
+	t.jobs[uuid] = data.JobInfo{
+		Uuid:      uuid,
+		Type:      type_,
+		StartedAt: time.Now(),
+	}
+	return true
+}
+
+func (t *JobTracker) Done(uuid string) {
+	t.mx.Lock()
+	delete(t.jobs, uuid)
+	t.mx.Unlock()
+
+	t.wg.Done()
+}
+
+func (t *JobTracker) Close() {
+	t.mx.Lock()
+	t.closed = true
+	t.mx.Unlock()
+}
+
+// GetActiveJobs lists running jobs, the oldest first
+func (t *JobTracker) GetActiveJobs() []data.JobInfo {
+	t.mx.Lock()
+	jobs := make([]data.JobInfo, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		jobs = append(jobs, job)
+	}
+	t.mx.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+
+	return jobs
+}
+
+// Wait waits for running jobs and reports whether all of them
+// have finished before the timeout
+func (t *JobTracker) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -1,18 +1,34 @@
 package presentation
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
+	"uv_server/internal/uv_server/common"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 	"uv_server/internal/uv_server/config"
 	"uv_server/internal/uv_server/data"
 )
 
+// ErrJobsNotStopped is returned by Run when jobs are still running after
+// the shutdown, they may send messages at any moment, so channels they
+// send to must be left open
+var ErrJobsNotStopped = errors.New("jobs have not stopped after the cancellation")
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(c *http.Request) bool { return true },
 }
@@ -21,9 +37,23 @@ type Server struct {
 	log        *logrus.Entry
 	config     *config.Config
 	jobBuilder *JobBuilder
-	sessions   []*Session
+	tracker    *JobTracker
+
+	sessions_mx sync.Mutex
+	sessions    []*Session
+	closing     bool
+
+	// Parent of all job contexts, canceled with common.ErrShutdown
+	jobsCtx    context.Context
+	cancelJobs context.CancelCauseFunc
+
+	srv    *http.Server
+	served chan error
+	stop   context.CancelFunc
 
-	srv *http.Server
+	// Set once the library is migrated and its directories are
+	// initialized, cleared on shutdown
+	ready atomic.Bool
 }
 
 func NewServer(config *config.Config, resources *data.Resources) *Server {
@@ -31,26 +61,161 @@ func NewServer(config *config.Config, resources *data.Resources) *Server {
 
 	object.log = loggers.PresentationLogger
 	object.config = config
-	object.jobBuilder = NewJobBuilder(config, resources)
+	object.jobsCtx, object.cancelJobs = context.WithCancelCause(context.Background())
+	object.tracker = NewJobTracker()
+	object.jobBuilder = NewJobBuilder(config, object.jobsCtx, resources, object.tracker)
 	object.sessions = make([]*Session, 0)
 
 	return object
 }
 
-func (s *Server) Run() error {
-	http.HandleFunc("/ws", s.handleConnection)
+// Listen starts accepting connections, so health of the server can be
+// checked while the library is prepared. Clients are refused until
+// the server is ready
+func (s *Server) Listen() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleConnection)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReadiness)
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
 
 	addr := fmt.Sprintf(":%v", s.config.Port)
-	s.srv = &http.Server{Addr: addr}
+	s.srv = &http.Server{Addr: addr, Handler: mux}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.served = make(chan error, 1)
+	go func() {
+		s.served <- s.srv.Serve(listener)
+	}()
 
 	s.log.Infof("websocket server started on %s", addr)
 
-	return s.srv.ListenAndServe()
+	return nil
+}
+
+func (s *Server) SetReady() {
+	s.ready.Store(true)
+	s.log.Info("server is ready")
+}
+
+// Close stops listening when the server fails to get ready
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// Run serves clients until the context is canceled or the client
+// disconnects, then the server is shut down gracefully
+func (s *Server) Run(ctx context.Context) error {
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.stop()
+
+	select {
+	case <-ctx.Done():
+	case err := <-s.served:
+		// The server is closed only by the shutdown below
+		return err
+	}
+
+	if !s.shutdown() {
+		return ErrJobsNotStopped
+	}
+
+	return nil
+}
+
+// shutdown stops accepting connections and jobs, running jobs are given
+// the grace period to finish before they are canceled. Sessions are closed
+// after the last messages of their jobs are sent, false is returned when
+// jobs have not stopped and sessions are left open
+func (s *Server) shutdown() bool {
+	s.log.Info("shutting down...")
+
+	grace := time.Duration(s.config.ShutdownGracePeriodSeconds) * time.Second
+
+	s.ready.Store(false)
+	s.tracker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		s.log.Errorf("failed to stop listening: %v", err)
+	}
+
+	if !s.tracker.Wait(grace) {
+		s.log.Warn("jobs have not finished in time, canceling them")
+	}
+
+	s.cancelJobs(common.ErrShutdown)
+
+	// Workflows stop their children and clean up after the cancellation
+	if !s.tracker.Wait(grace) {
+		s.log.Error("jobs have not stopped after the cancellation")
+		return false
+	}
+
+	s.sessions_mx.Lock()
+	s.closing = true
+	sessions := s.sessions
+	s.sessions_mx.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+
+	s.log.Info("server is stopped")
+
+	return true
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ready")
+}
+
+// authorized checks the token of the client, it is passed as a query
+// parameter by clients which can not set headers of websocket requests
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.AuthToken == "" {
+		return true
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		token = r.URL.Query().Get("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AuthToken)) == 1
 }
 
 func (s *Server) handleConnection(w http.ResponseWriter, r *http.Request) {
 	s.log.Infof("handling connection from %s", r.RemoteAddr)
 
+	if !s.ready.Load() {
+		s.log.Infof("refusing connection from %s, server is not ready", r.RemoteAddr)
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !s.authorized(r) {
+		s.log.Warnf("refusing connection from %s, invalid auth token", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 
 	if err != nil {
@@ -60,7 +225,15 @@ func (s *Server) handleConnection(w http.ResponseWriter, r *http.Request) {
 	// Could add session removal later, but as I'm expecting to have
 	// only one client at the moment so I do not really care
 
-	session := NewSession(s.config, ws, r.RemoteAddr, s.jobBuilder, s.srv)
+	session := NewSession(s.config, ws, r.RemoteAddr, s.jobBuilder, s.tracker, s.stop)
+
+	s.sessions_mx.Lock()
+	defer s.sessions_mx.Unlock()
+
+	if s.closing {
+		ws.Close()
+		return
+	}
 
 	s.sessions = append(s.sessions, session)
 	session.Run()
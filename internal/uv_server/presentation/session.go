@@ -1,19 +1,21 @@
 package presentation
 
 import (
-	"context"
+	"encoding/json"
 	"time"
 
 	"errors"
 	"net"
-	"net/http"
 	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 
 	"uv_server/internal/uv_protocol"
+	cjmessages "uv_server/internal/uv_server/business/common_job_messages"
+	"uv_server/internal/uv_server/common"
 	"uv_server/internal/uv_server/common/loggers"
+	"uv_server/internal/uv_server/common/metrics"
 	"uv_server/internal/uv_server/config"
 	"uv_server/internal/uv_server/presentation/job"
 )
@@ -31,9 +33,16 @@ type Session struct {
 	conn    *websocket.Conn
 	peer    string
 	builder *JobBuilder
-	srv     *http.Server
+	tracker *JobTracker
+	// stopServer starts the server shutdown
+	stopServer func()
 
 	job_out chan *job.Message
+	// Guards job_out against messages sent after the session is closed
+	out_mx sync.Mutex
+	closed bool
+	// Closed when writePump exits
+	written chan struct{}
 
 	jobs_mx sync.Mutex
 	jobs    map[string]*job.Job
@@ -44,7 +53,8 @@ func NewSession(
 	conn *websocket.Conn,
 	peer string,
 	builder *JobBuilder,
-	srv *http.Server,
+	tracker *JobTracker,
+	stopServer func(),
 ) *Session {
 	object := &Session{}
 
@@ -54,11 +64,13 @@ func NewSession(
 	object.peer = peer
 	object.builder = builder
 	object.job_out = make(chan *job.Message, messageLimit)
+	object.written = make(chan struct{})
 
 	object.jobs_mx = sync.Mutex{}
 	object.jobs = make(map[string]*job.Job)
 
-	object.srv = srv
+	object.tracker = tracker
+	object.stopServer = stopServer
 
 	return object
 }
@@ -66,6 +78,7 @@ func NewSession(
 func (s *Session) readPump() {
 	defer func() {
 		s.conn.Close()
+		metrics.SessionsActive.Dec()
 	}()
 
 	for {
@@ -76,10 +89,7 @@ func (s *Session) readPump() {
 				s.log.Infof("connection from %s is closed %T: %v", s.peer, err, err)
 
 				if !s.config.AllowClientReconnect {
-					err = s.srv.Shutdown(context.TODO())
-					if err != nil {
-						s.log.Fatalf("failed to shut server down gracefully: %v", err)
-					}
+					s.stopServer()
 				}
 
 				return
@@ -88,10 +98,7 @@ func (s *Session) readPump() {
 					s.log.Infof("connection from %s is closed %T: %v", s.peer, err, err)
 
 					if !s.config.AllowClientReconnect {
-						err = s.srv.Shutdown(context.TODO())
-						if err != nil {
-							s.log.Fatalf("failed to shut server down gracefully: %v", err)
-						}
+						s.stopServer()
 					}
 
 					return
@@ -135,7 +142,17 @@ func (s *Session) handleIncomingMessage(raw_msg []byte) {
 			return
 		}
 
-		go job.Run(msg)
+		if !s.tracker.Add(*msg.Header.Uuid, msg.Header.Type.String()) {
+			s.log.Debugf("refusing job during shutdown: %v", *msg.Header.Uuid)
+			metrics.JobsTotal.WithLabelValues(msg.Header.Type.String(), metrics.JobRejected).Inc()
+			s.refuse(*msg.Header.Uuid)
+			return
+		}
+
+		go func() {
+			defer s.tracker.Done(*msg.Header.Uuid)
+			job.Run(msg)
+		}()
 
 		s.jobs_mx.Lock()
 		s.jobs[*msg.Header.Uuid] = job
@@ -143,11 +160,58 @@ func (s *Session) handleIncomingMessage(raw_msg []byte) {
 	}
 }
 
+// refuse answers a request which is not going to be served, there is
+// no job to remove so the message does not finish one
+func (s *Session) refuse(uuid string) {
+	payload, err := json.Marshal(cjmessages.Error{Reason: common.ErrShutdown.Error()})
+	if err != nil {
+		s.log.Fatalf("failed to serialize message: %v", err)
+	}
+
+	s.out_mx.Lock()
+	defer s.out_mx.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.job_out <- &job.Message{
+		Msg: &uv_protocol.Message{
+			Header: &uv_protocol.Header{
+				Uuid: &uuid,
+				Type: uv_protocol.Error,
+			},
+			Payload: payload,
+		},
+		Done: false,
+	}
+}
+
 func (s *Session) writePump() {
-	defer func() {
-		s.conn.Close()
-	}()
+	defer close(s.written)
+
+	graceful := s.write()
+
+	if graceful {
+		err := s.conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, common.ErrShutdown.Error()),
+			time.Now().Add(5*time.Second))
+		if err != nil {
+			s.log.Debugf("failed to send close message: %v", err)
+		}
+	}
+
+	s.conn.Close()
+
+	// Jobs still running after the connection is lost must not block
+	for range s.job_out {
+	}
+}
 
+// write sends job messages to the client until the session is closed,
+// false is returned when the connection fails
+func (s *Session) write() bool {
 	for j_message := range s.job_out {
 		if j_message.Done {
 			s.jobs_mx.Lock()
@@ -166,7 +230,8 @@ func (s *Session) writePump() {
 
 		w, err := s.conn.NextWriter(websocket.BinaryMessage)
 		if err != nil {
-			s.log.Fatal(err)
+			s.log.Errorf("failed to start message: %v", err)
+			return false
 		}
 
 		message := j_message.Msg
@@ -183,12 +248,27 @@ func (s *Session) writePump() {
 		}
 
 		if err := w.Close(); err != nil {
-			return
+			return false
 		}
 	}
+
+	return true
 }
 
 func (s *Session) Run() {
+	metrics.SessionsActive.Inc()
+
 	go s.readPump()
 	go s.writePump()
 }
+
+// Close sends the queued messages and closes the connection, jobs
+// of the session must be finished before
+func (s *Session) Close() {
+	s.out_mx.Lock()
+	s.closed = true
+	close(s.job_out)
+	s.out_mx.Unlock()
+
+	<-s.written
+}
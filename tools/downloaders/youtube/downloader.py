@@ -1,3 +1,12 @@
+"""Downloads audio with yt-dlp for uv_server.
+
+Messages are written to stdout as JSON, one per line, the protocol is
+defined in internal/uv_server/data/downloaders/child_protocol.go and
+both sides have to be changed together. The first message is hello,
+the last one is done or failed. Tracebacks and other noise go to stderr,
+the server adds its end to the failure reason.
+"""
+
 from sys import argv
 from json import dumps
 from argparse import ArgumentParser
@@ -5,10 +14,33 @@ from argparse import ArgumentParser
 import yt_dlp
 import re
 import sys
+import traceback
+
+PROTOCOL_VERSION = 1
+
+MESSAGE_HELLO = "hello"
+MESSAGE_METADATA = "metadata"
+MESSAGE_PROGRESS = "progress"
+MESSAGE_LOG = "log"
+MESSAGE_WARNING = "warning"
+MESSAGE_DONE = "done"
+MESSAGE_FAILED = "failed"
+
+PHASE_FETCHING_METADATA = "fetching_metadata"
+PHASE_DOWNLOADING = "downloading"
+PHASE_POST_PROCESSING = "post_processing"
+
+LOG_DEBUG = "debug"
+LOG_INFO = "info"
+
+def send(type: str, **fields):
+    message = {
+        "type": type,
+        # Values yt-dlp does not know are None
+        **{key: value for key, value in fields.items() if value is not None}
+    }
 
-DOWNLOADING_PROGRESS = 1
-DOWNLOADING_DONE = 2
-DOWNLOADING_FAILED = 3
+    print(dumps(message), flush=True)
 
 def extract_youtube_error(message: str) -> str:
     message = re.sub(r'\x1b\[[0-9;]*m', '', message)
@@ -19,39 +51,49 @@ def extract_youtube_error(message: str) -> str:
 
 class Logger:
     def debug(self, msg):
-        pass
+        # yt-dlp passes both debug and info messages here,
+        # debug ones are prefixed
+        if msg.startswith("[debug] "):
+            send(MESSAGE_LOG, level=LOG_DEBUG, msg=msg)
+        else:
+            self.info(msg)
 
     def info(self, msg):
-        pass
+        send(MESSAGE_LOG, level=LOG_INFO, msg=msg)
+
+    def warning(self, msg):
+        send(MESSAGE_WARNING, msg=msg)
 
     @staticmethod
     def error(msg):
-        msg = extract_youtube_error(str(msg))
-        error = {
-            "type": DOWNLOADING_FAILED,
-            "msg": msg
-        }
-
-        print(dumps(error), flush=True)
+        print(msg, file=sys.stderr, flush=True)
+        send(MESSAGE_FAILED, msg=extract_youtube_error(str(msg)))
         sys.exit(-1)
 
 
-def download_file(url: str, dir: str, ffmpeg_location: str):
+def download_file(url: str, dir: str, format: str, ffmpeg_location: str, limit_rate: int):
     filename = str()
 
     def progress_hook(data):
         if data['status'] == 'finished':
             nonlocal filename
             filename = data['filename']
-        else:
-            progress = {
-                "type": DOWNLOADING_PROGRESS,
-                "percentage": data["downloaded_bytes"] / data["total_bytes"] * 100
-            }
-            
-            # data["speed"]
-            
-            print(dumps(progress), flush=True)
+        elif data['status'] == 'downloading':
+            downloaded = data.get("downloaded_bytes") or 0
+            total = data.get("total_bytes") or data.get("total_bytes_estimate")
+
+            send(
+                MESSAGE_PROGRESS,
+                phase=PHASE_DOWNLOADING,
+                percentage=downloaded / total * 100 if total else None,
+                downloaded_bytes=int(downloaded),
+                total_bytes=int(total) if total else None,
+                speed=data.get("speed"),
+                eta=data.get("eta"))
+
+    def postprocessor_hook(data):
+        if data['status'] == 'started':
+            send(MESSAGE_PROGRESS, phase=PHASE_POST_PROCESSING)
 
     ydl_opts = {
         "format": "bestaudio/best",
@@ -59,48 +101,78 @@ def download_file(url: str, dir: str, ffmpeg_location: str):
         "postprocessors": [
             {
                 "key": "FFmpegExtractAudio",
-                'preferredcodec': 'mp3',
+                'preferredcodec': format,
             }
         ],
         "ffmpeg_location": ffmpeg_location,
         'logger': Logger(),
+        # Progress is reported by the hooks
+        'noprogress': True,
         'progress_hooks': [progress_hook],
+        'postprocessor_hooks': [postprocessor_hook],
     }
 
+    if limit_rate > 0:
+        ydl_opts["ratelimit"] = limit_rate
+
+    send(MESSAGE_PROGRESS, phase=PHASE_FETCHING_METADATA)
+
     with yt_dlp.YoutubeDL(ydl_opts) as ydl:
-        error_code = ydl.download(url)
-    
-    return filename[:filename.rfind('.')] + '.mp3'
+        info = ydl.extract_info(url, download=True)
+
+    send(
+        MESSAGE_METADATA,
+        title=info.get("title") or "",
+        uploader=info.get("uploader") or "",
+        id=info.get("id") or "",
+        upload_date=info.get("upload_date") or "",
+        duration=info.get("duration") or 0)
+
+    return filename[:filename.rfind('.')] + '.' + format
 
 if __name__ == "__main__":
     parser = ArgumentParser()
 
+    parser.add_argument(
+        "--version", action="version", version=yt_dlp.version.__version__,
+        help="Print yt-dlp version and exit")
+
     parser.add_argument(
         "--url", type=str, nargs=1, required=True,
         help="Url to the file to be downloaded")
-    
+
     parser.add_argument(
         "--dir", type=str, nargs=1, required=True,
         help="Directory to store the file")
-    
+
+    parser.add_argument(
+        "--format", type=str, nargs=1, default=["mp3"],
+        help="Audio format of the resulting file")
+
     parser.add_argument(
         "--ffmpeg_location", type=str, nargs=1, required=True,
         help="ffmpeg location")
-    
+
+    parser.add_argument(
+        "--limit_rate", type=int, nargs=1, default=[0],
+        help="Download rate limit in bytes per second, 0 means unlimited")
+
     namespace = parser.parse_args(argv[1:])
-    
+
+    send(
+        MESSAGE_HELLO,
+        version=PROTOCOL_VERSION,
+        tool_version=yt_dlp.version.__version__)
+
     try:
         filename = download_file(
             namespace.url[0],
             namespace.dir[0],
-            namespace.ffmpeg_location[0])
-        
-        progress = {
-            "type": DOWNLOADING_DONE,
-            "filename": filename
-        }
-    
-        print(dumps(progress), flush=True)
-    except Exception:
-        pass
-    
\ No newline at end of file
+            namespace.format[0],
+            namespace.ffmpeg_location[0],
+            namespace.limit_rate[0])
+
+        send(MESSAGE_DONE, filename=filename)
+    except Exception as e:
+        traceback.print_exc()
+        send(MESSAGE_FAILED, msg=str(e) or type(e).__name__)